@@ -1,10 +1,16 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"log"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
+	"github.com/jbpratt/bots/internal/trivia"
 	"github.com/jbpratt/bots/internal/triviabot"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -17,9 +23,58 @@ func main() {
 	lvl := zap.LevelFlag("v", zapcore.InfoLevel, "set the log level")
 	leaderboardPage := flag.String("html", "/tmp/leaderboard/index.html", "path to output generated leaderboard page")
 	leaderboardIngress := flag.String("ingress", "https://leaderboard.jbpratt.xyz", "leaderboard ingress URL")
+	questionsFile := flag.String("questions", "", "path to a JSON file of questions to ask instead of those in the database")
+	apiAddr := flag.String("api", "", "address to serve the JSON leaderboard and status api on, disabled if empty")
+	maxQuiz := flag.Duration("max-quiz", 0, "end quizzes still running after this long, disabled if 0")
+	questionCooldown := flag.Duration("question-cooldown", 0, "keep questions out of quizzes for this long after they were asked, disabled if 0")
+	maxQuizzes := flag.Int("max-quizzes", 0, "channels which may run a quiz at once, unlimited if 0")
+	publicAnswers := flag.Bool("public-answers", false, "take answers typed in chat instead of whispers")
+	judges := flag.String("judges", "", "comma separated users to whisper each round's answer to")
+	countdown := flag.String("countdown", "", "comma separated time left in each round to announce, like 20s,10s,5s, disabled if empty")
+	doubleChance := flag.Float64("double-chance", 0, "chance of each round being worth double points, from 0 to 1")
+	finalRoundLabel := flag.String("final-round-label", "Final round", "what the last round is called when asked, numbered like any other if empty")
+	textAnswers := flag.Bool("text-answers", true, "accept whispering the text of an answer in place of its number")
+	cooldownBypass := flag.String("cooldown-bypass", "", "comma separated users who skip the cooldown between quizzes, including every mod with \"mods\"")
+	changeAnswers := flag.Bool("change-answers", false, "let players change their answer until the round closes")
+	distinctQuestions := flag.Bool("distinct-questions", false, "never ask a question twice in one quiz, cutting it short if the questions run out")
+	freshness := flag.Float64("freshness", 0, "draw questions at random favoring fresher ones, more strongly the higher it is, asked in sequence if 0")
+	grace := flag.Duration("grace", 0, "keep accepting answers for this long after a round closes, for slow chat, disabled if 0")
+	gracePoints := flag.Int("grace-points", 0, "points a correct answer given during -grace scores")
+	burstSize := flag.Int("burst-size", 0, "flag this many answers to a round arriving within -burst-window of each other as suspicious, disabled if under 2")
+	burstWindow := flag.Duration("burst-window", 0, "how close together answers must arrive to count towards a burst, only identical timestamps if 0")
+	pointsDecay := flag.Float64("points-decay", 0, "fraction of their points players lose per day they don't play, from 0 to 1")
+	rateLimitPause := flag.Duration("rate-limit-pause", 0, "time to hold back messages once the chat server rate limits the bot, 2s if 0")
+	pollDuration := flag.Duration("poll-duration", 0, "time to collect votes on the category of a quiz polled in chat, 30s if 0")
+	speedDuration := flag.Duration("speed-duration", 0, "time to answer each round of a speed quiz, 10s if 0")
+	skipVotes := flag.Int("skip-votes", 0, "players who must vote to skip a round, 3 if 0")
+	requeueSkipped := flag.Bool("requeue-skipped", false, "ask skipped questions again at the end of the quiz rather than revealing their answer")
+	manualAdvance := flag.Bool("manual-advance", false, "wait between rounds until an admin types \"trivia next\", for hosted events")
+	roundDelayJitter := flag.Float64("round-delay-jitter", 0, "vary the delay between rounds by up to this fraction either way, at most 0.5, fixed if 0")
+	quiet := flag.Bool("quiet", false, "only send the questions, answers and results of quizzes, until turned off in chat")
+	maxQuestionLength := flag.Int("max-question-length", 0, "cut questions longer than this many characters short, pointing to the repeat command for the rest, unlimited if 0")
+	minOddsAnswers := flag.Int("min-odds-answers", 0, "only reveal the odds of rounds at least this many players answered")
+	attribution := flag.Bool("attribution", false, "credit where each question was collected from when asking it")
+	archiveQuizzes := flag.Bool("archive-quizzes", false, "store finished quizzes, as their answers were shown, to recap them after a restart")
+	answerLog := flag.String("answer-log", "", "file to append how many picked each choice of every round to, without names, as JSON lines")
+	answerCounts := flag.Bool("answer-counts", false, "add how many players answered each round, and how many correctly, to its results")
+	answerFeedback := flag.Bool("answer-feedback", false, "whisper everyone who answered a round whether they got it right once it closes")
+	scoringBreakdown := flag.Bool("scoring-breakdown", false, "explain how points are scored when a quiz starts")
+	createIndexes := flag.Bool("create-indexes", false, "create the indexes recommended for large question banks")
+	allCorrect := flag.String("all-correct", "ranked", "how to score rounds everyone answered correctly, ranked by speed or a flat point each")
+	ties := flag.String("ties", "arrival", "how to rank correct answers sent at the same time, by arrival or shared positions and points")
+	answerCase := flag.String("answer-case", "original", "how to case answers when shown, as imported (original), lower or title")
+	configPath := flag.String("config", "", "path to a JSON config file, which flags given explicitly override")
 
 	flag.Parse()
 
+	// with a config file only the flags given explicitly apply, so their
+	// defaults don't override it
+	set := map[string]bool{}
+	flag.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	useFlag := func(name string) bool {
+		return *configPath == "" || set[name]
+	}
+
 	if *dev {
 		serverURL = "wss://chat2.strims.gg/ws"
 	}
@@ -38,20 +93,256 @@ func main() {
 		}
 	}()
 
-	url, jwt := os.Getenv("STRIMS_CHAT_WSS_URL"), os.Getenv("STRIMS_CHAT_TOKEN")
-	if url == "" {
-		url = serverURL
+	cfg := triviabot.Config{}
+	if *configPath != "" {
+		var err error
+		if cfg, err = triviabot.LoadConfig(*configPath); err != nil {
+			logger.Fatal(err.Error())
+		}
+	}
+
+	if url := os.Getenv("STRIMS_CHAT_WSS_URL"); url != "" {
+		cfg.URL = url
+	}
+	if cfg.URL == "" {
+		cfg.URL = serverURL
+	}
+	if jwt := os.Getenv("STRIMS_CHAT_TOKEN"); jwt != "" {
+		cfg.JWT = jwt
 	}
-	if jwt == "" {
+	if cfg.JWT == "" {
 		logger.Fatal("must provide $STRIMS_CHAT_TOKEN")
 	}
 
-	triviabot, err := triviabot.New(logger.Sugar(), url, jwt, *dbPath, *leaderboardPage, *leaderboardIngress)
+	if cfg.DBPath == "" || set["db"] {
+		cfg.DBPath = *dbPath
+	}
+	if cfg.LeaderboardPage == "" || set["html"] {
+		cfg.LeaderboardPage = *leaderboardPage
+	}
+	if cfg.LeaderboardIngress == "" || set["ingress"] {
+		cfg.LeaderboardIngress = *leaderboardIngress
+	}
+
+	opts := []triviabot.Option{}
+	if *questionsFile != "" {
+		source, err := trivia.NewFileSource(*questionsFile)
+		if err != nil {
+			logger.Fatal(err.Error())
+		}
+		opts = append(opts, triviabot.WithSource(source))
+	}
+
+	if *judges != "" {
+		opts = append(opts, triviabot.WithJudges(strings.Split(*judges, ",")...))
+	}
+
+	if *publicAnswers {
+		opts = append(opts, triviabot.WithPublicAnswers())
+	}
+
+	if *maxQuiz > 0 {
+		opts = append(opts, triviabot.WithMaxQuizDuration(*maxQuiz))
+	}
+
+	if *questionCooldown > 0 {
+		opts = append(opts, triviabot.WithQuestionCooldown(*questionCooldown))
+	}
+
+	if *maxQuizzes > 0 {
+		opts = append(opts, triviabot.WithMaxQuizzes(*maxQuizzes))
+	}
+
+	if useFlag("answer-case") {
+		casing, err := triviabot.ParseAnswerCase(*answerCase)
+		if err != nil {
+			logger.Fatal(err.Error())
+		}
+		opts = append(opts, triviabot.WithAnswerCase(casing))
+	}
+
+	if useFlag("all-correct") {
+		scoring, err := trivia.ParseAllCorrectScoring(*allCorrect)
+		if err != nil {
+			logger.Fatal(err.Error())
+		}
+		opts = append(opts, triviabot.WithAllCorrectScoring(scoring))
+	}
+
+	if useFlag("ties") {
+		scoring, err := trivia.ParseTieScoring(*ties)
+		if err != nil {
+			logger.Fatal(err.Error())
+		}
+		opts = append(opts, triviabot.WithTieScoring(scoring))
+	}
+
+	if *countdown != "" {
+		remaining := []time.Duration{}
+		for _, mark := range strings.Split(*countdown, ",") {
+			d, err := time.ParseDuration(mark)
+			if err != nil {
+				logger.Fatal("invalid -countdown: " + err.Error())
+			}
+			remaining = append(remaining, d)
+		}
+		opts = append(opts, triviabot.WithCountdown(remaining...))
+	}
+
+	if *doubleChance > 0 {
+		opts = append(opts, triviabot.WithDoublePoints(*doubleChance))
+	}
+
+	if useFlag("final-round-label") {
+		opts = append(opts, triviabot.WithFinalRoundLabel(*finalRoundLabel))
+	}
+
+	if useFlag("text-answers") {
+		opts = append(opts, triviabot.WithTextAnswers(*textAnswers))
+	}
+
+	if *cooldownBypass != "" {
+		mods, users := false, []string{}
+		for _, user := range strings.Split(*cooldownBypass, ",") {
+			if user == "mods" {
+				mods = true
+			} else {
+				users = append(users, user)
+			}
+		}
+		opts = append(opts, triviabot.WithCooldownBypass(mods, users...))
+	}
+
+	if *changeAnswers {
+		opts = append(opts, triviabot.WithAnswerChanges())
+	}
+
+	if *distinctQuestions {
+		opts = append(opts, triviabot.WithDistinctQuestions())
+	}
+
+	if *freshness > 0 {
+		opts = append(opts, triviabot.WithFreshness(*freshness))
+	}
+
+	if *grace > 0 {
+		opts = append(opts, triviabot.WithGracePeriod(*grace, *gracePoints))
+	}
+
+	if *burstSize > 1 {
+		opts = append(opts, triviabot.WithBurstDetection(*burstSize, *burstWindow))
+	}
+
+	if *pointsDecay > 0 {
+		opts = append(opts, triviabot.WithPointsDecay(*pointsDecay))
+	}
+
+	if *rateLimitPause > 0 {
+		opts = append(opts, triviabot.WithRateLimitPause(*rateLimitPause))
+	}
+
+	if *pollDuration > 0 {
+		opts = append(opts, triviabot.WithPollDuration(*pollDuration))
+	}
+
+	if *speedDuration > 0 {
+		opts = append(opts, triviabot.WithSpeedDuration(*speedDuration))
+	}
+
+	if *skipVotes > 0 {
+		opts = append(opts, triviabot.WithSkipVotes(*skipVotes))
+	}
+
+	if *requeueSkipped {
+		opts = append(opts, triviabot.WithRequeueSkipped())
+	}
+
+	if *manualAdvance {
+		opts = append(opts, triviabot.WithManualAdvance())
+	}
+
+	if *roundDelayJitter > 0 {
+		opts = append(opts, triviabot.WithRoundDelayJitter(*roundDelayJitter))
+	}
+
+	if *quiet {
+		opts = append(opts, triviabot.WithQuietMode())
+	}
+
+	if *maxQuestionLength > 0 {
+		opts = append(opts, triviabot.WithMaxQuestionLength(*maxQuestionLength))
+	}
+
+	if *minOddsAnswers > 0 {
+		opts = append(opts, triviabot.WithMinOddsAnswers(*minOddsAnswers))
+	}
+
+	if *attribution {
+		opts = append(opts, triviabot.WithAttribution())
+	}
+
+	if *archiveQuizzes {
+		opts = append(opts, triviabot.WithQuizArchive())
+	}
+
+	if *answerLog != "" {
+		opts = append(opts, triviabot.WithAnswerLog(*answerLog))
+	}
+
+	if *answerCounts {
+		opts = append(opts, triviabot.WithAnswerCounts())
+	}
+
+	if *scoringBreakdown {
+		opts = append(opts, triviabot.WithScoringBreakdown())
+	}
+
+	if *answerFeedback {
+		opts = append(opts, triviabot.WithAnswerFeedback())
+	}
+
+	if *createIndexes {
+		opts = append(opts, triviabot.WithIndexes())
+	}
+
+	if *apiAddr != "" {
+		opts = append(opts, triviabot.WithAPI(*apiAddr))
+	}
+
+	cfg.Options = opts
+	triviabot, err := triviabot.NewFromConfig(logger.Sugar(), cfg)
 	if err != nil {
 		logger.Fatal(err.Error())
 	}
 
-	if err = triviabot.Run(); err != nil {
+	// deploys stop the bot with a signal, so quizzes are scored and the
+	// leaderboard written before it exits
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- triviabot.Run()
+	}()
+
+	select {
+	case err = <-done:
+		if err != nil {
+			logger.Fatal(err.Error())
+		}
+	case <-ctx.Done():
+		logger.Info("shutting down")
+	}
+	// a second signal exits at once
+	stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err = triviabot.Close(ctx); err != nil {
 		logger.Fatal(err.Error())
 	}
 }
+
+// shutdownTimeout is how long the running quizzes have to be scored on
+// shutdown.
+const shutdownTimeout = 30 * time.Second
@@ -4,11 +4,14 @@ package bot
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html"
 	"net/http"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
@@ -27,11 +30,27 @@ var (
 	ViewerStateFilter MsgTypeFilter = "VIEWERSTATE"
 )
 
+// ErrRateLimited is returned by the first send after the server reported the
+// bot as throttled, dropping one of its messages. The send which returns it is
+// not delivered either, so the caller can back off before retrying it. The
+// dropped message is sent again ahead of the next send.
+var ErrRateLimited = errors.New("rate limited by the server")
+
 type Bot struct {
-	logger         *zap.SugaredLogger
-	conn           *websocket.Conn
-	reconnect      bool
-	lastSentMsg    string
+	logger      *zap.SugaredLogger
+	conn        *websocket.Conn
+	reconnect   bool
+	destroyed   atomic.Bool
+	rateLimited atomic.Bool
+	// sendMu guards lastSentMsg, as rooms send to their channels
+	// concurrently.
+	sendMu      sync.Mutex
+	lastSentMsg map[string]string
+	// writeMu guards lastWritten, the payload last written to the server,
+	// and dropped, the payload the server throttled to be written again.
+	writeMu        sync.Mutex
+	lastWritten    string
+	dropped        string
 	url            string
 	token          string
 	filters        []MsgTypeFilter
@@ -43,6 +62,7 @@ type Msg struct {
 	Kind     string   `json:"-"`
 	Data     string   `json:"data"`
 	User     string   `json:"nick,omitempty"`
+	Channel  string   `json:"channel,omitempty"`
 	Time     int64    `json:"timestamp,omitempty"`
 	Features []string `json:"features,omitempty"`
 }
@@ -54,11 +74,12 @@ func New(
 	filters ...MsgTypeFilter,
 ) (*Bot, error) {
 	b := &Bot{
-		logger:    logger,
-		reconnect: reconnect,
-		filters:   filters,
-		url:       url,
-		token:     jwt,
+		logger:      logger,
+		reconnect:   reconnect,
+		lastSentMsg: map[string]string{},
+		filters:     filters,
+		url:         url,
+		token:       jwt,
 	}
 	if err := b.dial(url, jwt); err != nil {
 		return nil, fmt.Errorf("failed to create bot: %w", err)
@@ -83,12 +104,24 @@ func (b *Bot) dial(url, jwt string) error {
 }
 
 func (b *Bot) Send(msg string) error {
-	if msg == b.lastSentMsg {
+	return b.SendChannel(msg, "")
+}
+
+// SendChannel sends msg to channel, or to the server's default channel when
+// channel is empty.
+func (b *Bot) SendChannel(msg, channel string) error {
+	// held until sent, so the same message sent twice at once is still
+	// told apart
+	b.sendMu.Lock()
+	defer b.sendMu.Unlock()
+
+	if msg == b.lastSentMsg[channel] {
 		msg += " ."
 	}
 
 	marsha, err := json.Marshal(&Msg{
-		Data: strings.ReplaceAll(html.UnescapeString(msg), "\"", "'"),
+		Data:    strings.ReplaceAll(html.UnescapeString(msg), "\"", "'"),
+		Channel: channel,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to marshal output message: %w", err)
@@ -98,7 +131,7 @@ func (b *Bot) Send(msg string) error {
 		return fmt.Errorf("failed to send message %q: %w", msg, err)
 	}
 
-	b.lastSentMsg = msg
+	b.lastSentMsg[channel] = msg
 
 	return nil
 }
@@ -146,7 +179,7 @@ func (b *Bot) Run() error {
 		for {
 			_, data, err := b.conn.Read(ctx)
 			if err != nil {
-				if b.reconnect {
+				if b.reconnect && !b.destroyed.Load() {
 					return b.dial(b.url, b.token)
 				}
 				return fmt.Errorf("failed while reading message: %w", err)
@@ -167,6 +200,11 @@ func (b *Bot) Run() error {
 
 			msg, err := parseMsg(raw)
 			if err != nil {
+				if errors.Is(err, ErrRateLimited) {
+					b.logger.Warnw("rate limited by the server", "err", err)
+					b.dropLastWritten()
+					continue
+				}
 				b.logger.Infow("failed to parse message", "err", err)
 				continue
 			}
@@ -198,7 +236,12 @@ func (b *Bot) Run() error {
 	return nil
 }
 
+// Destroy closes the connection for good, so it is not reconnected. Only the
+// first call has any effect.
 func (b *Bot) Destroy() error {
+	if b.destroyed.Swap(true) {
+		return nil
+	}
 	b.logger.Info("self destruction initiated")
 	return b.conn.Close(websocket.StatusNormalClosure, "going away")
 }
@@ -209,6 +252,9 @@ func parseMsg(raw string) (*Msg, error) {
 		if err != nil {
 			return nil, fmt.Errorf("failed to unquote string: %w", err)
 		}
+		if value == "throttled" {
+			return nil, fmt.Errorf("server returned error: %w", ErrRateLimited)
+		}
 		return nil, fmt.Errorf("server returned error: %s", value)
 	}
 
@@ -228,11 +274,43 @@ func parseMsg(raw string) (*Msg, error) {
 	return out, nil
 }
 
+// dropLastWritten records the payload last written as dropped by the server,
+// refusing the next send so the caller backs off before it is written again.
+func (b *Bot) dropLastWritten() {
+	b.writeMu.Lock()
+	defer b.writeMu.Unlock()
+	if b.lastWritten != "" {
+		b.dropped = b.lastWritten
+	}
+	b.rateLimited.Store(true)
+}
+
 func (b *Bot) send(msg string) error {
+	b.writeMu.Lock()
+	defer b.writeMu.Unlock()
+
+	if b.rateLimited.Swap(false) {
+		return ErrRateLimited
+	}
+
+	if b.dropped != "" {
+		b.logger.Debugw("sending dropped message again", "msg", b.dropped)
+		if err := b.write(b.dropped); err != nil {
+			return err
+		}
+		b.dropped = ""
+	}
+
 	b.logger.Debugw("sending message", "msg", msg)
+	return b.write(msg)
+}
+
+// write writes msg to the server. The caller must hold writeMu.
+func (b *Bot) write(msg string) error {
 	if err := b.conn.Write(context.Background(), websocket.MessageText, []byte(msg)); err != nil {
 		return fmt.Errorf("failed to write message: %w", err)
 	}
+	b.lastWritten = msg
 	return nil
 }
 
@@ -1,9 +1,127 @@
 package bot_test
 
-import "testing"
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jbpratt/bots/internal/bot"
+	"go.uber.org/zap"
+	"nhooyr.io/websocket"
+)
 
 func TestAdd(t *testing.T) {
 	if 1+1 != 2 {
 		t.Error("failed horribly")
 	}
 }
+
+func TestSendChannelConcurrently(t *testing.T) {
+	received := make(chan string, 100)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.CloseNow()
+		for {
+			_, data, err := conn.Read(r.Context())
+			if err != nil {
+				return
+			}
+			received <- string(data)
+		}
+	}))
+	defer server.Close()
+
+	b, err := bot.New(zap.NewNop().Sugar(), "ws"+strings.TrimPrefix(server.URL, "http"), "token", false)
+	if err != nil {
+		t.Fatalf("failed to create bot: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		channel := fmt.Sprintf("channel%d", i)
+		for j := 0; j < 5; j++ {
+			wg.Add(1)
+			go func(j int) {
+				defer wg.Done()
+				if err := b.SendChannel(fmt.Sprintf("message %d", j), channel); err != nil {
+					t.Errorf("failed to send to %s: %v", channel, err)
+				}
+			}(j)
+		}
+	}
+	wg.Wait()
+
+	for i := 0; i < 20; i++ {
+		select {
+		case <-received:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("only %d of 20 messages arrived", i)
+		}
+	}
+}
+
+func TestSendAgainAfterThrottled(t *testing.T) {
+	received := make(chan string, 100)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.CloseNow()
+		for i := 0; ; i++ {
+			_, data, err := conn.Read(r.Context())
+			if err != nil {
+				return
+			}
+			received <- string(data)
+			// the first message is dropped
+			if i == 0 {
+				if err = conn.Write(r.Context(), websocket.MessageText, []byte(`ERR "throttled"`)); err != nil {
+					return
+				}
+			}
+		}
+	}))
+	defer server.Close()
+
+	b, err := bot.New(zap.NewNop().Sugar(), "ws"+strings.TrimPrefix(server.URL, "http"), "token", false)
+	if err != nil {
+		t.Fatalf("failed to create bot: %v", err)
+	}
+	defer b.Destroy()
+	go b.Run()
+
+	if err = b.Send("one"); err != nil {
+		t.Fatalf("failed to send: %v", err)
+	}
+	<-received
+	// wait for the server's reply to be read
+	time.Sleep(100 * time.Millisecond)
+
+	if err = b.Send("two"); !errors.Is(err, bot.ErrRateLimited) {
+		t.Fatalf("expected the send after being throttled to be refused, got %v", err)
+	}
+	if err = b.Send("two"); err != nil {
+		t.Fatalf("failed to send after backing off: %v", err)
+	}
+
+	want := []string{"one", "two"}
+	for _, data := range want {
+		select {
+		case got := <-received:
+			if !strings.Contains(got, `"data":"`+data+`"`) {
+				t.Errorf("expected %q to be sent, got %s", data, got)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for %q", data)
+		}
+	}
+}
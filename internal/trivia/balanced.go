@@ -0,0 +1,52 @@
+package trivia
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+)
+
+// balancedSource spreads questions across categories, see NewBalancedSource.
+type balancedSource struct {
+	source     FilterableSource
+	filter     Filter
+	categories []string
+	next       int
+}
+
+// NewBalancedSource returns a Source asking one question of each category of
+// source, in a random order, before asking another of any. Questions are
+// otherwise drawn as filter allows, whose category is ignored. Categories
+// without matching questions are skipped.
+func NewBalancedSource(source FilterableSource, filter Filter) (Source, error) {
+	categories, err := source.Categories()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list categories: %w", err)
+	}
+	rand.Shuffle(len(categories), func(i, j int) {
+		categories[i], categories[j] = categories[j], categories[i]
+	})
+
+	filter.Category = ""
+	return &balancedSource{source: source, filter: filter, categories: categories}, nil
+}
+
+func (s *balancedSource) Question() (*Question, error) {
+	if len(s.categories) == 0 {
+		return s.source.Filtered(s.filter).Question()
+	}
+
+	for range s.categories {
+		filter := s.filter
+		filter.Category = s.categories[s.next%len(s.categories)]
+		s.next++
+
+		question, err := s.source.Filtered(filter).Question()
+		if errors.Is(err, ErrNoQuestions) {
+			continue
+		}
+		return question, err
+	}
+
+	return nil, fmt.Errorf("%w matching %s in any category", ErrNoQuestions, s.filter)
+}
@@ -0,0 +1,95 @@
+package trivia
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// CanonicalCategory normalizes the spelling of a category name, as sources
+// differ in casing and whitespace, like "history " and "History". Surrounding
+// and repeated whitespace is dropped and the name starts with a capital letter.
+// Names differing only in the case of later letters are the same category, see
+// canonicalSpellings.
+func CanonicalCategory(name string) string {
+	name = strings.Join(strings.Fields(name), " ")
+	first, size := utf8.DecodeRuneInString(name)
+	if size == 0 {
+		return ""
+	}
+	return string(unicode.ToUpper(first)) + name[size:]
+}
+
+// canonicalSpellings maps every spelling in counts, the number of questions
+// of each category, to the canonical one of its category. Spellings which
+// only differ in case once canonical are the same category, spelled the way
+// most questions spell it, or the first in sort order on a tie.
+func canonicalSpellings(counts map[string]int) map[string]string {
+	spellings := []string{}
+	for spelling := range counts {
+		spellings = append(spellings, spelling)
+	}
+	sort.Slice(spellings, func(i, j int) bool {
+		if counts[spellings[i]] != counts[spellings[j]] {
+			return counts[spellings[i]] > counts[spellings[j]]
+		}
+		return spellings[i] < spellings[j]
+	})
+
+	byKey := map[string]string{}
+	canonical := map[string]string{}
+	for _, spelling := range spellings {
+		name := CanonicalCategory(spelling)
+		key := strings.ToLower(name)
+		if _, ok := byKey[key]; !ok {
+			byKey[key] = name
+		}
+		canonical[spelling] = byKey[key]
+	}
+	return canonical
+}
+
+// canonicalizeCategories respells the categories of the imported questions
+// canonically, so questions of one category are filtered and counted together.
+// Categories left empty are cleared.
+func canonicalizeCategories(ctx context.Context, db *sql.DB) error {
+	rows, err := db.QueryContext(ctx, "SELECT category, COUNT(*) FROM questions WHERE category IS NOT NULL GROUP BY category")
+	if err != nil {
+		return fmt.Errorf("failed to query categories: %w", err)
+	}
+	defer rows.Close()
+
+	counts := map[string]int{}
+	for rows.Next() {
+		var (
+			category string
+			count    int
+		)
+		if err = rows.Scan(&category, &count); err != nil {
+			return fmt.Errorf("failed to scan category: %w", err)
+		}
+		counts[category] = count
+	}
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("failed to query categories: %w", err)
+	}
+	rows.Close()
+
+	for spelling, canonical := range canonicalSpellings(counts) {
+		if spelling == canonical {
+			continue
+		}
+		var value interface{} = canonical
+		if canonical == "" {
+			value = nil
+		}
+		if _, err = db.ExecContext(ctx, "UPDATE questions SET category = ? WHERE category = ?", value, spelling); err != nil {
+			return fmt.Errorf("failed to respell category %q: %w", spelling, err)
+		}
+	}
+	return nil
+}
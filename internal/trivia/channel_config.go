@@ -0,0 +1,68 @@
+package trivia
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jbpratt/bots/internal/trivia/models"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+)
+
+const sqlChannelConfigTable = `
+/*
+  Store the settings of each channel, like the category and difficulty of
+  questions asked when a quiz is started without choosing them. Empty values
+  match any question.
+*/
+CREATE TABLE IF NOT EXISTS channel_configs (
+  id         INTEGER NOT NULL PRIMARY KEY,
+  channel    TEXT    NOT NULL UNIQUE,
+  category   TEXT    NOT NULL DEFAULT '',
+  difficulty TEXT    NOT NULL DEFAULT ''
+);
+`
+
+// DefaultFilter returns the filter of the questions asked in the
+// leaderboard's channel when a quiz is started without one.
+func (l *Leaderboard) DefaultFilter() (Filter, error) {
+	l.rw.RLock()
+	defer l.rw.RUnlock()
+
+	config, err := models.ChannelConfigs(
+		models.ChannelConfigWhere.Channel.EQ(l.channel),
+	).OneG(context.Background())
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Filter{}, nil
+		}
+		return Filter{}, fmt.Errorf("failed to query channel config: %w", err)
+	}
+
+	return Filter{Category: config.Category, Difficulty: config.Difficulty}, nil
+}
+
+// SetDefaultFilter stores the category and difficulty of filter as the
+// defaults of the leaderboard's channel.
+func (l *Leaderboard) SetDefaultFilter(filter Filter) error {
+	l.rw.Lock()
+	defer l.rw.Unlock()
+
+	config := &models.ChannelConfig{
+		Channel:    l.channel,
+		Category:   filter.Category,
+		Difficulty: filter.Difficulty,
+	}
+	err := config.UpsertG(
+		context.Background(), true,
+		[]string{models.ChannelConfigColumns.Channel},
+		boil.Whitelist(models.ChannelConfigColumns.Category, models.ChannelConfigColumns.Difficulty),
+		boil.Infer(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to store channel config: %w", err)
+	}
+
+	return nil
+}
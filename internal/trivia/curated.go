@@ -0,0 +1,75 @@
+package trivia
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jbpratt/bots/internal/trivia/models"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+	"go.uber.org/zap"
+)
+
+// NewQuizFromIDs builds a quiz asking exactly the questions with ids, one per
+// round in the given order, for curated events. The questions are asked even
+// if removed, but every ID must exist and be askable, or the error lists those
+// which aren't. opts.Size is the number of IDs and there is no warm-up round.
+func NewQuizFromIDs(ctx context.Context, exec boil.ContextExecutor, logger *zap.SugaredLogger, ids []int64, opts QuizOptions) (*Quiz, error) {
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("%w: no question IDs given", ErrNoQuestions)
+	}
+
+	args := []interface{}{}
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	found, err := models.Questions(qm.WhereIn("id IN ?", args...)).All(ctx, exec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query questions: %w", err)
+	}
+
+	byID := map[int64]*models.Question{}
+	for _, question := range found {
+		byID[question.ID.Int64] = question
+	}
+
+	source := &listSource{}
+	missing, broken := []string{}, []string{}
+	for _, id := range ids {
+		model, ok := byID[id]
+		if !ok {
+			missing = append(missing, fmt.Sprint(id))
+			continue
+		}
+		question := newQuestionFromModel(model)
+		if problem := question.problem(); problem != "" {
+			broken = append(broken, fmt.Sprintf("%d has %s", id, problem))
+			continue
+		}
+		source.questions = append(source.questions, question)
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%w with IDs %s", ErrNoQuestions, strings.Join(missing, ", "))
+	}
+	if len(broken) > 0 {
+		return nil, fmt.Errorf("broken questions: %s", strings.Join(broken, "; "))
+	}
+
+	opts.Size, opts.WarmUp, opts.Distinct = len(ids), false, false
+	return NewQuizWithOptions(logger, source, opts)
+}
+
+// listSource hands out its questions in order, once each.
+type listSource struct {
+	questions []*Question
+	next      int
+}
+
+func (s *listSource) Question() (*Question, error) {
+	if s.next >= len(s.questions) {
+		return nil, ErrNoQuestions
+	}
+	s.next++
+	return s.questions[s.next-1], nil
+}
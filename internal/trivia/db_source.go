@@ -6,7 +6,9 @@ import (
 	_ "embed"
 	"errors"
 	"fmt"
-	"strings"
+	"math"
+	"math/rand"
+	"time"
 
 	"github.com/jbpratt/bots/internal/trivia/models"
 	"github.com/volatiletech/sqlboiler/v4/boil"
@@ -32,6 +34,8 @@ const sqlQuestionTable = `
 /*
   Store trivia questions scraped from external sources. choices is a comma
   delimited list of all answers. Unique question allows for INSERT OR IGNORE.
+  used counts how many times a question has been asked, last_asked being
+  when it last was.
 */
 CREATE TABLE IF NOT EXISTS questions (
   id              INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -42,6 +46,12 @@ CREATE TABLE IF NOT EXISTS questions (
   source          TEXT    NOT NULL,
   type            TEXT,
   removed         TINYINT(1) NOT NULL DEFAULT 0,
+  category        TEXT,
+  difficulty      TEXT,
+  media           TEXT,
+  used            INTEGER NOT NULL DEFAULT 0,
+  last_asked      DATETIME,
+  aliases         TEXT,
   UNIQUE(question)
 );
 
@@ -53,6 +63,9 @@ CREATE TABLE IF NOT EXISTS question_sequence (
 type DBSource struct {
 	cache []*Question
 	db    *sql.DB
+	// freshness is the exponent of the freshness weight questions are drawn
+	// by, see SetFreshness.
+	freshness float64
 }
 
 func NewDefaultDBSource(db *sql.DB) (*DBSource, error) {
@@ -61,9 +74,16 @@ func NewDefaultDBSource(db *sql.DB) (*DBSource, error) {
 
 func NewDBSource(db *sql.DB) (*DBSource, error) {
 	ctx := context.Background()
-	if _, err := db.ExecContext(ctx, sqlQuestionTable+sqlQuestionsEntries); err != nil {
+	if err := migrateQuestions(ctx, db); err != nil {
+		return nil, err
+	}
+
+	if _, err := db.ExecContext(ctx, sqlQuestionsEntries); err != nil {
 		return nil, fmt.Errorf("failed to run init sql: %w", err)
 	}
+	if err := canonicalizeCategories(ctx, db); err != nil {
+		return nil, err
+	}
 
 	count, err := models.QuestionSequences().CountG(ctx)
 	if err != nil {
@@ -99,24 +119,11 @@ func (s *DBSource) refreshCache(ctx context.Context) error {
 	}
 
 	if len(questions) == 0 {
-		return errors.New("no questions found in database")
+		return ErrNoQuestions
 	}
 
 	for _, question := range questions {
-		choices := strings.Split(question.Choices, ",")
-		q := &Question{
-			Question: question.Question,
-			Answers:  []*Answer{},
-		}
-
-		for _, choice := range choices {
-			q.Answers = append(q.Answers, &Answer{
-				Value:   choice,
-				Correct: choice == question.Answer,
-			})
-		}
-
-		s.cache = append(s.cache, q)
+		s.cache = append(s.cache, newQuestionFromModel(question))
 	}
 
 	if _, err = s.db.ExecContext(ctx, "UPDATE question_sequence SET n = n + ?", 3); err != nil {
@@ -127,6 +134,10 @@ func (s *DBSource) refreshCache(ctx context.Context) error {
 }
 
 func (s *DBSource) Question() (*Question, error) {
+	if s.freshness > 0 {
+		return s.Filtered(Filter{}).Question()
+	}
+
 	if len(s.cache) == 0 {
 		if err := s.refreshCache(context.Background()); err != nil {
 			return nil, err
@@ -139,3 +150,205 @@ func (s *DBSource) Question() (*Question, error) {
 
 	return q, nil
 }
+
+// Filtered returns a Source drawing random questions from the database which
+// match filter. Each is drawn once before any repeats, unlike the default
+// sequence, which is shared between quizzes.
+func (s *DBSource) Filtered(filter Filter) Source {
+	return &filteredDBSource{filter: filter, freshness: s.freshness}
+}
+
+// SetFreshness draws questions at random weighted by their freshness raised
+// to exponent, in place of the default sequence. The higher exponent is, the
+// more strongly fresher questions are favored. Questions are drawn uniformly
+// at random, or in sequence when unfiltered, by default or when exponent is 0.
+func (s *DBSource) SetFreshness(exponent float64) {
+	s.freshness = exponent
+}
+
+// Categories returns the distinct categories of the questions which haven't
+// been removed.
+func (s *DBSource) Categories() ([]string, error) {
+	return s.distinct("category")
+}
+
+// Difficulties returns the distinct difficulties of the questions which
+// haven't been removed.
+func (s *DBSource) Difficulties() ([]string, error) {
+	return s.distinct("difficulty")
+}
+
+func (s *DBSource) distinct(column string) ([]string, error) {
+	rows, err := s.db.QueryContext(context.Background(), fmt.Sprintf(
+		"SELECT DISTINCT %[1]s FROM questions WHERE removed = 0 AND %[1]s IS NOT NULL AND %[1]s != '' ORDER BY %[1]s",
+		column,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s values: %w", column, err)
+	}
+	defer rows.Close()
+
+	values := []string{}
+	for rows.Next() {
+		var value string
+		if err = rows.Scan(&value); err != nil {
+			return nil, fmt.Errorf("failed to scan %s value: %w", column, err)
+		}
+		values = append(values, value)
+	}
+
+	return values, rows.Err()
+}
+
+type filteredDBSource struct {
+	filter    Filter
+	freshness float64
+	// count is the number of matching questions, counted on the first draw,
+	// and drawn holds the offsets among them drawn since.
+	count int64
+	drawn map[int64]bool
+}
+
+func (s *filteredDBSource) Question() (*Question, error) {
+	if s.freshness > 0 {
+		return s.freshQuestion()
+	}
+
+	ctx := context.Background()
+	// questions may be removed while drawing, so count again once if the
+	// count is outdated
+	for attempt := 0; attempt < 2; attempt++ {
+		if s.drawn == nil || int64(len(s.drawn)) >= s.count {
+			count, err := models.Questions(s.where()...).CountG(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to count questions: %w", err)
+			}
+			if count == 0 {
+				return nil, fmt.Errorf("%w matching %s", ErrNoQuestions, s.filter)
+			}
+			s.count, s.drawn = count, map[int64]bool{}
+		}
+
+		// skipping to a random offset is much cheaper than ordering every
+		// matching row by random(), for large question banks
+		offset := rand.Int63n(s.count)
+		for s.drawn[offset] {
+			offset = (offset + 1) % s.count
+		}
+		s.drawn[offset] = true
+
+		question, err := models.Questions(append(s.where(),
+			qm.OrderBy(models.QuestionColumns.ID),
+			qm.Offset(int(offset)),
+		)...).OneG(ctx)
+		if errors.Is(err, sql.ErrNoRows) {
+			s.drawn = nil
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to query questions: %w", err)
+		}
+
+		return newQuestionFromModel(question), nil
+	}
+
+	return nil, fmt.Errorf("%w matching %s", ErrNoQuestions, s.filter)
+}
+
+// ids returns the IDs of the questions matching the filter, in order.
+func (s *filteredDBSource) ids(ctx context.Context) ([]int64, error) {
+	questions, err := models.Questions(append(s.where(),
+		qm.Select(models.QuestionColumns.ID),
+		qm.OrderBy(models.QuestionColumns.ID),
+	)...).AllG(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query questions: %w", err)
+	}
+
+	if len(questions) == 0 {
+		return nil, fmt.Errorf("%w matching %s", ErrNoQuestions, s.filter)
+	}
+
+	ids := make([]int64, len(questions))
+	for i, question := range questions {
+		ids[i] = question.ID.Int64
+	}
+	return ids, nil
+}
+
+// freshQuestion draws one of a random sample of the matching questions,
+// weighted by their freshness.
+func (s *filteredDBSource) freshQuestion() (*Question, error) {
+	ctx := context.Background()
+	candidates, err := models.Questions(append(s.where(),
+		qm.Select(models.QuestionColumns.ID, models.QuestionColumns.Used, models.QuestionColumns.LastAsked),
+		qm.OrderBy("random()"),
+		qm.Limit(freshnessCandidates),
+	)...).AllG(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query questions: %w", err)
+	}
+
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("%w matching %s", ErrNoQuestions, s.filter)
+	}
+
+	now := time.Now()
+	weights := make([]float64, len(candidates))
+	for i, candidate := range candidates {
+		weights[i] = math.Pow(Freshness(candidate.Used, candidate.LastAsked, now), s.freshness)
+	}
+
+	question, err := models.FindQuestionG(ctx, candidates[weightedIndex(weights, rand.Float64())].ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query question: %w", err)
+	}
+
+	return newQuestionFromModel(question), nil
+}
+
+func (s *filteredDBSource) where() []qm.QueryMod {
+	mods := []qm.QueryMod{
+		models.QuestionWhere.Removed.EQ("0"),
+	}
+	if s.filter.Category != "" {
+		mods = append(mods, qm.Where("category = ? COLLATE NOCASE", CanonicalCategory(s.filter.Category)))
+	}
+	if s.filter.Difficulty != "" {
+		mods = append(mods, qm.Where("difficulty = ? COLLATE NOCASE", s.filter.Difficulty))
+	}
+	if len(s.filter.Exclude) > 0 {
+		ids := []interface{}{}
+		for _, id := range s.filter.Exclude {
+			ids = append(ids, id)
+		}
+		mods = append(mods, qm.WhereNotIn("id NOT IN ?", ids...))
+	}
+	return mods
+}
+
+func newQuestionFromModel(question *models.Question) *Question {
+	q := &Question{
+		ID:         question.ID.Int64,
+		Question:   question.Question,
+		Type:       question.Type.String,
+		Category:   question.Category.String,
+		Difficulty: question.Difficulty.String,
+		Media:      question.Media.String,
+		Source:     question.Source,
+		Answers:    []*Answer{},
+	}
+
+	for _, choice := range ParseChoices(question.Choices) {
+		ans := &Answer{
+			Value:   choice,
+			Correct: answersEqual(choice, question.Answer),
+		}
+		if ans.Correct && question.Aliases.String != "" {
+			ans.Aliases = ParseChoices(question.Aliases.String)
+		}
+		q.Answers = append(q.Answers, ans)
+	}
+
+	return q
+}
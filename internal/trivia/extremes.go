@@ -0,0 +1,73 @@
+package trivia
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/volatiletech/sqlboiler/v4/boil"
+)
+
+// QuestionRate is how often a question has been answered correctly across
+// every channel.
+type QuestionRate struct {
+	ID       int64
+	Question string
+	Answered int64
+	Correct  int64
+}
+
+// Rate returns the percentage of answers to the question which were correct.
+func (r *QuestionRate) Rate() float64 {
+	if r.Answered == 0 {
+		return 0
+	}
+	return float64(r.Correct) * 100 / float64(r.Answered)
+}
+
+func (r *QuestionRate) String() string {
+	return fmt.Sprintf("#%d %.0f%% of %d %q", r.ID, r.Rate(), r.Answered, r.Question)
+}
+
+const sqlQuestionRates = `
+SELECT q.id, q.question, count(*), sum(p.correct)
+FROM participations p
+JOIN questions q ON q.id = p.question_id
+WHERE q.removed = '0'
+GROUP BY q.id
+HAVING count(*) >= ?
+ORDER BY avg(p.correct) %s, count(*) DESC, q.id ASC
+LIMIT ?
+`
+
+// QuestionExtremes returns up to limit of the questions with the lowest and
+// the highest correct-rates, hardest and easiest first respectively. Only
+// questions answered at least minAnswers times are ranked, so a single lucky
+// answer doesn't mark a question as easy.
+func QuestionExtremes(ctx context.Context, exec boil.ContextExecutor, limit, minAnswers int) (hardest, easiest []*QuestionRate, err error) {
+	if hardest, err = questionRates(ctx, exec, "ASC", limit, minAnswers); err != nil {
+		return nil, nil, err
+	}
+	if easiest, err = questionRates(ctx, exec, "DESC", limit, minAnswers); err != nil {
+		return nil, nil, err
+	}
+	return hardest, easiest, nil
+}
+
+func questionRates(ctx context.Context, exec boil.ContextExecutor, order string, limit, minAnswers int) ([]*QuestionRate, error) {
+	rows, err := exec.QueryContext(ctx, fmt.Sprintf(sqlQuestionRates, order), minAnswers, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query question rates: %w", err)
+	}
+	defer rows.Close()
+
+	rates := []*QuestionRate{}
+	for rows.Next() {
+		rate := &QuestionRate{}
+		if err = rows.Scan(&rate.ID, &rate.Question, &rate.Answered, &rate.Correct); err != nil {
+			return nil, fmt.Errorf("failed to scan question rates: %w", err)
+		}
+		rates = append(rates, rate)
+	}
+
+	return rates, rows.Err()
+}
@@ -0,0 +1,178 @@
+package trivia
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"sort"
+	"strings"
+)
+
+// FileQuestion is a question as written in the JSON file of a FileSource.
+type FileQuestion struct {
+	Question string `json:"question"`
+	// Answer is the correct one of Choices.
+	Answer  string   `json:"answer"`
+	Choices []string `json:"choices"`
+	// Aliases are alternate texts accepted for Answer when answering with
+	// text.
+	Aliases []string `json:"aliases"`
+	// Type is "boolean" for true or false questions, "multiple" otherwise.
+	Type       string `json:"type"`
+	Category   string `json:"category"`
+	Difficulty string `json:"difficulty"`
+	Media      string `json:"media"`
+	Source     string `json:"source"`
+}
+
+// FileSource is a FilterableSource of the questions in a JSON file, for
+// running without a question database. Questions are drawn at random.
+type FileSource struct {
+	questions []*Question
+}
+
+// NewFileSource loads the questions of the JSON file at path, an array of
+// FileQuestion. Every malformed question is reported at once.
+func NewFileSource(path string) (*FileSource, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read questions: %w", err)
+	}
+
+	var entries []FileQuestion
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err = decoder.Decode(&entries); err != nil {
+		return nil, fmt.Errorf("failed to parse questions %s: %w", path, err)
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("%w in %s", ErrNoQuestions, path)
+	}
+
+	s := &FileSource{}
+	problems := []string{}
+	for i, entry := range entries {
+		question := entry.question()
+		problem := question.problem()
+		if strings.TrimSpace(question.Question) == "" {
+			problem = "no question"
+		}
+		if problem != "" {
+			problems = append(problems, fmt.Sprintf("question %d (%q) has %s", i+1, entry.Question, problem))
+			continue
+		}
+		s.questions = append(s.questions, question)
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("malformed questions in %s: %s", path, strings.Join(problems, "; "))
+	}
+
+	// sources spell categories inconsistently, keep each one together
+	counts := map[string]int{}
+	for _, question := range s.questions {
+		counts[question.Category]++
+	}
+	canonical := canonicalSpellings(counts)
+	for _, question := range s.questions {
+		question.Category = canonical[question.Category]
+	}
+
+	return s, nil
+}
+
+func (e FileQuestion) question() *Question {
+	q := &Question{
+		Question:   e.Question,
+		Type:       e.Type,
+		Category:   e.Category,
+		Difficulty: e.Difficulty,
+		Media:      e.Media,
+		Source:     e.Source,
+		Answers:    []*Answer{},
+	}
+	for _, choice := range e.Choices {
+		ans := &Answer{
+			Value:   choice,
+			Correct: answersEqual(choice, e.Answer),
+		}
+		if ans.Correct {
+			ans.Aliases = e.Aliases
+		}
+		q.Answers = append(q.Answers, ans)
+	}
+	return q
+}
+
+func (s *FileSource) Question() (*Question, error) {
+	return s.Filtered(Filter{}).Question()
+}
+
+// Filtered returns a Source of the questions matching filter, compared
+// ignoring case like the database does. Exclude never matches, as questions of
+// a file have no ID.
+func (s *FileSource) Filtered(filter Filter) Source {
+	return &filteredFileSource{source: s, filter: filter}
+}
+
+// Categories returns the distinct categories of the questions, sorted.
+func (s *FileSource) Categories() ([]string, error) {
+	return s.distinct(func(q *Question) string { return q.Category }), nil
+}
+
+// Difficulties returns the distinct difficulties of the questions, sorted.
+func (s *FileSource) Difficulties() ([]string, error) {
+	return s.distinct(func(q *Question) string { return q.Difficulty }), nil
+}
+
+func (s *FileSource) distinct(field func(*Question) string) []string {
+	seen := map[string]bool{}
+	values := []string{}
+	for _, question := range s.questions {
+		value := field(question)
+		if value != "" && !seen[value] {
+			seen[value] = true
+			values = append(values, value)
+		}
+	}
+	sort.Strings(values)
+	return values
+}
+
+type filteredFileSource struct {
+	source *FileSource
+	filter Filter
+}
+
+func (s *filteredFileSource) Question() (*Question, error) {
+	matching := []*Question{}
+	for _, question := range s.source.questions {
+		if s.matches(question) {
+			matching = append(matching, question)
+		}
+	}
+	if len(matching) == 0 {
+		return nil, fmt.Errorf("%w matching %s", ErrNoQuestions, s.filter)
+	}
+
+	// quizzes shuffle the answers they are given, so hand out a copy
+	picked := matching[rand.Intn(len(matching))]
+	q := *picked
+	q.Answers = []*Answer{}
+	for _, ans := range picked.Answers {
+		a := *ans
+		q.Answers = append(q.Answers, &a)
+	}
+	return &q, nil
+}
+
+func (s *filteredFileSource) matches(question *Question) bool {
+	if s.filter.Category != "" && !strings.EqualFold(question.Category, CanonicalCategory(s.filter.Category)) {
+		return false
+	}
+	if s.filter.Difficulty != "" && !strings.EqualFold(question.Difficulty, s.filter.Difficulty) {
+		return false
+	}
+	return true
+}
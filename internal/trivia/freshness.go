@@ -0,0 +1,67 @@
+package trivia
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/volatiletech/null/v8"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+)
+
+// freshnessCandidates caps how many matching questions are weighed against
+// each other when drawing a question by freshness.
+const freshnessCandidates = 200
+
+// staleFor is how long after being asked a question is half as stale as when
+// it was just asked.
+const staleFor = 24 * time.Hour
+
+// Freshness weighs a question asked used times, last at lastAsked, from 1 for
+// a question never asked towards 0 for one asked often and recently. Asking a
+// question counts as much against it as it being asked just now, which wears
+// off over days.
+func Freshness(used int64, lastAsked null.Time, now time.Time) float64 {
+	staleness := 0.0
+	if lastAsked.Valid {
+		age := now.Sub(lastAsked.Time)
+		if age < 0 {
+			age = 0
+		}
+		staleness = float64(staleFor) / float64(age+staleFor)
+	}
+	return 1 / (1 + float64(used) + staleness)
+}
+
+// weightedIndex returns the index picked by r, from 0 to 1, when each index
+// is picked in proportion to its weight.
+func weightedIndex(weights []float64, r float64) int {
+	total := 0.0
+	for _, weight := range weights {
+		total += weight
+	}
+
+	target := r * total
+	for i, weight := range weights {
+		if target < weight {
+			return i
+		}
+		target -= weight
+	}
+	return len(weights) - 1
+}
+
+// MarkAsked counts the question with id as asked at. Questions which did not
+// come from the database have no ID and are ignored.
+func MarkAsked(ctx context.Context, exec boil.ContextExecutor, id int64, at time.Time) error {
+	if id == 0 {
+		return nil
+	}
+
+	_, err := exec.ExecContext(ctx, "UPDATE questions SET used = used + 1, last_asked = ? WHERE id = ?", at, id)
+	if err != nil {
+		return fmt.Errorf("failed to mark question %d as asked: %w", id, err)
+	}
+
+	return nil
+}
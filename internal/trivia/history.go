@@ -0,0 +1,160 @@
+package trivia
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jbpratt/bots/internal/trivia/models"
+	"github.com/volatiletech/null/v8"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+)
+
+const sqlParticipationTable = `
+/*
+  Store every answer given in a round, so players can look back on how they
+  did. question_id is NULL for questions which did not come from the
+  questions table.
+*/
+CREATE TABLE IF NOT EXISTS participations (
+  id          INTEGER NOT NULL PRIMARY KEY,
+  name        TEXT    NOT NULL,
+  channel     TEXT    NOT NULL DEFAULT '',
+  question_id INTEGER,
+  correct     BOOLEAN NOT NULL,
+  answered_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS participations_name ON participations(channel, name, answered_at);
+`
+
+// History is a player's record of answers on a leaderboard's channel.
+type History struct {
+	Name string
+	// Recent lists the player's latest answers, newest first.
+	Recent   models.ParticipationSlice
+	Answered int64
+	Correct  int64
+}
+
+// Accuracy returns the percentage of the player's answers which were correct.
+func (h *History) Accuracy() float64 {
+	if h.Answered == 0 {
+		return 0
+	}
+	return float64(h.Correct) * 100 / float64(h.Answered)
+}
+
+// RecordAnswers stores the answers given in round, which must have ended.
+func (l *Leaderboard) RecordAnswers(round *Round) error {
+	l.rw.Lock()
+	defer l.rw.Unlock()
+
+	ctx := context.Background()
+	correctIdx, _ := round.Question.Correct()
+	for _, participant := range round.Answers() {
+		record := &models.Participation{
+			Name:       participant.Name,
+			Channel:    l.channel,
+			Correct:    participant.Choice == correctIdx,
+			AnsweredAt: round.StartedAt.Add(participant.TimeToSubmission),
+		}
+		if round.Question.ID != 0 {
+			record.QuestionID = null.Int64From(round.Question.ID)
+		}
+
+		if err := record.InsertG(ctx, boil.Infer()); err != nil {
+			return fmt.Errorf("failed to record answer of %s: %w", participant.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// History returns the latest limit answers of the player called name along
+// with their totals.
+func (l *Leaderboard) History(name string, limit int) (*History, error) {
+	l.rw.RLock()
+	defer l.rw.RUnlock()
+
+	ctx := context.Background()
+	where := []qm.QueryMod{
+		models.ParticipationWhere.Channel.EQ(l.channel),
+		models.ParticipationWhere.Name.EQ(name),
+	}
+
+	recent, err := models.Participations(append(where,
+		qm.OrderBy("answered_at desc, id desc"),
+		qm.Limit(limit),
+	)...).AllG(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query answers of %s: %w", name, err)
+	}
+
+	answered, err := models.Participations(where...).CountG(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count answers of %s: %w", name, err)
+	}
+
+	correct, err := models.Participations(append(where, models.ParticipationWhere.Correct.EQ(true))...).CountG(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count correct answers of %s: %w", name, err)
+	}
+
+	return &History{
+		Name:     name,
+		Recent:   recent,
+		Answered: answered,
+		Correct:  correct,
+	}, nil
+}
+
+// CategoryRecord is a player's record of answers to the questions of one
+// category.
+type CategoryRecord struct {
+	Category string
+	Answered int64
+	Correct  int64
+}
+
+// Accuracy returns the percentage of the answers which were correct.
+func (c *CategoryRecord) Accuracy() float64 {
+	if c.Answered == 0 {
+		return 0
+	}
+	return float64(c.Correct) * 100 / float64(c.Answered)
+}
+
+// Strengths returns the player's record in each category they answered at
+// least minAnswers questions of, most accurate first. Answers to questions
+// without a category, or which did not come from the database, are left out.
+func (l *Leaderboard) Strengths(name string, minAnswers int) ([]*CategoryRecord, error) {
+	l.rw.RLock()
+	defer l.rw.RUnlock()
+
+	rows, err := l.db.QueryContext(context.Background(), `
+SELECT q.category, COUNT(*), SUM(p.correct)
+FROM participations p
+INNER JOIN questions q ON q.id = p.question_id
+WHERE p.channel = ? AND p.name = ? AND q.category IS NOT NULL AND q.category != ''
+GROUP BY q.category
+HAVING COUNT(*) >= ?
+ORDER BY CAST(SUM(p.correct) AS REAL) / COUNT(*) DESC, COUNT(*) DESC, q.category`,
+		l.channel, name, minAnswers,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query the categories of %s: %w", name, err)
+	}
+	defer rows.Close()
+
+	records := []*CategoryRecord{}
+	for rows.Next() {
+		record := &CategoryRecord{}
+		if err = rows.Scan(&record.Category, &record.Answered, &record.Correct); err != nil {
+			return nil, fmt.Errorf("failed to scan the categories of %s: %w", name, err)
+		}
+		records = append(records, record)
+	}
+
+	return records, rows.Err()
+}
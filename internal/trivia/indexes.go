@@ -0,0 +1,99 @@
+package trivia
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/volatiletech/sqlboiler/v4/boil"
+	"go.uber.org/zap"
+)
+
+// index is an index recommended for large question banks, on a column which
+// questions are filtered or ordered by.
+type index struct {
+	name   string
+	table  string
+	column string
+}
+
+// recommendedIndexes are only recommended once their column exists, so
+// columns added by later migrations are covered as they appear.
+var recommendedIndexes = []index{
+	{"questions_category", "questions", "category"},
+	{"questions_difficulty", "questions", "difficulty"},
+	{"questions_used", "questions", "used"},
+	{"questions_source", "questions", "source"},
+}
+
+// MissingIndexes returns the names of the recommended indexes which have not
+// been created.
+func MissingIndexes(ctx context.Context, exec boil.ContextExecutor) ([]string, error) {
+	missing := []string{}
+	for _, idx := range recommendedIndexes {
+		ok, err := indexApplies(ctx, exec, idx)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+
+		var count int
+		row := exec.QueryRowContext(ctx, "SELECT count(*) FROM sqlite_master WHERE type = 'index' AND name = ?", idx.name)
+		if err = row.Scan(&count); err != nil {
+			return nil, fmt.Errorf("failed to look up index %s: %w", idx.name, err)
+		}
+		if count == 0 {
+			missing = append(missing, idx.name)
+		}
+	}
+
+	return missing, nil
+}
+
+// CheckIndexes logs whether the recommended indexes exist, suggesting
+// CreateIndexes when any are missing.
+func CheckIndexes(ctx context.Context, exec boil.ContextExecutor, logger *zap.SugaredLogger) error {
+	missing, err := MissingIndexes(ctx, exec)
+	if err != nil {
+		return err
+	}
+
+	if len(missing) > 0 {
+		logger.Infow("recommended indexes are missing, large question banks may be slow to query", "indexes", missing)
+	} else {
+		logger.Debug("all recommended indexes exist")
+	}
+
+	return nil
+}
+
+// CreateIndexes creates any missing recommended indexes. It is safe to call
+// repeatedly.
+func CreateIndexes(ctx context.Context, exec boil.ContextExecutor) error {
+	for _, idx := range recommendedIndexes {
+		ok, err := indexApplies(ctx, exec, idx)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+
+		stmt := fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s (%s)", idx.name, idx.table, idx.column)
+		if _, err = exec.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to create index %s: %w", idx.name, err)
+		}
+	}
+
+	return nil
+}
+
+// indexApplies reports whether the column of idx exists.
+func indexApplies(ctx context.Context, exec boil.ContextExecutor, idx index) (bool, error) {
+	columns, err := tableColumns(ctx, exec, idx.table)
+	if err != nil {
+		return false, err
+	}
+	return columns[idx.column], nil
+}
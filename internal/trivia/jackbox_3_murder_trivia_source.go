@@ -50,7 +50,7 @@ func (s *Jackbox3MurderTriviaJSONSource) Question() (*Question, error) {
 	for _, a := range sq.Choices {
 		q.Answers = append(q.Answers, &Answer{
 			Value:   a,
-			Correct: a == sq.Answer,
+			Correct: answersEqual(a, sq.Answer),
 		})
 	}
 
@@ -3,10 +3,15 @@ package trivia
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"math"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/jbpratt/bots/internal/trivia/models"
+	"github.com/volatiletech/null/v8"
 	"github.com/volatiletech/sqlboiler/v4/boil"
 	"github.com/volatiletech/sqlboiler/v4/queries/qm"
 	"go.uber.org/zap"
@@ -18,64 +23,135 @@ const sqlUserTable = `
 /*
   Store users who have played a game of trivia and their total points
   over the history of playing. Sorting this table by points allows
-  for determining an overall leaderboard.
+  for determining an overall leaderboard. Each channel keeps its own
+  leaderboard, the default channel being ''. max_streak is the most
+  correct answers a user has given in a row within a single quiz.
+  last_active is when a user last played, NULL for users who haven't
+  played since it was added.
 */
 CREATE TABLE IF NOT EXISTS users (
   id           INTEGER NOT NULL PRIMARY KEY,
   name         TEXT    NOT NULL,
   points       INTEGER NOT NULL,
-  games_played INTEGER NOT NULL
+  games_played INTEGER NOT NULL,
+  channel      TEXT    NOT NULL DEFAULT '',
+  max_streak   INTEGER NOT NULL DEFAULT 0,
+  last_active  DATETIME
 );
 `
 
 type Leaderboard struct {
-	logger *zap.SugaredLogger
-	db     *sql.DB
-	rw     sync.RWMutex
+	logger  *zap.SugaredLogger
+	db      *sql.DB
+	rw      sync.RWMutex
+	channel string
+	// decay is the fraction of their points players lose per day of
+	// inactivity, see SetDecay.
+	decay float64
+	// ranking caches the players ranked by Ranking as of rankedAt, until the
+	// leaderboard changes. It is guarded by rankingMu, as it is filled in
+	// while rw is only held for reading.
+	rankingMu sync.Mutex
+	ranking   models.UserSlice
+	rankedAt  time.Time
 }
 
+// decayedRankingTTL is how long a ranking is cached for while points decay,
+// as decay lowers them without the leaderboard changing.
+const decayedRankingTTL = time.Minute
+
+// NewLeaderboard returns the leaderboard of the default channel.
 func NewLeaderboard(logger *zap.SugaredLogger, db *sql.DB) (*Leaderboard, error) {
-	if _, err := db.ExecContext(context.Background(), sqlUserTable); err != nil {
+	return NewChannelLeaderboard(logger, db, "")
+}
+
+// NewChannelLeaderboard returns a leaderboard only tracking the players of
+// channel.
+func NewChannelLeaderboard(logger *zap.SugaredLogger, db *sql.DB, channel string) (*Leaderboard, error) {
+	ctx := context.Background()
+	if err := migrateUsers(ctx, db); err != nil {
+		return nil, fmt.Errorf("failed to run init sql: %w", err)
+	}
+	if err := migrateParticipations(ctx, db); err != nil {
+		return nil, fmt.Errorf("failed to run init sql: %w", err)
+	}
+	if err := migrateChannelConfigs(ctx, db); err != nil {
+		return nil, fmt.Errorf("failed to run init sql: %w", err)
+	}
+	if err := migrateQuizzes(ctx, db); err != nil {
 		return nil, fmt.Errorf("failed to run init sql: %w", err)
 	}
 	return &Leaderboard{
-		logger: logger,
-		db:     db,
+		logger:  logger,
+		db:      db,
+		channel: channel,
 	}, nil
 }
 
+// SetDecay makes players lose rate, a fraction from 0 to 1, of their points
+// for each whole day since they last played. Decayed points are shown by
+// Highscores and stored once the player plays again. Points don't decay by
+// default.
+func (l *Leaderboard) SetDecay(rate float64) {
+	l.rw.Lock()
+	defer l.rw.Unlock()
+	l.decay = rate
+	l.invalidateRanking()
+}
+
+// decayed returns the points of user once decayed until now.
+func (l *Leaderboard) decayed(user *models.User, now time.Time) int64 {
+	if l.decay <= 0 || !user.LastActive.Valid {
+		return user.Points
+	}
+
+	days := math.Floor(now.Sub(user.LastActive.Time).Hours() / 24)
+	if days < 1 {
+		return user.Points
+	}
+	return int64(math.Round(float64(user.Points) * math.Pow(1-l.decay, days)))
+}
+
+// Update adds the points each player in entries scored in a quiz to their
+// totals, counting the quiz as a game played. Totals are merged rather than
+// overwritten, so updates for several quizzes add up. Every player is updated
+// in one transaction: if Update fails no total changes, so it may be retried.
 func (l *Leaderboard) Update(entries map[string]int) error {
 	l.rw.Lock()
 	defer l.rw.Unlock()
 
-	l.logger.Info("updating leaderboard with entries: %v", entries)
+	l.logger.Infow("updating leaderboard", "channel", l.channel, "entries", entries)
 
 	ctx := context.Background()
+	now := time.Now()
 	tx, err := l.db.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
+	// a no-op once committed
+	defer tx.Rollback()
 
 	for name, points := range entries {
 		var user *models.User
 		var exists bool
 
-		exists, err = models.Users(models.UserWhere.Name.EQ(name)).ExistsG(ctx)
+		exists, err = models.Users(l.where(name)...).Exists(ctx, tx)
 		if err != nil {
 			return fmt.Errorf("failed to determine if user exists: %w", err)
 		}
 
 		if exists {
-			user, err = models.Users(models.UserWhere.Name.EQ(name)).OneG(ctx)
+			user, err = models.Users(l.where(name)...).One(ctx, tx)
 			if err != nil {
 				return fmt.Errorf("failed to get user(%s): %w", name, err)
 			}
 
-			l.logger.Infof("found user to update: %v", user)
+			l.logger.Debugw("found user to update", "user", user)
 
-			user.Points += int64(points)
+			user.Points = l.decayed(user, now) + int64(points)
 			user.GamesPlayed++
-			if _, err = user.UpdateG(ctx, boil.Infer()); err != nil {
+			user.LastActive = null.TimeFrom(now)
+			if _, err = user.Update(ctx, tx, boil.Infer()); err != nil {
 				return fmt.Errorf("failed to update user: %w", err)
 			}
 		} else {
@@ -83,9 +159,11 @@ func (l *Leaderboard) Update(entries map[string]int) error {
 				Name:        name,
 				Points:      int64(points),
 				GamesPlayed: 1,
+				Channel:     l.channel,
+				LastActive:  null.TimeFrom(now),
 			}
-			l.logger.Infof("inserting new user: %v", user)
-			if err = user.InsertG(ctx, boil.Infer()); err != nil {
+			l.logger.Debugw("inserting new user", "user", user)
+			if err = user.Insert(ctx, tx, boil.Infer()); err != nil {
 				return fmt.Errorf("failed to insert new user: %w", err)
 			}
 		}
@@ -95,26 +173,235 @@ func (l *Leaderboard) Update(entries map[string]int) error {
 		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
+	l.invalidateRanking()
 	return nil
 }
 
+// Highscores returns the limit highest ranked players, or all of them if
+// limit is 0, see Ranking.
 func (l *Leaderboard) Highscores(limit int) (models.UserSlice, error) {
 	l.rw.RLock()
 	defer l.rw.RUnlock()
 
+	users, err := l.cachedRanking(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	if limit > 0 && len(users) > limit {
+		users = users[:limit]
+	}
+	return append(models.UserSlice{}, users...), nil
+}
+
+// Ranking returns every player who has played, ordered by their points after
+// decay, highest first, ties broken by name. The ranking is cached until the
+// leaderboard is updated, so the commands and pages showing it don't each
+// query and sort it again. The players returned must not be modified.
+func (l *Leaderboard) Ranking() (models.UserSlice, error) {
+	l.rw.RLock()
+	defer l.rw.RUnlock()
+
+	users, err := l.cachedRanking(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	return append(models.UserSlice{}, users...), nil
+}
+
+// cachedRanking returns the cached ranking, ranking the players again if
+// there is none. The caller must hold l.rw, so the leaderboard can't change
+// until the ranking is cached.
+func (l *Leaderboard) cachedRanking(ctx context.Context) (models.UserSlice, error) {
+	l.rankingMu.Lock()
+	defer l.rankingMu.Unlock()
+
+	now := time.Now()
+	if l.ranking != nil && (l.decay <= 0 || now.Sub(l.rankedAt) < decayedRankingTTL) {
+		return l.ranking, nil
+	}
+
+	users, err := models.Users(
+		models.UserWhere.Channel.EQ(l.channel),
+		models.UserWhere.GamesPlayed.GT(0),
+		qm.Select(models.UserColumns.Name, models.UserColumns.Points, models.UserColumns.GamesPlayed, models.UserColumns.LastActive),
+	).AllG(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query users: %w", err)
+	}
+
+	// decay may reorder the players, so they are ranked by their decayed
+	// points
+	for _, user := range users {
+		user.Points = l.decayed(user, now)
+	}
+	sort.SliceStable(users, func(i, j int) bool {
+		if users[i].Points != users[j].Points {
+			return users[i].Points > users[j].Points
+		}
+		return users[i].Name < users[j].Name
+	})
+
+	l.ranking, l.rankedAt = users, now
+	return users, nil
+}
+
+// invalidateRanking drops the cached ranking once the leaderboard changed.
+func (l *Leaderboard) invalidateRanking() {
+	l.rankingMu.Lock()
+	defer l.rankingMu.Unlock()
+	l.ranking = nil
+}
+
+// UpdateStreaks raises the longest streak on record of each player in
+// streaks, keeping records which are already at least as long. Players must
+// already be on the leaderboard.
+func (l *Leaderboard) UpdateStreaks(streaks map[string]int) error {
+	l.rw.Lock()
+	defer l.rw.Unlock()
+
 	ctx := context.Background()
-	if limit == 0 {
-		size, err := models.Users().CountG(ctx)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get count of users: %w", err)
+	for name, streak := range streaks {
+		if streak == 0 {
+			continue
+		}
+
+		mods := append(l.where(name), models.UserWhere.MaxStreak.LT(int64(streak)))
+		if _, err := models.Users(mods...).UpdateAllG(ctx, models.M{models.UserColumns.MaxStreak: streak}); err != nil {
+			return fmt.Errorf("failed to update streak of %s: %w", name, err)
 		}
-		limit = int(size)
 	}
 
+	return nil
+}
+
+// Streaks returns the limit players with the longest streaks on record.
+func (l *Leaderboard) Streaks(limit int) (models.UserSlice, error) {
+	l.rw.RLock()
+	defer l.rw.RUnlock()
+
 	return models.Users(
-		models.UserWhere.GamesPlayed.GT(0),
-		qm.Select(models.UserColumns.Name, models.UserColumns.Points, models.UserColumns.GamesPlayed),
-		qm.OrderBy("points desc"),
+		models.UserWhere.Channel.EQ(l.channel),
+		models.UserWhere.MaxStreak.GT(0),
+		qm.Select(models.UserColumns.Name, models.UserColumns.MaxStreak),
+		qm.OrderBy("max_streak desc, name asc"),
 		qm.Limit(limit),
-	).AllG(ctx)
+	).AllG(context.Background())
+}
+
+// Totals returns the points of every ranked player summed, after decay, and
+// how many players are ranked.
+func (l *Leaderboard) Totals() (int64, int, error) {
+	l.rw.RLock()
+	defer l.rw.RUnlock()
+
+	ctx := context.Background()
+	if l.decay > 0 {
+		users, err := l.cachedRanking(ctx)
+		if err != nil {
+			return 0, 0, err
+		}
+		var points int64
+		for _, user := range users {
+			points += user.Points
+		}
+		return points, len(users), nil
+	}
+
+	var (
+		points  int64
+		players int
+	)
+	err := l.db.QueryRowContext(ctx,
+		"SELECT COALESCE(SUM(points), 0), COUNT(*) FROM users WHERE channel = ? AND games_played > 0",
+		l.channel,
+	).Scan(&points, &players)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to sum points: %w", err)
+	}
+	return points, players, nil
+}
+
+// where matches the user called name on this leaderboard's channel.
+func (l *Leaderboard) where(name string) []qm.QueryMod {
+	return []qm.QueryMod{
+		models.UserWhere.Channel.EQ(l.channel),
+		models.UserWhere.Name.EQ(name),
+	}
+}
+
+// ErrNotOnLeaderboard is returned when a player has no entry to act on.
+var ErrNotOnLeaderboard = errors.New("not on the leaderboard")
+
+// Merge moves the standing of the player called old onto the one called
+// target, like after they changed their name. Points and games played are
+// summed, keeping the longest streak and the latest activity, and the
+// answer history of old is renamed. target is created if it isn't on the
+// leaderboard yet. old is removed.
+func (l *Leaderboard) Merge(old, target string) error {
+	if old == target {
+		return fmt.Errorf("cannot merge %s into itself", old)
+	}
+
+	l.rw.Lock()
+	defer l.rw.Unlock()
+
+	ctx := context.Background()
+	now := time.Now()
+	tx, err := l.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	// a no-op once committed
+	defer tx.Rollback()
+
+	from, err := models.Users(l.where(old)...).One(ctx, tx)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%s is %w", old, ErrNotOnLeaderboard)
+		}
+		return fmt.Errorf("failed to get user(%s): %w", old, err)
+	}
+
+	into, err := models.Users(l.where(target)...).One(ctx, tx)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		// renaming keeps everything as it was
+		from.Name = target
+		if _, err = from.Update(ctx, tx, boil.Whitelist(models.UserColumns.Name)); err != nil {
+			return fmt.Errorf("failed to rename user: %w", err)
+		}
+	case err != nil:
+		return fmt.Errorf("failed to get user(%s): %w", target, err)
+	default:
+		into.Points = l.decayed(into, now) + l.decayed(from, now)
+		into.GamesPlayed += from.GamesPlayed
+		if from.MaxStreak > into.MaxStreak {
+			into.MaxStreak = from.MaxStreak
+		}
+		if from.LastActive.Valid && (!into.LastActive.Valid || from.LastActive.Time.After(into.LastActive.Time)) {
+			into.LastActive = from.LastActive
+		}
+		if _, err = into.Update(ctx, tx, boil.Infer()); err != nil {
+			return fmt.Errorf("failed to update user: %w", err)
+		}
+		if _, err = from.Delete(ctx, tx); err != nil {
+			return fmt.Errorf("failed to delete user: %w", err)
+		}
+	}
+
+	_, err = models.Participations(
+		models.ParticipationWhere.Channel.EQ(l.channel),
+		models.ParticipationWhere.Name.EQ(old),
+	).UpdateAll(ctx, tx, models.M{models.ParticipationColumns.Name: target})
+	if err != nil {
+		return fmt.Errorf("failed to rename answer history: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	l.invalidateRanking()
+	l.logger.Infow("merged players", "channel", l.channel, "old", old, "target", target)
+	return nil
 }
@@ -0,0 +1,143 @@
+package trivia
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jbpratt/bots/internal/trivia/models"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+)
+
+// Problem is a defect commonly found in imported questions.
+type Problem string
+
+const (
+	ProblemEmptyAnswer      Problem = "empty answer"
+	ProblemTooFewChoices    Problem = "too few choices"
+	ProblemAnswerNotChoice  Problem = "answer not among choices"
+	ProblemHTMLEntities     Problem = "HTML entities"
+	ProblemDuplicateChoices Problem = "duplicate choices"
+	ProblemLongChoices      Problem = "choices too long"
+)
+
+// Problems lists every Problem in the order they are reported.
+var Problems = []Problem{
+	ProblemEmptyAnswer,
+	ProblemTooFewChoices,
+	ProblemAnswerNotChoice,
+	ProblemHTMLEntities,
+	ProblemDuplicateChoices,
+	ProblemLongChoices,
+}
+
+var htmlEntity = regexp.MustCompile(`&(#[0-9]+|#[xX][0-9a-fA-F]+|[a-zA-Z]+);`)
+
+// ParseChoices splits the comma delimited choices column of a question.
+func ParseChoices(choices string) []string {
+	return strings.Split(choices, ",")
+}
+
+// QuestionProblems returns the problems found in question, if any.
+func QuestionProblems(question *models.Question) []Problem {
+	problems := []Problem{}
+	choices := ParseChoices(question.Choices)
+
+	if strings.TrimSpace(question.Answer) == "" {
+		problems = append(problems, ProblemEmptyAnswer)
+	}
+
+	if len(choices) < MinChoices(question.Type.String) {
+		problems = append(problems, ProblemTooFewChoices)
+	}
+
+	found := false
+	for _, choice := range choices {
+		if answersEqual(choice, question.Answer) {
+			found = true
+			break
+		}
+	}
+	if !found {
+		problems = append(problems, ProblemAnswerNotChoice)
+	}
+
+	for _, text := range []string{question.Question, question.Answer, question.Choices} {
+		if htmlEntity.MatchString(text) {
+			problems = append(problems, ProblemHTMLEntities)
+			break
+		}
+	}
+
+	seen := map[string]bool{}
+	for _, choice := range choices {
+		key := strings.ToLower(strings.TrimSpace(choice))
+		if seen[key] {
+			problems = append(problems, ProblemDuplicateChoices)
+			break
+		}
+		seen[key] = true
+	}
+
+	length := 0
+	for _, choice := range choices {
+		length += utf8.RuneCountInString(choice)
+	}
+	if length > MaxChoicesLength {
+		problems = append(problems, ProblemLongChoices)
+	}
+
+	return problems
+}
+
+// LintResult counts the questions sharing a Problem.
+type LintResult struct {
+	Problem Problem
+	Count   int
+	// Samples holds the IDs of up to the requested number of the questions.
+	Samples []int64
+}
+
+func (r *LintResult) String() string {
+	if len(r.Samples) == 0 {
+		return fmt.Sprintf("%s: %d", r.Problem, r.Count)
+	}
+
+	ids := []string{}
+	for _, id := range r.Samples {
+		ids = append(ids, fmt.Sprint(id))
+	}
+	return fmt.Sprintf("%s: %d (ids %s)", r.Problem, r.Count, strings.Join(ids, ", "))
+}
+
+// LintQuestions checks every question which hasn't been removed for problems,
+// keeping up to samples IDs of the questions with each problem.
+func LintQuestions(ctx context.Context, exec boil.ContextExecutor, samples int) ([]*LintResult, error) {
+	questions, err := models.Questions(models.QuestionWhere.Removed.EQ("0")).All(ctx, exec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query questions: %w", err)
+	}
+
+	results := map[Problem]*LintResult{}
+	for _, problem := range Problems {
+		results[problem] = &LintResult{Problem: problem}
+	}
+
+	for _, question := range questions {
+		for _, problem := range QuestionProblems(question) {
+			result := results[problem]
+			result.Count++
+			if len(result.Samples) < samples {
+				result.Samples = append(result.Samples, question.ID.Int64)
+			}
+		}
+	}
+
+	ordered := []*LintResult{}
+	for _, problem := range Problems {
+		ordered = append(ordered, results[problem])
+	}
+	return ordered, nil
+}
@@ -0,0 +1,117 @@
+package trivia
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/volatiletech/sqlboiler/v4/boil"
+)
+
+// column is a column added to a table after its initial release. CREATE TABLE
+// IF NOT EXISTS leaves existing tables untouched, so these are added in place
+// when missing.
+type column struct {
+	name       string
+	definition string
+}
+
+var questionColumns = []column{
+	{"category", "TEXT"},
+	{"difficulty", "TEXT"},
+	{"media", "TEXT"},
+	{"used", "INTEGER NOT NULL DEFAULT 0"},
+	{"last_asked", "DATETIME"},
+	{"aliases", "TEXT"},
+}
+
+var userColumns = []column{
+	{"channel", "TEXT NOT NULL DEFAULT ''"},
+	{"max_streak", "INTEGER NOT NULL DEFAULT 0"},
+	{"last_active", "DATETIME"},
+}
+
+// migrateQuestions creates the questions tables and brings an existing
+// questions table up to date with sqlQuestionTable.
+func migrateQuestions(ctx context.Context, db *sql.DB) error {
+	if _, err := db.ExecContext(ctx, sqlQuestionTable); err != nil {
+		return fmt.Errorf("failed to create questions tables: %w", err)
+	}
+	return addMissingColumns(ctx, db, "questions", questionColumns)
+}
+
+// migrateUsers creates the users table and brings an existing users table up
+// to date with sqlUserTable.
+func migrateUsers(ctx context.Context, db *sql.DB) error {
+	if _, err := db.ExecContext(ctx, sqlUserTable); err != nil {
+		return fmt.Errorf("failed to create users table: %w", err)
+	}
+	return addMissingColumns(ctx, db, "users", userColumns)
+}
+
+// migrateParticipations creates the participations table.
+func migrateParticipations(ctx context.Context, db *sql.DB) error {
+	if _, err := db.ExecContext(ctx, sqlParticipationTable); err != nil {
+		return fmt.Errorf("failed to create participations table: %w", err)
+	}
+	return nil
+}
+
+// migrateChannelConfigs creates the channel_configs table.
+func migrateChannelConfigs(ctx context.Context, db *sql.DB) error {
+	if _, err := db.ExecContext(ctx, sqlChannelConfigTable); err != nil {
+		return fmt.Errorf("failed to create channel_configs table: %w", err)
+	}
+	return nil
+}
+
+// migrateQuizzes creates the quizzes table.
+func migrateQuizzes(ctx context.Context, db *sql.DB) error {
+	if _, err := db.ExecContext(ctx, sqlQuizTable); err != nil {
+		return fmt.Errorf("failed to create quizzes table: %w", err)
+	}
+	return nil
+}
+
+func addMissingColumns(ctx context.Context, db *sql.DB, table string, columns []column) error {
+	existing, err := tableColumns(ctx, db, table)
+	if err != nil {
+		return err
+	}
+
+	for _, column := range columns {
+		if existing[column.name] {
+			continue
+		}
+
+		stmt := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column.name, column.definition)
+		if _, err = db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to add column %s to %s: %w", column.name, table, err)
+		}
+	}
+
+	return nil
+}
+
+func tableColumns(ctx context.Context, exec boil.ContextExecutor, table string) (map[string]bool, error) {
+	rows, err := exec.QueryContext(ctx, fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query columns of %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	columns := map[string]bool{}
+	for rows.Next() {
+		var (
+			cid, notNull, pk int
+			name, kind       string
+			dflt             sql.NullString
+		)
+		if err = rows.Scan(&cid, &name, &kind, &notNull, &dflt, &pk); err != nil {
+			return nil, fmt.Errorf("failed to scan columns of %s: %w", table, err)
+		}
+		columns[name] = true
+	}
+
+	return columns, rows.Err()
+}
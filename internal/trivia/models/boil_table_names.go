@@ -4,10 +4,14 @@
 package models
 
 var TableNames = struct {
+	ChannelConfigs   string
+	Participations   string
 	QuestionSequence string
 	Questions        string
 	Users            string
 }{
+	ChannelConfigs:   "channel_configs",
+	Participations:   "participations",
 	QuestionSequence: "question_sequence",
 	Questions:        "questions",
 	Users:            "users",
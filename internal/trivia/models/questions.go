@@ -32,6 +32,12 @@ type Question struct {
 	Source         string      `boil:"source" json:"source" toml:"source" yaml:"source"`
 	Type           null.String `boil:"type" json:"type,omitempty" toml:"type" yaml:"type,omitempty"`
 	Removed        string      `boil:"removed" json:"removed" toml:"removed" yaml:"removed"`
+	Category       null.String `boil:"category" json:"category,omitempty" toml:"category" yaml:"category,omitempty"`
+	Difficulty     null.String `boil:"difficulty" json:"difficulty,omitempty" toml:"difficulty" yaml:"difficulty,omitempty"`
+	Media          null.String `boil:"media" json:"media,omitempty" toml:"media" yaml:"media,omitempty"`
+	Used           int64       `boil:"used" json:"used" toml:"used" yaml:"used"`
+	LastAsked      null.Time   `boil:"last_asked" json:"lastAsked,omitempty" toml:"lastAsked" yaml:"lastAsked,omitempty"`
+	Aliases        null.String `boil:"aliases" json:"aliases,omitempty" toml:"aliases" yaml:"aliases,omitempty"`
 
 	R *questionR `boil:"-" json:"-" toml:"-" yaml:"-"`
 	L questionL  `boil:"-" json:"-" toml:"-" yaml:"-"`
@@ -46,6 +52,12 @@ var QuestionColumns = struct {
 	Source         string
 	Type           string
 	Removed        string
+	Category       string
+	Difficulty     string
+	Media          string
+	Used           string
+	LastAsked      string
+	Aliases        string
 }{
 	ID:             "id",
 	QuestionNumber: "question_number",
@@ -55,6 +67,12 @@ var QuestionColumns = struct {
 	Source:         "source",
 	Type:           "type",
 	Removed:        "removed",
+	Category:       "category",
+	Difficulty:     "difficulty",
+	Media:          "media",
+	Used:           "used",
+	LastAsked:      "last_asked",
+	Aliases:        "aliases",
 }
 
 var QuestionTableColumns = struct {
@@ -66,6 +84,12 @@ var QuestionTableColumns = struct {
 	Source         string
 	Type           string
 	Removed        string
+	Category       string
+	Difficulty     string
+	Media          string
+	Used           string
+	LastAsked      string
+	Aliases        string
 }{
 	ID:             "questions.id",
 	QuestionNumber: "questions.question_number",
@@ -75,6 +99,12 @@ var QuestionTableColumns = struct {
 	Source:         "questions.source",
 	Type:           "questions.type",
 	Removed:        "questions.removed",
+	Category:       "questions.category",
+	Difficulty:     "questions.difficulty",
+	Media:          "questions.media",
+	Used:           "questions.used",
+	LastAsked:      "questions.last_asked",
+	Aliases:        "questions.aliases",
 }
 
 // Generated where
@@ -150,6 +180,30 @@ func (w whereHelpernull_String) GTE(x null.String) qm.QueryMod {
 func (w whereHelpernull_String) IsNull() qm.QueryMod    { return qmhelper.WhereIsNull(w.field) }
 func (w whereHelpernull_String) IsNotNull() qm.QueryMod { return qmhelper.WhereIsNotNull(w.field) }
 
+type whereHelpernull_Time struct{ field string }
+
+func (w whereHelpernull_Time) EQ(x null.Time) qm.QueryMod {
+	return qmhelper.WhereNullEQ(w.field, false, x)
+}
+func (w whereHelpernull_Time) NEQ(x null.Time) qm.QueryMod {
+	return qmhelper.WhereNullEQ(w.field, true, x)
+}
+func (w whereHelpernull_Time) LT(x null.Time) qm.QueryMod {
+	return qmhelper.Where(w.field, qmhelper.LT, x)
+}
+func (w whereHelpernull_Time) LTE(x null.Time) qm.QueryMod {
+	return qmhelper.Where(w.field, qmhelper.LTE, x)
+}
+func (w whereHelpernull_Time) GT(x null.Time) qm.QueryMod {
+	return qmhelper.Where(w.field, qmhelper.GT, x)
+}
+func (w whereHelpernull_Time) GTE(x null.Time) qm.QueryMod {
+	return qmhelper.Where(w.field, qmhelper.GTE, x)
+}
+
+func (w whereHelpernull_Time) IsNull() qm.QueryMod    { return qmhelper.WhereIsNull(w.field) }
+func (w whereHelpernull_Time) IsNotNull() qm.QueryMod { return qmhelper.WhereIsNotNull(w.field) }
+
 var QuestionWhere = struct {
 	ID             whereHelpernull_Int64
 	QuestionNumber whereHelperint64
@@ -159,6 +213,12 @@ var QuestionWhere = struct {
 	Source         whereHelperstring
 	Type           whereHelpernull_String
 	Removed        whereHelperstring
+	Category       whereHelpernull_String
+	Difficulty     whereHelpernull_String
+	Media          whereHelpernull_String
+	Used           whereHelperint64
+	LastAsked      whereHelpernull_Time
+	Aliases        whereHelpernull_String
 }{
 	ID:             whereHelpernull_Int64{field: "\"questions\".\"id\""},
 	QuestionNumber: whereHelperint64{field: "\"questions\".\"question_number\""},
@@ -168,6 +228,12 @@ var QuestionWhere = struct {
 	Source:         whereHelperstring{field: "\"questions\".\"source\""},
 	Type:           whereHelpernull_String{field: "\"questions\".\"type\""},
 	Removed:        whereHelperstring{field: "\"questions\".\"removed\""},
+	Category:       whereHelpernull_String{field: "\"questions\".\"category\""},
+	Difficulty:     whereHelpernull_String{field: "\"questions\".\"difficulty\""},
+	Media:          whereHelpernull_String{field: "\"questions\".\"media\""},
+	Used:           whereHelperint64{field: "\"questions\".\"used\""},
+	LastAsked:      whereHelpernull_Time{field: "\"questions\".\"last_asked\""},
+	Aliases:        whereHelpernull_String{field: "\"questions\".\"aliases\""},
 }
 
 // QuestionRels is where relationship names are stored.
@@ -187,9 +253,9 @@ func (*questionR) NewStruct() *questionR {
 type questionL struct{}
 
 var (
-	questionAllColumns            = []string{"id", "question_number", "question", "answer", "choices", "source", "type", "removed"}
+	questionAllColumns            = []string{"id", "question_number", "question", "answer", "choices", "source", "type", "removed", "category", "difficulty", "media", "used", "last_asked", "aliases"}
 	questionColumnsWithoutDefault = []string{}
-	questionColumnsWithDefault    = []string{"id", "question_number", "question", "answer", "choices", "source", "type", "removed"}
+	questionColumnsWithDefault    = []string{"id", "question_number", "question", "answer", "choices", "source", "type", "removed", "category", "difficulty", "media", "used", "last_asked", "aliases"}
 	questionPrimaryKeyColumns     = []string{"id"}
 	questionGeneratedColumns      = []string{}
 )
@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"github.com/friendsofgo/errors"
+	"github.com/volatiletech/null/v8"
 	"github.com/volatiletech/sqlboiler/v4/boil"
 	"github.com/volatiletech/sqlboiler/v4/queries"
 	"github.com/volatiletech/sqlboiler/v4/queries/qm"
@@ -23,10 +24,13 @@ import (
 
 // User is an object representing the database table.
 type User struct {
-	ID          int64  `boil:"id" json:"id" toml:"id" yaml:"id"`
-	Name        string `boil:"name" json:"name" toml:"name" yaml:"name"`
-	Points      int64  `boil:"points" json:"points" toml:"points" yaml:"points"`
-	GamesPlayed int64  `boil:"games_played" json:"gamesPlayed" toml:"gamesPlayed" yaml:"gamesPlayed"`
+	ID          int64     `boil:"id" json:"id" toml:"id" yaml:"id"`
+	Name        string    `boil:"name" json:"name" toml:"name" yaml:"name"`
+	Points      int64     `boil:"points" json:"points" toml:"points" yaml:"points"`
+	GamesPlayed int64     `boil:"games_played" json:"gamesPlayed" toml:"gamesPlayed" yaml:"gamesPlayed"`
+	Channel     string    `boil:"channel" json:"channel" toml:"channel" yaml:"channel"`
+	MaxStreak   int64     `boil:"max_streak" json:"maxStreak" toml:"maxStreak" yaml:"maxStreak"`
+	LastActive  null.Time `boil:"last_active" json:"lastActive,omitempty" toml:"lastActive" yaml:"lastActive,omitempty"`
 
 	R *userR `boil:"-" json:"-" toml:"-" yaml:"-"`
 	L userL  `boil:"-" json:"-" toml:"-" yaml:"-"`
@@ -37,11 +41,17 @@ var UserColumns = struct {
 	Name        string
 	Points      string
 	GamesPlayed string
+	Channel     string
+	MaxStreak   string
+	LastActive  string
 }{
 	ID:          "id",
 	Name:        "name",
 	Points:      "points",
 	GamesPlayed: "games_played",
+	Channel:     "channel",
+	MaxStreak:   "max_streak",
+	LastActive:  "last_active",
 }
 
 var UserTableColumns = struct {
@@ -49,11 +59,17 @@ var UserTableColumns = struct {
 	Name        string
 	Points      string
 	GamesPlayed string
+	Channel     string
+	MaxStreak   string
+	LastActive  string
 }{
 	ID:          "users.id",
 	Name:        "users.name",
 	Points:      "users.points",
 	GamesPlayed: "users.games_played",
+	Channel:     "users.channel",
+	MaxStreak:   "users.max_streak",
+	LastActive:  "users.last_active",
 }
 
 // Generated where
@@ -63,11 +79,17 @@ var UserWhere = struct {
 	Name        whereHelperstring
 	Points      whereHelperint64
 	GamesPlayed whereHelperint64
+	Channel     whereHelperstring
+	MaxStreak   whereHelperint64
+	LastActive  whereHelpernull_Time
 }{
 	ID:          whereHelperint64{field: "\"users\".\"id\""},
 	Name:        whereHelperstring{field: "\"users\".\"name\""},
 	Points:      whereHelperint64{field: "\"users\".\"points\""},
 	GamesPlayed: whereHelperint64{field: "\"users\".\"games_played\""},
+	Channel:     whereHelperstring{field: "\"users\".\"channel\""},
+	MaxStreak:   whereHelperint64{field: "\"users\".\"max_streak\""},
+	LastActive:  whereHelpernull_Time{field: "\"users\".\"last_active\""},
 }
 
 // UserRels is where relationship names are stored.
@@ -87,9 +109,9 @@ func (*userR) NewStruct() *userR {
 type userL struct{}
 
 var (
-	userAllColumns            = []string{"id", "name", "points", "games_played"}
+	userAllColumns            = []string{"id", "name", "points", "games_played", "channel", "max_streak", "last_active"}
 	userColumnsWithoutDefault = []string{"name", "points", "games_played"}
-	userColumnsWithDefault    = []string{"id"}
+	userColumnsWithDefault    = []string{"id", "channel", "max_streak", "last_active"}
 	userPrimaryKeyColumns     = []string{"id"}
 	userGeneratedColumns      = []string{}
 )
@@ -101,12 +101,12 @@ func (s *OpenTDBSource) refreshCache() error {
 			Question: result.Question,
 			Type:     result.Type,
 			Answers: []*Answer{
-				{result.CorrectAnswer, true},
+				{Value: result.CorrectAnswer, Correct: true},
 			},
 		}
 
 		for _, value := range result.IncorrectAnswers {
-			q.Answers = append(q.Answers, &Answer{value, false})
+			q.Answers = append(q.Answers, &Answer{Value: value})
 		}
 
 		s.cache = append(s.cache, q)
@@ -0,0 +1,60 @@
+package trivia
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/jbpratt/bots/internal/trivia/models"
+	"github.com/volatiletech/null/v8"
+)
+
+// SeedableSource is a Source which can repeat the same questions in the same
+// order for the same seed.
+type SeedableSource interface {
+	Seeded(filter Filter, seed int64) (Source, error)
+}
+
+// DailySeed returns the seed of the daily challenge of day's date in UTC, the
+// same all day long.
+func DailySeed(day time.Time) int64 {
+	y, m, d := day.UTC().Date()
+	return int64(y*10000 + int(m)*100 + d)
+}
+
+// seededDBSource hands out questions by ID in a fixed order, see
+// DBSource.Seeded.
+type seededDBSource struct {
+	ids  []int64
+	next int
+}
+
+// Seeded returns a Source handing out the questions matching filter in an
+// order determined by seed, wrapping around once all have been asked.
+// Sources with the same seed ask the same questions for as long as the
+// matching questions don't change. A question deleted since fails with
+// sql.ErrNoRows, which NewQuiz substitutes with the next in order.
+func (s *DBSource) Seeded(filter Filter, seed int64) (Source, error) {
+	ids, err := (&filteredDBSource{filter: filter}).ids(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	rand.New(rand.NewSource(seed)).Shuffle(len(ids), func(i, j int) {
+		ids[i], ids[j] = ids[j], ids[i]
+	})
+
+	return &seededDBSource{ids: ids}, nil
+}
+
+func (s *seededDBSource) Question() (*Question, error) {
+	id := s.ids[s.next%len(s.ids)]
+	s.next++
+
+	question, err := models.FindQuestionG(context.Background(), null.Int64From(id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query question %d: %w", id, err)
+	}
+
+	return newQuestionFromModel(question), nil
+}
@@ -0,0 +1,176 @@
+package trivia
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const sqlQuizTable = `
+/*
+  Store the state of finished quizzes as JSON, see QuizState, so what was
+  asked can be shown as it was, in the order answers were shown, even after
+  a restart.
+*/
+CREATE TABLE IF NOT EXISTS quizzes (
+  id       INTEGER NOT NULL PRIMARY KEY,
+  quiz_id  TEXT    NOT NULL,
+  channel  TEXT    NOT NULL DEFAULT '',
+  state    TEXT    NOT NULL,
+  ended_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS quizzes_channel ON quizzes(channel, ended_at);
+`
+
+// QuizState is a copy of a quiz's state at one point in time, see
+// Quiz.State.
+type QuizState struct {
+	ID         string `json:"id"`
+	InProgress bool   `json:"inProgress"`
+	// CurrentRound is the index in Rounds of the most recently started
+	// round, or -1 if none has been started yet.
+	CurrentRound int            `json:"currentRound"`
+	Size         int            `json:"size"`
+	Rounds       []RoundState   `json:"rounds"`
+	Scoreboard   map[string]int `json:"scoreboard"`
+}
+
+// RoundState is a copy of a round's state, including its correct answer.
+type RoundState struct {
+	Num        int       `json:"num"`
+	WarmUp     bool      `json:"warmUp"`
+	Final      bool      `json:"final"`
+	Multiplier int       `json:"multiplier"`
+	Complete   bool      `json:"complete"`
+	StartedAt  time.Time `json:"startedAt"`
+	EndedAt    time.Time `json:"endedAt"`
+	Question   string    `json:"question"`
+	// Answers are in the order they were asked.
+	Answers      []Answer      `json:"answers"`
+	Votes        []int         `json:"votes"`
+	Participants []Participant `json:"participants"`
+}
+
+// State copies the current state of the quiz, taken under its locks so it is
+// consistent even while rounds are being played.
+func (q *Quiz) State() QuizState {
+	q.rw.RLock()
+	defer q.rw.RUnlock()
+
+	state := QuizState{
+		ID:           q.ID,
+		InProgress:   q.inProgress,
+		CurrentRound: int(q.currentRound.Load()),
+		Size:         q.size,
+		Rounds:       []RoundState{},
+		Scoreboard:   map[string]int{},
+	}
+	for name, points := range q.Scoreboard {
+		state.Scoreboard[name] = points
+	}
+	for _, round := range q.Rounds {
+		state.Rounds = append(state.Rounds, round.state())
+	}
+
+	return state
+}
+
+// Snapshot returns the current state of the quiz as JSON, for debugging.
+func (q *Quiz) Snapshot() ([]byte, error) {
+	data, err := json.Marshal(q.State())
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal quiz %s: %w", q.ID, err)
+	}
+	return data, nil
+}
+
+func (r *Round) state() RoundState {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	state := RoundState{
+		Num:          r.Num,
+		WarmUp:       r.WarmUp,
+		Final:        r.Final,
+		Multiplier:   r.Multiplier,
+		Complete:     r.Complete,
+		StartedAt:    r.StartedAt,
+		EndedAt:      r.endedAt,
+		Question:     r.Question.Question,
+		Answers:      []Answer{},
+		Votes:        append([]int{}, r.Votes...),
+		Participants: []Participant{},
+	}
+	for _, ans := range r.Question.Answers {
+		state.Answers = append(state.Answers, *ans)
+	}
+	for _, p := range r.Participants {
+		state.Participants = append(state.Participants, *p)
+	}
+
+	return state
+}
+
+// AverageTime returns how long participants took to answer on average, from
+// when the round started, and false if nobody answered.
+func (r RoundState) AverageTime() (time.Duration, bool) {
+	if len(r.Participants) == 0 {
+		return 0, false
+	}
+
+	var total time.Duration
+	for _, participant := range r.Participants {
+		total += participant.TimeToSubmission
+	}
+	return total / time.Duration(len(r.Participants)), true
+}
+
+// RecordQuiz stores state, that of a finished quiz, as the channel's latest.
+func (l *Leaderboard) RecordQuiz(state QuizState) error {
+	l.rw.Lock()
+	defer l.rw.Unlock()
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to marshal quiz %s: %w", state.ID, err)
+	}
+
+	_, err = l.db.ExecContext(context.Background(),
+		"INSERT INTO quizzes (quiz_id, channel, state, ended_at) VALUES (?, ?, ?, ?)",
+		state.ID, l.channel, string(data), time.Now(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record quiz %s: %w", state.ID, err)
+	}
+
+	return nil
+}
+
+// LastQuiz returns the state of the channel's latest quiz stored with
+// RecordQuiz, or nil if there is none.
+func (l *Leaderboard) LastQuiz() (*QuizState, error) {
+	l.rw.RLock()
+	defer l.rw.RUnlock()
+
+	var data string
+	err := l.db.QueryRowContext(context.Background(),
+		"SELECT state FROM quizzes WHERE channel = ? ORDER BY ended_at DESC, id DESC LIMIT 1",
+		l.channel,
+	).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query the last quiz: %w", err)
+	}
+
+	state := &QuizState{}
+	if err = json.Unmarshal([]byte(data), state); err != nil {
+		return nil, fmt.Errorf("failed to parse the last quiz: %w", err)
+	}
+	return state, nil
+}
@@ -0,0 +1,38 @@
+package trivia
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jbpratt/bots/internal/trivia/models"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+)
+
+// submissionPrefix starts the source of every question submitted by a user,
+// followed by their name.
+const submissionPrefix = "user:"
+
+// SubmissionSource returns the source of the questions submitted by name.
+func SubmissionSource(name string) string {
+	return submissionPrefix + name
+}
+
+// Submissions returns the count of questions submitted by name along with
+// the latest limit of them, newest first. Submissions which have been removed
+// are included.
+func Submissions(ctx context.Context, exec boil.ContextExecutor, name string, limit int) (int64, models.QuestionSlice, error) {
+	mods := []qm.QueryMod{models.QuestionWhere.Source.EQ(SubmissionSource(name))}
+
+	count, err := models.Questions(mods...).Count(ctx, exec)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to count submissions: %w", err)
+	}
+
+	questions, err := models.Questions(append(mods, qm.OrderBy("id desc"), qm.Limit(limit))...).All(ctx, exec)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to query submissions: %w", err)
+	}
+
+	return count, questions, nil
+}
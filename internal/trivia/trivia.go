@@ -2,48 +2,499 @@
 package trivia
 
 import (
+	"database/sql"
 	"errors"
 	"fmt"
 	"math/rand"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+	"unicode/utf8"
 
+	"github.com/dustin/go-humanize"
 	"go.uber.org/zap"
 )
 
+// ErrNoQuestions is returned by a Source which has no questions left to give.
+var ErrNoQuestions = errors.New("no questions found")
+
+var (
+	// ErrAlreadyAnswered is returned when a participant answers a round twice,
+	// unless the quiz lets answers be changed.
+	ErrAlreadyAnswered = errors.New("already answered")
+	// ErrInvalidAnswer is returned for an answer which is not one of the
+	// question's choices.
+	ErrInvalidAnswer = errors.New("invalid answer")
+	// ErrAmbiguousAnswer is returned for answer text matching more than one
+	// of the question's choices.
+	ErrAmbiguousAnswer = errors.New("ambiguous answer")
+	// ErrPaused is returned for an answer sent while the round is paused.
+	ErrPaused = errors.New("round paused")
+)
+
+// maxSkippedQuestions is how many broken questions NewQuiz skips before giving
+// up on a source.
+const maxSkippedQuestions = 10
+
+// maxRepeatedQuestions is how many repeated questions in a row a quiz of
+// Distinct questions draws before deciding its source has run out.
+const maxRepeatedQuestions = 10
+
+// minChoices is how many choices a question needs to be asked, and
+// minMultipleChoices how many a multiple choice question needs. Questions
+// with fewer are skipped rather than padded with made up distractors, which
+// would be easy to rule out.
+const (
+	minChoices         = 2
+	minMultipleChoices = 4
+)
+
+// MaxChoicesLength is the most characters the choices of a question may add up
+// to. Longer ones, most likely a malformed import, would flood chat and are
+// neither imported nor asked.
+const MaxChoicesLength = 400
+
+// MinChoices returns how many choices a question of questionType needs to be
+// asked.
+func MinChoices(questionType string) int {
+	if questionType == "multiple" {
+		return minMultipleChoices
+	}
+	return minChoices
+}
+
+// clockSkew is how far before a round's start an answer may be timestamped
+// and still be accepted, as the chat server's clock may lag behind ours.
+const clockSkew = time.Second
+
+// OutsideWindowError is returned for an answer timestamped before its round
+// started or after it ended, such as one delayed in delivery.
+type OutsideWindowError struct {
+	TimeIn    time.Time
+	StartedAt time.Time
+	// EndedAt is zero while the round is still open.
+	EndedAt time.Time
+}
+
+func (e *OutsideWindowError) Error() string {
+	if !e.EndedAt.IsZero() && !e.TimeIn.Before(e.StartedAt) {
+		return fmt.Sprintf("answer at %s arrived after the round ended at %s", e.TimeIn.Format(time.StampMilli), e.EndedAt.Format(time.StampMilli))
+	}
+	return fmt.Sprintf("answer at %s arrived before the round started at %s", e.TimeIn.Format(time.StampMilli), e.StartedAt.Format(time.StampMilli))
+}
+
+// Source hands out the questions of quizzes. DBSource is the default, but a
+// Source may be backed by anything, like a file or an HTTP API. Implementing
+// FilterableSource too lets quizzes pick a category or difficulty, and
+// SeedableSource the daily challenge.
 type Source interface {
+	// Question returns the next question to ask, or an error wrapping
+	// ErrNoQuestions if there are none to ask.
 	Question() (*Question, error)
 }
 
+// FilterableSource is a Source which can narrow its questions down to those
+// matching a Filter.
+type FilterableSource interface {
+	Source
+	Filtered(Filter) Source
+	// Categories and Difficulties list the values questions can be filtered
+	// by.
+	Categories() ([]string, error)
+	Difficulties() ([]string, error)
+}
+
+// Filter restricts the questions drawn for a quiz. Empty fields match any
+// question.
+type Filter struct {
+	Category   string
+	Difficulty string
+	// Exclude holds the IDs of questions which must not be drawn.
+	Exclude []int64
+}
+
+func (f Filter) IsZero() bool {
+	return f.Category == "" && f.Difficulty == "" && len(f.Exclude) == 0
+}
+
+func (f Filter) String() string {
+	parts := []string{}
+	if f.Category != "" {
+		parts = append(parts, fmt.Sprintf("category %q", f.Category))
+	}
+	if f.Difficulty != "" {
+		parts = append(parts, fmt.Sprintf("difficulty %q", f.Difficulty))
+	}
+	if len(parts) == 0 {
+		parts = append(parts, "any question")
+	}
+	if len(f.Exclude) > 0 {
+		return fmt.Sprintf("%s excluding %d asked", strings.Join(parts, " and "), len(f.Exclude))
+	}
+	return strings.Join(parts, " and ")
+}
+
 type Question struct {
-	Question string
-	Type     string
-	Answers  []*Answer
+	// ID is the question's row in the questions table, or zero if it did not
+	// come from the database.
+	ID         int64
+	Question   string
+	Type       string
+	Category   string
+	Difficulty string
+	// Media is an optional image or audio URL accompanying the question.
+	Media string
+	// Source names where the question was collected from, like "opentdb",
+	// or is empty if unknown.
+	Source  string
+	Answers []*Answer
+}
+
+// Correct returns the correct answer and its index in Answers, or nil if the
+// question has none.
+func (q *Question) Correct() (int, *Answer) {
+	for idx, ans := range q.Answers {
+		if ans.Correct {
+			return idx, ans
+		}
+	}
+	return -1, nil
+}
+
+// key identifies the question among those of a quiz, by its ID if it came
+// from the database and by its text otherwise.
+func (q *Question) key() string {
+	if q.ID != 0 {
+		return "#" + strconv.FormatInt(q.ID, 10)
+	}
+	return q.Question
+}
+
+// problem describes why the question can't be asked, or is empty if it can.
+func (q *Question) problem() string {
+	// a question whose answer is not among its choices can't be won
+	if _, ans := q.Correct(); ans == nil {
+		return "its answer not among the choices"
+	}
+
+	choices := 0
+	for _, ans := range q.Answers {
+		if strings.TrimSpace(ans.Value) != "" {
+			choices++
+		}
+	}
+	if min := MinChoices(q.Type); choices < min {
+		return fmt.Sprintf("%d of at least %d choices", choices, min)
+	}
+
+	if length := q.ChoicesLength(); length > MaxChoicesLength {
+		return fmt.Sprintf("choices %d characters long, over %d", length, MaxChoicesLength)
+	}
+
+	return ""
+}
+
+// ChoicesLength returns how many characters the question's choices add up to.
+func (q *Question) ChoicesLength() int {
+	length := 0
+	for _, ans := range q.Answers {
+		length += utf8.RuneCountInString(ans.Value)
+	}
+	return length
+}
+
+// ParseAnswer returns the index of the answer picked by data, which is the
+// answer's number counting from 1 or, for boolean questions, its word (true or
+// false, in any case). The index is not checked to be in range.
+func (q *Question) ParseAnswer(data string) (int, bool) {
+	data = strings.TrimSpace(data)
+	if num, err := strconv.Atoi(data); err == nil {
+		return num - 1, true
+	}
+
+	if q.Type == "boolean" {
+		for idx, ans := range q.Answers {
+			if answersEqual(ans.Value, data) {
+				return idx, true
+			}
+		}
+	}
+
+	return 0, false
+}
+
+// Choice returns the answer at index idx as shown to players, like
+// "`2) Paris`", or false if there is no such answer.
+func (q *Question) Choice(idx int) (string, bool) {
+	if idx < 0 || idx >= len(q.Answers) {
+		return "", false
+	}
+	return fmt.Sprintf("`%d) %s`", idx+1, q.Answers[idx].Value), true
+}
+
+// MatchChoice returns the index of the answer whose text, or one of its
+// aliases, is data, ignoring case and whitespace, or failing that, the only
+// answer starting with data.
+// It returns ErrAmbiguousAnswer if data matches several answers, and
+// ErrInvalidAnswer if it matches none.
+func (q *Question) MatchChoice(data string) (int, error) {
+	data = strings.Join(strings.Fields(data), " ")
+	if data == "" {
+		return 0, ErrInvalidAnswer
+	}
+
+	for _, matches := range []func(string) bool{
+		func(value string) bool { return answersEqual(value, data) },
+		func(value string) bool {
+			value = strings.Join(strings.Fields(value), " ")
+			return strings.HasPrefix(strings.ToLower(value), strings.ToLower(data))
+		},
+	} {
+		found := []int{}
+		for idx, ans := range q.Answers {
+			if ans.matches(matches) {
+				found = append(found, idx)
+			}
+		}
+
+		switch len(found) {
+		case 0:
+			continue
+		case 1:
+			return found[0], nil
+		default:
+			return 0, ErrAmbiguousAnswer
+		}
+	}
+
+	return 0, ErrInvalidAnswer
+}
+
+// answersEqual reports whether two answers are the same once case and
+// whitespace are normalized, since imported choices are often padded or
+// inconsistently capitalized.
+func answersEqual(a, b string) bool {
+	return strings.EqualFold(strings.Join(strings.Fields(a), " "), strings.Join(strings.Fields(b), " "))
 }
 
 type Answer struct {
 	Value   string
 	Correct bool
+	// Aliases are alternate texts accepted for the answer when answering
+	// with text, like "USA" for "United States".
+	Aliases []string `json:",omitempty"`
+}
+
+// matches reports whether matches accepts the answer's value or any of its
+// aliases.
+func (a *Answer) matches(matches func(string) bool) bool {
+	if matches(a.Value) {
+		return true
+	}
+	for _, alias := range a.Aliases {
+		if matches(alias) {
+			return true
+		}
+	}
+	return false
 }
 
 type Participant struct {
 	Name             string
 	Choice           int
 	TimeToSubmission time.Duration
+	// Suspicious marks an answer which arrived in a burst, see
+	// BurstDetection.
+	Suspicious bool
+	// Late marks an answer which arrived during the grace period after the
+	// round closed, see QuizOptions.Grace.
+	Late bool
 }
 
 type Quiz struct {
+	// ID identifies the quiz in logs.
+	ID           string
 	rw           sync.RWMutex
 	logger       *zap.SugaredLogger
 	duration     time.Duration
-	currentRound int
+	currentRound atomic.Int32
 	rng          *rand.Rand
 	Rounds       []*Round
 	Timer        *time.Timer
 	inProgress   bool
 	Scoreboard   map[string]int
+	speed        map[string]time.Duration
+	// streak counts each player's current run of correct answers, and
+	// bestStreak their longest run in the quiz.
+	streak     map[string]int
+	bestStreak map[string]int
+	// players is everyone who answered any completed round, scored or not.
+	players    map[string]bool
+	size       int
+	endEarly   int
+	change     bool
+	burst      BurstDetection
+	grace      time.Duration
+	latePoints int
+	allCorrect AllCorrectScoring
+	ties       TieScoring
+	requeue    bool
+	// reset marks a quiz given up on by Reset, whose rounds are no longer
+	// scored or announced.
+	reset bool
+}
+
+// QuizOptions configure a quiz.
+type QuizOptions struct {
+	// Size is the number of scored rounds.
+	Size int
+	// Duration is the time given to answer each round.
+	Duration time.Duration
+	// EndEarly ends each round as soon as this many players have answered
+	// correctly, rather than waiting out the full duration. Zero disables it.
+	EndEarly int
+	// WarmUp plays an example round before the scored ones, which awards no
+	// points, so new players can learn how to answer.
+	WarmUp bool
+	// AllCorrect scores rounds which every participant answered correctly.
+	AllCorrect AllCorrectScoring
+	// Ties scores correct answers which arrived at the same time.
+	Ties TieScoring
+	// ChangeAnswers lets players change their answer until the round closes,
+	// scoring their latest answer as if it were their first.
+	ChangeAnswers bool
+	// DoubleRound is the number of a scored round worth double points, or
+	// zero for none.
+	DoubleRound int
+	// DoubleChance is the chance of each scored round being worth double
+	// points, from 0 to 1.
+	DoubleChance float64
+	// Burst flags answers arriving too close together to be typed by hand.
+	Burst BurstDetection
+	// Grace keeps accepting answers for this long after a round closes, for
+	// answers delayed on their way. Late answers are recorded like any
+	// other, but a correct one only scores LatePoints rather than a ranked
+	// position. Zero disables it.
+	Grace      time.Duration
+	LatePoints int
+	// Distinct draws again in place of questions already in the quiz. If
+	// the source runs out, the quiz is cut short to the questions it has.
+	Distinct bool
+	// Seed makes the order answers are shuffled in, and which rounds are
+	// worth double points, the same for every quiz with the seed, unless
+	// zero. Pair it with a source seeded the same, see SeedableSource.
+	Seed int64
+	// RequeueSkipped asks the question of a skipped round again as an extra
+	// round at the end of the quiz, rather than discarding it. A question is
+	// only requeued once.
+	RequeueSkipped bool
+}
+
+// BurstDetection flags answers which arrive in a tight cluster, as scripts
+// answering for several accounts at once do. Flagged answers are logged and
+// marked Suspicious, but still scored. The zero value disables it.
+type BurstDetection struct {
+	// Size is how many answers make a burst, at least 2 to enable it.
+	Size int
+	// Window is how far apart the answers of a burst may be, with zero only
+	// counting identical timestamps.
+	Window time.Duration
+}
+
+func (b BurstDetection) enabled() bool {
+	return b.Size > 1
+}
+
+// AllCorrectScoring is how a round is scored when every participant answered
+// correctly.
+type AllCorrectScoring int
+
+const (
+	// AllCorrectRanked ranks the participants by speed like any other round.
+	AllCorrectRanked AllCorrectScoring = iota
+	// AllCorrectFlat awards every participant a single point.
+	AllCorrectFlat
+)
+
+func (s AllCorrectScoring) String() string {
+	switch s {
+	case AllCorrectRanked:
+		return "ranked"
+	case AllCorrectFlat:
+		return "flat"
+	default:
+		return fmt.Sprintf("AllCorrectScoring(%d)", int(s))
+	}
+}
+
+// ParseAllCorrectScoring returns the AllCorrectScoring named s.
+func ParseAllCorrectScoring(s string) (AllCorrectScoring, error) {
+	for _, scoring := range []AllCorrectScoring{AllCorrectRanked, AllCorrectFlat} {
+		if scoring.String() == s {
+			return scoring, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown all correct scoring %q, want ranked or flat", s)
+}
+
+// TieScoring is how correct answers sent at the same millisecond are ranked
+// against each other.
+type TieScoring int
+
+const (
+	// TiesByArrival ranks tied answers in the order they reached the bot, so
+	// every position is held by one player.
+	TiesByArrival TieScoring = iota
+	// TiesShared gives tied answers the same position and points, pushing the
+	// next answer down as many positions as there were tied players, so two
+	// players tied for 1st are followed by the 3rd.
+	TiesShared
+)
+
+func (s TieScoring) String() string {
+	switch s {
+	case TiesByArrival:
+		return "arrival"
+	case TiesShared:
+		return "shared"
+	default:
+		return fmt.Sprintf("TieScoring(%d)", int(s))
+	}
+}
+
+// ParseTieScoring returns the TieScoring named s.
+func ParseTieScoring(s string) (TieScoring, error) {
+	for _, scoring := range []TieScoring{TiesByArrival, TiesShared} {
+		if scoring.String() == s {
+			return scoring, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown tie scoring %q, want arrival or shared", s)
+}
+
+// Positions returns the position, from 0, of each of winners, ordered by
+// speed as DetermineOutcome returns them, with ties scored as given.
+func Positions(winners []*Participant, ties TieScoring) []int {
+	positions := make([]int, len(winners))
+	for i, v := range winners {
+		positions[i] = i
+		if ties == TiesShared && i > 0 && v.TimeToSubmission == winners[i-1].TimeToSubmission {
+			positions[i] = positions[i-1]
+		}
+	}
+	return positions
+}
+
+// Score is a player's standing in a quiz.
+type Score struct {
+	Name   string
+	Points int
+	// Speed is the cumulative time the player took to submit their correct
+	// answers. Lower is faster.
+	Speed time.Duration
 }
 
 func NewDefaultQuiz(logger *zap.SugaredLogger, source Source) (*Quiz, error) {
@@ -51,35 +502,211 @@ func NewDefaultQuiz(logger *zap.SugaredLogger, source Source) (*Quiz, error) {
 }
 
 func NewQuiz(logger *zap.SugaredLogger, size int, duration time.Duration, source Source) (*Quiz, error) {
+	return NewQuizWithOptions(logger, source, QuizOptions{Size: size, Duration: duration})
+}
+
+func NewQuizWithOptions(logger *zap.SugaredLogger, source Source, opts QuizOptions) (*Quiz, error) {
+	seed := opts.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+
 	quiz := &Quiz{
-		duration:     duration,
-		logger:       logger,
-		rng:          rand.New(rand.NewSource(time.Now().UnixNano())),
-		currentRound: -1,
-		Scoreboard:   map[string]int{},
+		ID:         strconv.FormatInt(time.Now().UnixNano(), 36),
+		duration:   opts.Duration,
+		logger:     logger,
+		rng:        rand.New(rand.NewSource(seed)),
+		Scoreboard: map[string]int{},
+		speed:      map[string]time.Duration{},
+		streak:     map[string]int{},
+		bestStreak: map[string]int{},
+		players:    map[string]bool{},
+		size:       opts.Size,
+		endEarly:   opts.EndEarly,
+		change:     opts.ChangeAnswers,
+		burst:      opts.Burst,
+		grace:      opts.Grace,
+		latePoints: opts.LatePoints,
+		allCorrect: opts.AllCorrect,
+		ties:       opts.Ties,
+		requeue:    opts.RequeueSkipped,
 	}
 
+	quiz.currentRound.Store(-1)
+
 	quiz.logger.Info("creating new series of rounds")
 
-	for i := 0; i < size; i++ {
+	// the warm-up round is numbered 0, ahead of the scored rounds
+	i, size := 1, opts.Size
+	if opts.WarmUp {
+		i = 0
+	}
+
+	skipped, repeated, asked := 0, 0, map[string]bool{}
+	for i <= size {
 		question, err := source.Question()
+		if errors.Is(err, sql.ErrNoRows) {
+			// the question was deleted after the source picked it, like one
+			// scheduled by a seeded source, so ask the next one instead
+			quiz.logger.Warnw("substituting a deleted question", "round", i, "error", err)
+			if skipped++; skipped > maxSkippedQuestions {
+				return nil, fmt.Errorf("skipped %d missing questions: %w", skipped, err)
+			}
+			continue
+		}
 		if err != nil {
 			return nil, err
 		}
 
+		if opts.Distinct {
+			if asked[question.key()] {
+				if repeated++; repeated > maxRepeatedQuestions {
+					quiz.logger.Warnw("ran out of distinct questions", "rounds", len(quiz.Rounds))
+					break
+				}
+				continue
+			}
+			repeated = 0
+			asked[question.key()] = true
+		}
+
+		if problem := question.problem(); problem != "" {
+			quiz.logger.Warnw("skipping broken question", "question", question.Question, "problem", problem)
+			if skipped++; skipped > maxSkippedQuestions {
+				return nil, fmt.Errorf("skipped %d broken questions, the last with %s", skipped, problem)
+			}
+			continue
+		}
+
+		multiplier := 1
+		if i != 0 && (i == opts.DoubleRound || quiz.rng.Float64() < opts.DoubleChance) {
+			multiplier = 2
+		}
+
 		quiz.Rounds = append(quiz.Rounds, &Round{
-			logger:   logger,
-			Question: question,
-			Num:      i + 1,
-			Final:    i == size-1,
+			logger:     logger,
+			Question:   question,
+			Num:        i,
+			Final:      i == size,
+			WarmUp:     i == 0,
+			Multiplier: multiplier,
+			early:      make(chan struct{}, 1),
+			done:       make(chan struct{}),
 		})
+		i++
+	}
+
+	if i <= size {
+		// cut short of distinct questions, i is the round which was missing
+		if i <= 1 {
+			return nil, fmt.Errorf("%w for a quiz of distinct questions", ErrNoQuestions)
+		}
+		quiz.Rounds[len(quiz.Rounds)-1].Final = true
+		quiz.size = i - 1
 	}
 
 	return quiz, nil
 }
 
+// Reset marks the quiz as no longer in progress, without a current round, to
+// recover from a round which never completed. A round still open is ended like
+// any ended early, but neither scored nor announced, and the rounds themselves
+// are left as they were.
+func (q *Quiz) Reset() {
+	q.rw.Lock()
+	defer q.rw.Unlock()
+
+	if round := q.round(int(q.currentRound.Load())); round != nil {
+		round.End()
+	}
+	q.reset = true
+	q.inProgress = false
+	q.currentRound.Store(-1)
+}
+
+// Size returns the number of scored rounds in the quiz.
+func (q *Quiz) Size() int {
+	return q.size
+}
+
+// CurrentRound returns the most recently started round, or nil if no round
+// has been started yet.
 func (q *Quiz) CurrentRound() *Round {
-	return q.Rounds[q.currentRound]
+	// skipped rounds are requeued onto Rounds under the lock
+	q.rw.RLock()
+	defer q.rw.RUnlock()
+	return q.round(int(q.currentRound.Load()))
+}
+
+// NextRound returns the round to be started after the current one, or nil if
+// the current round is the last.
+func (q *Quiz) NextRound() *Round {
+	q.rw.RLock()
+	defer q.rw.RUnlock()
+	return q.round(int(q.currentRound.Load()) + 1)
+}
+
+// round returns the round at idx in Rounds, or nil if there is none. The
+// caller must hold q.rw.
+func (q *Quiz) round(idx int) *Round {
+	if idx < 0 || idx >= len(q.Rounds) {
+		return nil
+	}
+	return q.Rounds[idx]
+}
+
+// Pause freezes the timer of the round in progress, rejecting answers with
+// ErrPaused until Resume. The time spent paused doesn't count towards the
+// round's duration nor the players' answer times.
+func (q *Quiz) Pause() error {
+	q.rw.Lock()
+	defer q.rw.Unlock()
+
+	round := q.round(int(q.currentRound.Load()))
+	if !q.inProgress || round == nil {
+		return errors.New("no round is in progress")
+	}
+
+	round.mu.Lock()
+	defer round.mu.Unlock()
+	if !round.pausedAt.IsZero() {
+		return errors.New("the round is already paused")
+	}
+	if !round.endedAt.IsZero() || !q.Timer.Stop() {
+		return errors.New("the round has already closed")
+	}
+	round.pausedAt = time.Now()
+
+	q.logger.Infow("round paused", "left", round.closesAt.Sub(round.pausedAt))
+	return nil
+}
+
+// Resume restarts the timer of the round frozen by Pause with the time it had
+// left, which is returned.
+func (q *Quiz) Resume() (time.Duration, error) {
+	q.rw.Lock()
+	defer q.rw.Unlock()
+
+	round := q.round(int(q.currentRound.Load()))
+	if !q.inProgress || round == nil {
+		return 0, errors.New("no round is in progress")
+	}
+
+	round.mu.Lock()
+	defer round.mu.Unlock()
+	if round.pausedAt.IsZero() {
+		return 0, errors.New("the round isn't paused")
+	}
+
+	now := time.Now()
+	left := round.closesAt.Sub(round.pausedAt)
+	round.pausedFor += now.Sub(round.pausedAt)
+	round.closesAt = now.Add(left)
+	round.pausedAt = time.Time{}
+	q.Timer.Reset(left)
+
+	q.logger.Infow("round resumed", "left", left)
+	return left, nil
 }
 
 func (q *Quiz) InProgress() bool {
@@ -92,83 +719,281 @@ func (q *Quiz) StartRound(
 	onComplete func(string, []*Participant) error,
 ) (*Round, error) {
 
-	if q.InProgress() {
+	q.rw.Lock()
+	defer q.rw.Unlock()
+
+	if q.inProgress {
 		return nil, errors.New("a quiz is already in progress")
 	}
 
 	q.logger.Info("starting round")
 
-	q.rw.Lock()
+	next := int(q.currentRound.Load()) + 1
+	if next >= len(q.Rounds) {
+		return nil, errors.New("quiz is already complete")
+	}
+	round := q.Rounds[next]
+	round.endEarly = q.endEarly
+	round.change = q.change
+	round.burst = q.burst
+	round.grace = q.grace
+
+	q.logger.Infow("determined round...", "question", round.Question)
+
+	// order the answers before the round is visible through CurrentRound
+	if err := q.orderAnswers(round.Question); err != nil {
+		return nil, err
+	}
+
 	q.inProgress = true
-	q.currentRound++
-	q.rw.Unlock()
+	q.currentRound.Store(int32(next))
 
-	if q.currentRound >= len(q.Rounds) {
-		return nil, errors.New("quiz is already complete")
+	round.mu.Lock()
+	round.closesAt = time.Now().Add(q.duration)
+	round.mu.Unlock()
+
+	q.Timer = time.NewTimer(q.duration)
+	go q.completeRound(round, q.Timer, onComplete)
+
+	q.logger.Infow("timer started, round set to in progress", "duration", q.duration)
+
+	return round, nil
+}
+
+// positionPoints are the points scored by the fastest correct answers, by
+// position, and otherPoints those scored by any other correct answer.
+var positionPoints = []int{6, 4, 2}
+
+const otherPoints = 1
+
+// ScoringBreakdown describes how the quiz scores its rounds in one line, like
+// "1st +6, 2nd +4, 3rd +2, other correct answers +1". It is derived from the
+// options the quiz was created with, so it can't disagree with the scoring.
+func (q *Quiz) ScoringBreakdown() string {
+	double := 0
+	for _, round := range q.Rounds {
+		double = max(double, round.Multiplier)
 	}
-	round := q.Rounds[q.currentRound]
-	question := round.Question
+	return scoringBreakdown(q.allCorrect, q.ties, q.grace, q.latePoints, double)
+}
+
+// ScoringBreakdown describes how quizzes created with the options score their
+// rounds, like Quiz.ScoringBreakdown. Double rounds are mentioned if there may
+// be any.
+func (o QuizOptions) ScoringBreakdown() string {
+	double := 1
+	if o.DoubleRound > 0 || o.DoubleChance > 0 {
+		double = 2
+	}
+	return scoringBreakdown(o.AllCorrect, o.Ties, o.Grace, o.LatePoints, double)
+}
 
-	q.logger.Infow("determined round...", "question", question)
+// scoringBreakdown describes the scoring of rounds by the options given,
+// the highest multiplier of a round being double.
+func scoringBreakdown(allCorrect AllCorrectScoring, ties TieScoring, grace time.Duration, latePoints, double int) string {
+	parts := []string{}
+	for i, points := range positionPoints {
+		parts = append(parts, fmt.Sprintf("%s +%d", humanize.Ordinal(i+1), points))
+	}
+	parts = append(parts, fmt.Sprintf("other correct answers +%d", otherPoints))
 
-	if question.Type == "boolean" {
-		if len(question.Answers) != 2 {
-			return nil, fmt.Errorf("unexpected answer count for boolean question %d", len(question.Answers))
+	if allCorrect == AllCorrectFlat {
+		parts = append(parts, fmt.Sprintf("+%d each if everyone is correct", otherPoints))
+	}
+	if ties == TiesShared {
+		parts = append(parts, "ties share their place")
+	}
+	if grace > 0 && latePoints > 0 {
+		parts = append(parts, fmt.Sprintf("late answers +%d", latePoints))
+	}
+	if double > 1 {
+		parts = append(parts, fmt.Sprintf("double rounds ×%d", double))
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// score appends a round's outcome onto the current quiz leaderboard, scaling
+// the points awarded by multiplier.
+func (q *Quiz) score(winners, losers []*Participant, multiplier int) {
+	flat := q.allCorrect == AllCorrectFlat && len(winners) > 0 && len(losers) == 0
+
+	positions := Positions(winners, q.ties)
+	for i, v := range winners {
+		q.speed[v.Name] += v.TimeToSubmission
+		if flat || positions[i] >= len(positionPoints) {
+			q.Scoreboard[v.Name] += otherPoints * multiplier
+		} else {
+			q.Scoreboard[v.Name] += positionPoints[positions[i]] * multiplier
 		}
-		if strings.ToLower(question.Answers[0].Value) != "true" {
-			question.Answers[0], question.Answers[1] = question.Answers[1], question.Answers[0]
+	}
+
+	for _, v := range losers {
+		if _, ok := q.Scoreboard[v.Name]; !ok {
+			q.Scoreboard[v.Name] = 0
 		}
-	} else {
-		q.rng.Shuffle(len(question.Answers), func(i, j int) {
-			question.Answers[i], question.Answers[j] = question.Answers[j], question.Answers[i]
-		})
 	}
 
-	q.Timer = time.AfterFunc(q.duration, func() {
-		q.logger.Info("time is up!")
-		q.rw.Lock()
-		defer q.rw.Unlock()
-
-		// append onto the current quiz leaderboard
-		score := 3
-		winners, losers := round.DetermineOutcome()
-		for _, v := range winners {
-			if score >= 1 {
-				q.Scoreboard[v.Name] += score * 2
-				score--
-			} else {
-				q.Scoreboard[v.Name] += 1
-			}
+	// a wrong answer or sitting out a round both end a streak
+	correct := map[string]bool{}
+	for _, v := range winners {
+		correct[v.Name] = true
+		q.streak[v.Name]++
+		if q.streak[v.Name] > q.bestStreak[v.Name] {
+			q.bestStreak[v.Name] = q.streak[v.Name]
+		}
+	}
+	for name := range q.streak {
+		if !correct[name] {
+			q.streak[name] = 0
 		}
+	}
+}
 
-		for _, v := range losers {
-			if _, ok := q.Scoreboard[v.Name]; !ok {
-				q.Scoreboard[v.Name] = 0
-			}
+// scoreLate awards the correct answers which arrived during the round's grace
+// period the late points, scaled by the round's multiplier. Being late, they
+// also end a streak, like a wrong answer.
+func (q *Quiz) scoreLate(round *Round) {
+	if q.latePoints == 0 {
+		return
+	}
+
+	correctIdx, _ := round.Question.Correct()
+	for _, p := range round.Answers() {
+		if p.Late && p.Choice == correctIdx {
+			q.Scoreboard[p.Name] += q.latePoints * round.Multiplier
 		}
+	}
+}
 
-		// determine correct answer and format it
-		var correct string
-		for idx, ans := range question.Answers {
-			if ans.Correct {
-				correct = fmt.Sprintf("`%d) %s`", idx+1, ans.Value)
-				break
-			}
+// orderAnswers puts true before false for boolean questions and shuffles the
+// answers of any other question.
+func (q *Quiz) orderAnswers(question *Question) error {
+	if question.Type == "boolean" {
+		if len(question.Answers) != 2 {
+			return fmt.Errorf("unexpected answer count for boolean question %d", len(question.Answers))
+		}
+		if !answersEqual(question.Answers[0].Value, "true") {
+			question.Answers[0], question.Answers[1] = question.Answers[1], question.Answers[0]
 		}
+		return nil
+	}
 
-		q.logger.Infof("the correct answer is %q", correct)
+	q.rng.Shuffle(len(question.Answers), func(i, j int) {
+		question.Answers[i], question.Answers[j] = question.Answers[j], question.Answers[i]
+	})
+	return nil
+}
 
-		if err := onComplete(correct, winners); err != nil {
-			q.logger.Fatalf("failed to run onComplete: %v", err)
-		}
+// completeRound waits for the round's timer, or for enough correct answers
+// when ending early, then scores the round.
+func (q *Quiz) completeRound(
+	round *Round,
+	timer *time.Timer,
+	onComplete func(string, []*Participant) error,
+) {
+	select {
+	case <-timer.C:
+		q.logger.Info("time is up!")
+	case <-round.early:
+		timer.Stop()
+		q.logger.Info("enough correct answers, ending the round early")
+	}
+	round.end(time.Now())
+
+	if round.grace > 0 {
+		q.logger.Infow("accepting late answers", "grace", round.grace)
+		time.Sleep(round.grace)
+		round.mu.Lock()
+		round.graceOver = true
+		round.mu.Unlock()
+	}
+
+	defer close(round.done)
 
-		q.inProgress = false
+	q.rw.Lock()
+	if q.reset {
 		round.Complete = true
-	})
+		q.rw.Unlock()
+		q.logger.Info("quiz was reset, not scoring the round")
+		return
+	}
+	question := round.Question
 
-	q.logger.Infow("timer started, round set to in progress", "duration", q.duration)
+	winners, losers := round.DetermineOutcome()
+	for _, p := range round.Answers() {
+		q.players[p.Name] = true
+	}
+	skipped := round.Skipped()
+	if !round.WarmUp && !skipped {
+		q.score(winners, losers, round.Multiplier)
+		q.scoreLate(round)
+	}
+	if skipped && q.requeue && !round.WarmUp && !round.requeued {
+		q.requeueRound(round)
+	}
+	q.rw.Unlock()
 
-	return round, nil
+	// determine correct answer and format it
+	var correct string
+	if idx, ans := question.Correct(); ans != nil {
+		correct, _ = question.Choice(idx)
+	}
+
+	q.logger.Infof("the correct answer is %q", correct)
+
+	// announced without holding q.rw, so a slow send can't hold up everyone
+	// reading the quiz
+	if err := onComplete(correct, winners); err != nil {
+		q.logger.Fatalf("failed to run onComplete: %v", err)
+	}
+
+	q.rw.Lock()
+	q.inProgress = false
+	round.Complete = true
+	q.rw.Unlock()
+}
+
+// requeueRound appends a round asking the question of round, which was
+// skipped, after the last. The caller must hold q.rw.
+func (q *Quiz) requeueRound(round *Round) {
+	last := q.Rounds[len(q.Rounds)-1]
+	last.Final = false
+	q.size++
+
+	// the answers are shuffled again when asked, leave those of round as
+	// they were shown
+	question := *round.Question
+	question.Answers = []*Answer{}
+	for _, ans := range round.Question.Answers {
+		a := *ans
+		question.Answers = append(question.Answers, &a)
+	}
+
+	q.Rounds = append(q.Rounds, &Round{
+		logger:     round.logger,
+		Question:   &question,
+		Num:        q.size,
+		Final:      true,
+		Multiplier: round.Multiplier,
+		requeued:   true,
+		early:      make(chan struct{}, 1),
+		done:       make(chan struct{}),
+	})
+	round.mu.Lock()
+	round.requeuedAs = q.size
+	round.mu.Unlock()
+	q.logger.Infow("requeued skipped question", "round", round.Num, "as", q.size)
+}
+
+// AddPlayer puts name on the scoreboard with no points, unless they are on it
+// already, so they are ranked even without answering.
+func (q *Quiz) AddPlayer(name string) {
+	q.rw.Lock()
+	defer q.rw.Unlock()
+	if _, ok := q.Scoreboard[name]; !ok {
+		q.Scoreboard[name] = 0
+	}
 }
 
 func (q *Quiz) Score() map[string]int {
@@ -183,58 +1008,375 @@ func (q *Quiz) Score() map[string]int {
 	return data
 }
 
+// Players returns the number of distinct players who answered at least one
+// completed round of the quiz, including the warm-up and skipped rounds.
+func (q *Quiz) Players() int {
+	q.rw.RLock()
+	defer q.rw.RUnlock()
+	return len(q.players)
+}
+
+// Streaks returns the longest run of correct answers of each player who
+// answered correctly in the quiz.
+func (q *Quiz) Streaks() map[string]int {
+	q.rw.RLock()
+	defer q.rw.RUnlock()
+
+	data := map[string]int{}
+	for name, streak := range q.bestStreak {
+		data[name] = streak
+	}
+
+	return data
+}
+
+// SortedScore ranks the players by points, breaking ties in favour of the
+// player with the lowest cumulative answer speed, then by name so the ranking
+// is always the same.
+func (q *Quiz) SortedScore() []*Score {
+	q.rw.RLock()
+	defer q.rw.RUnlock()
+
+	scores := []*Score{}
+	for name, points := range q.Scoreboard {
+		scores = append(scores, &Score{
+			Name:   name,
+			Points: points,
+			Speed:  q.speed[name],
+		})
+	}
+
+	sort.Slice(scores, func(i, j int) bool {
+		if scores[i].Points != scores[j].Points {
+			return scores[i].Points > scores[j].Points
+		}
+		if scores[i].Speed != scores[j].Speed {
+			return scores[i].Speed < scores[j].Speed
+		}
+		return scores[i].Name < scores[j].Name
+	})
+
+	return scores
+}
+
 type Round struct {
 	logger       *zap.SugaredLogger
 	Question     *Question
 	Participants []*Participant
-	Complete     bool
-	Num          int
-	StartedAt    time.Time
-	Final        bool
+	// Votes counts the participants who picked each of the question's answers.
+	Votes     []int
+	Complete  bool
+	Num       int
+	StartedAt time.Time
+	Final     bool
+	// WarmUp rounds come before the scored rounds and award no points.
+	WarmUp bool
+	// Multiplier scales the points awarded in the round, 2 for double points
+	// rounds.
+	Multiplier int
+	// endEarly is the number of correct answers which end the round, or zero
+	// to wait out the full duration.
+	endEarly int
+	// change lets participants replace their answer while the round is open.
+	change bool
+	burst  BurstDetection
+	// grace is how long late answers are accepted after endedAt, until the
+	// round is scored and graceOver set.
+	grace     time.Duration
+	graceOver bool
+	// skipVotes holds the participants who voted to skip the round, and
+	// skipped whether it was. requeuedAs is the number of the round asking
+	// the question again once skipped, and requeued marks that round.
+	skipVotes  map[string]bool
+	skipped    bool
+	requeuedAs int
+	requeued   bool
+	// early signals the round to end before its duration is up.
+	early chan struct{}
+	done  chan struct{}
+	// mu guards Participants, Votes, StartedAt, endedAt, closesAt, pausedAt,
+	// pausedFor, skipVotes, skipped and requeuedAs, as answers arrive while
+	// the round is being announced and scored.
+	mu      sync.Mutex
+	endedAt time.Time
+	// closesAt is when the round's timer runs out, as of pausedAt while the
+	// round is paused.
+	closesAt time.Time
+	// pausedAt is when the round was paused, or zero if it isn't, and
+	// pausedFor how long it has been paused in total.
+	pausedAt  time.Time
+	pausedFor time.Duration
+}
+
+// Done returns a channel which is closed once the round has been scored.
+func (r *Round) Done() <-chan struct{} {
+	return r.done
 }
 
-func (r *Round) NewParticipant(username string, answer int, timeIn int64) bool {
+// NewParticipant records username's answer, the index of their choice, sent at
+// timeIn milliseconds since the epoch. Answers timestamped outside the round
+// are rejected with an *OutsideWindowError. A second answer replaces the
+// first, along with its time, if the quiz lets answers be changed, and is
+// rejected with ErrAlreadyAnswered otherwise. During the grace period after
+// the round closes, new answers are accepted as Late, but not changed ones.
+func (r *Round) NewParticipant(username string, answer int, timeIn int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var previous *Participant
 	for _, participant := range r.Participants {
 		if participant.Name == username {
-			return false
+			if !r.change {
+				return ErrAlreadyAnswered
+			}
+			previous = participant
 		}
 	}
 
-	if answer >= len(r.Question.Answers) {
-		return false
+	if answer < 0 || answer >= len(r.Question.Answers) {
+		return ErrInvalidAnswer
+	}
+	if !r.pausedAt.IsZero() {
+		return ErrPaused
 	}
 
-	timeToSub := time.Unix(timeIn/1000, timeIn%1000*int64(time.Millisecond)).Sub(r.StartedAt)
-	p := &Participant{username, answer, timeToSub}
+	in := time.UnixMilli(timeIn)
+	late := !r.endedAt.IsZero()
+	if r.StartedAt.IsZero() || in.Before(r.StartedAt.Add(-clockSkew)) || (late && !r.inGrace(in, previous)) {
+		return &OutsideWindowError{TimeIn: in, StartedAt: r.StartedAt, EndedAt: r.endedAt}
+	}
 
-	r.Participants = append(r.Participants, p)
-	r.logger.Infow("new participant", "entry", p)
+	timeToSub := in.Sub(r.StartedAt) - r.pausedFor
+	if timeToSub < 0 {
+		timeToSub = 0
+	}
 
-	return true
+	if r.Votes == nil {
+		r.Votes = make([]int, len(r.Question.Answers))
+	}
+	r.Votes[answer]++
+
+	p := previous
+	if p != nil {
+		r.Votes[p.Choice]--
+		p.Choice, p.TimeToSubmission = answer, timeToSub
+		r.logger.Infow("participant changed their answer", "entry", p)
+	} else {
+		p = &Participant{Name: username, Choice: answer, TimeToSubmission: timeToSub, Late: late}
+		r.Participants = append(r.Participants, p)
+		r.logger.Infow("new participant", "entry", p)
+	}
+
+	if r.burst.enabled() {
+		r.flagBurst(p)
+	}
+
+	if r.endEarly > 0 && r.correctCount() >= r.endEarly && r.endedAt.IsZero() {
+		// close at once, so no answer slips in before the round is scored
+		r.endedAt = time.Now()
+		r.End()
+	}
+
+	return nil
 }
 
-func (r *Round) DetermineOutcome() ([]*Participant, []*Participant) {
-	correctIdx := 0
-	for idx, ans := range r.Question.Answers {
-		if ans.Correct {
-			correctIdx = idx
-			break
+// inGrace reports whether an answer sent at in, replacing previous if not
+// nil, is accepted as late once the round has closed.
+func (r *Round) inGrace(in time.Time, previous *Participant) bool {
+	return r.grace > 0 && !r.graceOver && previous == nil && !in.After(r.endedAt.Add(r.grace))
+}
+
+// flagBurst marks p and the answers within the burst window of it as
+// suspicious if there are enough of them to make a burst.
+func (r *Round) flagBurst(p *Participant) {
+	burst := []*Participant{}
+	for _, other := range r.Participants {
+		gap := other.TimeToSubmission - p.TimeToSubmission
+		if gap <= r.burst.Window && gap >= -r.burst.Window {
+			burst = append(burst, other)
+		}
+	}
+	if len(burst) < r.burst.Size {
+		return
+	}
+
+	names := []string{}
+	for _, other := range burst {
+		other.Suspicious = true
+		names = append(names, other.Name)
+	}
+	r.logger.Warnw("suspicious burst of answers", "players", names, "window", r.burst.Window)
+}
+
+// Suspicious returns the names of the participants whose answers were flagged
+// by burst detection.
+func (r *Round) Suspicious() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	names := []string{}
+	for _, p := range r.Participants {
+		if p.Suspicious {
+			names = append(names, p.Name)
+		}
+	}
+	return names
+}
+
+// HasAnswered reports whether username has answered the round.
+func (r *Round) HasAnswered(username string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, participant := range r.Participants {
+		if participant.Name == username {
+			return true
 		}
 	}
+	return false
+}
+
+// End scores the round now rather than waiting out its duration. It does
+// nothing if the round has already ended.
+func (r *Round) End() {
+	select {
+	case r.early <- struct{}{}:
+	default:
+	}
+}
+
+// VoteSkip records username's vote to skip the round, returning how many have
+// voted. Votes are only counted while the round is open, otherwise 0 is
+// returned.
+func (r *Round) VoteSkip(username string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.StartedAt.IsZero() || !r.endedAt.IsZero() {
+		return 0
+	}
+	if r.skipVotes == nil {
+		r.skipVotes = map[string]bool{}
+	}
+	r.skipVotes[username] = true
+	return len(r.skipVotes)
+}
+
+// Skip ends the round now without scoring it, reporting whether it was still
+// open. See QuizOptions.RequeueSkipped for asking it again.
+func (r *Round) Skip() bool {
+	r.mu.Lock()
+	if r.StartedAt.IsZero() || !r.endedAt.IsZero() {
+		r.mu.Unlock()
+		return false
+	}
+	r.skipped = true
+	r.mu.Unlock()
+
+	r.End()
+	return true
+}
+
+// Skipped reports whether the round was skipped, see Skip.
+func (r *Round) Skipped() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.skipped
+}
+
+// RequeuedAs returns the number of the round asking the question again, once
+// the round was skipped, or 0 if it isn't asked again.
+func (r *Round) RequeuedAs() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.requeuedAs
+}
+
+// Open records that the question was asked at at, accepting answers from then
+// on.
+func (r *Round) Open(at time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.StartedAt = at
+}
+
+// ClosesAt returns when the round's timer runs out, unless it ends early. While
+// the round is paused, that is as if it resumed now.
+func (r *Round) ClosesAt() time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.pausedAt.IsZero() {
+		return time.Now().Add(r.closesAt.Sub(r.pausedAt))
+	}
+	return r.closesAt
+}
+
+// Paused reports whether the round is paused, see Quiz.Pause.
+func (r *Round) Paused() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return !r.pausedAt.IsZero()
+}
+
+// IsOpen reports whether the round is accepting answers.
+func (r *Round) IsOpen() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return !r.StartedAt.IsZero() && r.endedAt.IsZero()
+}
+
+// Answers returns the answers given so far.
+func (r *Round) Answers() []*Participant {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*Participant{}, r.Participants...)
+}
+
+// AverageTime returns how long participants took to answer on average, from
+// when the round started, and false if nobody answered.
+func (r *Round) AverageTime() (time.Duration, bool) {
+	return r.state().AverageTime()
+}
+
+// end closes the round to new answers, unless it already was.
+func (r *Round) end(at time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.endedAt.IsZero() {
+		r.endedAt = at
+	}
+}
+
+func (r *Round) correctCount() int {
+	idx, _ := r.Question.Correct()
+	if idx < 0 {
+		return 0
+	}
+	return r.Votes[idx]
+}
+
+// DetermineOutcome splits the participants into the winners, who answered
+// correctly before the round closed, fastest first, and everyone else. Only
+// correct answers are ranked, however fast a wrong one was, and nobody wins a
+// question without a correct answer.
+func (r *Round) DetermineOutcome() ([]*Participant, []*Participant) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	correctIdx, _ := r.Question.Correct()
 
 	losers := []*Participant{}
 	winners := []*Participant{}
-	// filter participants for correct choice
+	// filter participants for correct choice, then rank by time
 	for _, participant := range r.Participants {
-		if participant.Choice == correctIdx {
+		if participant.Choice == correctIdx && !participant.Late {
 			winners = append(winners, participant)
 		} else {
 			losers = append(losers, participant)
 		}
 	}
 
-	// sort participants by time in
-	sort.Slice(winners, func(i, j int) bool {
+	// sort participants by time in, keeping ties in the order they arrived
+	sort.SliceStable(winners, func(i, j int) bool {
 		return winners[i].TimeToSubmission < winners[j].TimeToSubmission
 	})
 
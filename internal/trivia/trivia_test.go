@@ -0,0 +1,1957 @@
+package trivia
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jbpratt/bots/internal/trivia/models"
+	"github.com/volatiletech/null/v8"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+	"go.uber.org/zap"
+)
+
+// sliceSource hands out copies of questions in order, wrapping around.
+type sliceSource struct {
+	index     int
+	questions []*Question
+}
+
+func (s *sliceSource) Question() (*Question, error) {
+	if len(s.questions) == 0 {
+		return nil, ErrNoQuestions
+	}
+
+	src := s.questions[s.index%len(s.questions)]
+	s.index++
+
+	q := *src
+	q.Answers = []*Answer{}
+	for _, ans := range src.Answers {
+		a := *ans
+		q.Answers = append(q.Answers, &a)
+	}
+	return &q, nil
+}
+
+func newSliceSource() *sliceSource {
+	return &sliceSource{questions: []*Question{{
+		Question: "What is the capital of France?",
+		Answers: []*Answer{
+			{Value: "Paris", Correct: true},
+			{Value: "Lyon"},
+			{Value: "Nice"},
+		},
+	}}}
+}
+
+func newTestQuiz(t *testing.T, size int) *Quiz {
+	t.Helper()
+
+	quiz, err := NewQuiz(zap.NewNop().Sugar(), size, 20*time.Millisecond, newSliceSource())
+	if err != nil {
+		t.Fatalf("failed to create quiz: %v", err)
+	}
+	return quiz
+}
+
+type submission struct {
+	name    string
+	correct bool
+	after   time.Duration
+}
+
+// playRound starts the next round, submits answers relative to its start and
+// waits for it to complete.
+func playRound(t *testing.T, quiz *Quiz, submissions ...submission) *Round {
+	t.Helper()
+
+	round, err := quiz.StartRound(func(string, []*Participant) error { return nil })
+	if err != nil {
+		t.Fatalf("failed to start round: %v", err)
+	}
+	round.StartedAt = time.UnixMilli(time.Now().UnixMilli())
+
+	correct, _ := round.Question.Correct()
+	for _, sub := range submissions {
+		choice := correct
+		if !sub.correct {
+			choice = (correct + 1) % len(round.Question.Answers)
+		}
+		timeIn := round.StartedAt.Add(sub.after).UnixMilli()
+		if err := round.NewParticipant(sub.name, choice, timeIn); err != nil {
+			t.Fatalf("submission %v was rejected: %v", sub, err)
+		}
+	}
+
+	for quiz.InProgress() {
+		time.Sleep(time.Millisecond)
+	}
+	return round
+}
+
+func TestSortedScoreBreaksTiesOnSpeed(t *testing.T) {
+	quiz := newTestQuiz(t, 2)
+
+	playRound(t, quiz,
+		submission{"alice", true, 1 * time.Second},
+		submission{"bob", true, 2 * time.Second},
+	)
+	playRound(t, quiz,
+		submission{"alice", true, 5 * time.Second},
+		submission{"bob", true, 1 * time.Second},
+	)
+
+	ranking := quiz.SortedScore()
+	if len(ranking) != 2 {
+		t.Fatalf("expected 2 ranked players, got %d", len(ranking))
+	}
+	if ranking[0].Points != ranking[1].Points {
+		t.Fatalf("expected a tie on points, got %d and %d", ranking[0].Points, ranking[1].Points)
+	}
+	if ranking[0].Name != "bob" || ranking[1].Name != "alice" {
+		t.Errorf("expected bob to win on speed, got %s then %s", ranking[0].Name, ranking[1].Name)
+	}
+	if ranking[0].Speed != 3*time.Second || ranking[1].Speed != 6*time.Second {
+		t.Errorf("unexpected cumulative speeds %s and %s", ranking[0].Speed, ranking[1].Speed)
+	}
+}
+
+func TestNewQuizSkipsAnswerNotAmongChoices(t *testing.T) {
+	broken := &Question{
+		Question: "What is the capital of Spain?",
+		Answers: []*Answer{
+			{Value: "Barcelona"},
+			{Value: "Seville"},
+		},
+	}
+	source := newSliceSource()
+	source.questions = append([]*Question{broken}, source.questions...)
+
+	quiz, err := NewQuiz(zap.NewNop().Sugar(), 1, time.Second, source)
+	if err != nil {
+		t.Fatalf("failed to create quiz: %v", err)
+	}
+	if got := quiz.Rounds[0].Question.Question; got != "What is the capital of France?" {
+		t.Errorf("expected the broken question to be skipped, got %q", got)
+	}
+
+	source.questions = []*Question{broken}
+	if _, err = NewQuiz(zap.NewNop().Sugar(), 1, time.Second, source); err == nil {
+		t.Error("expected a source of only broken questions to fail")
+	}
+}
+
+func TestWarmUpAwardsNothing(t *testing.T) {
+	quiz, err := NewQuizWithOptions(zap.NewNop().Sugar(), newSliceSource(), QuizOptions{
+		Size:     1,
+		Duration: 20 * time.Millisecond,
+		WarmUp:   true,
+	})
+	if err != nil {
+		t.Fatalf("failed to create quiz: %v", err)
+	}
+	if len(quiz.Rounds) != 2 || quiz.Size() != 1 {
+		t.Fatalf("expected a warm-up and 1 scored round, got %d rounds of size %d", len(quiz.Rounds), quiz.Size())
+	}
+
+	warmUp := playRound(t, quiz, submission{"alice", true, time.Second}, submission{"bob", false, time.Second})
+	if !warmUp.WarmUp || warmUp.Num != 0 || warmUp.Final {
+		t.Errorf("expected the first round to be the warm-up, got %+v", warmUp)
+	}
+	if score := quiz.Score(); len(score) != 0 {
+		t.Errorf("warm-up round awarded %v", score)
+	}
+
+	round := playRound(t, quiz, submission{"alice", true, time.Second})
+	if round.WarmUp || round.Num != 1 || !round.Final {
+		t.Errorf("expected the second round to be the final round 1, got %+v", round)
+	}
+	if score := quiz.Score(); len(score) != 1 || score["alice"] != 6 {
+		t.Errorf("expected only the scored round to award points, got %v", score)
+	}
+}
+
+func TestEndEarlyOnceEnoughCorrect(t *testing.T) {
+	quiz, err := NewQuizWithOptions(zap.NewNop().Sugar(), newSliceSource(), QuizOptions{
+		Size:     1,
+		Duration: time.Minute,
+		EndEarly: 3,
+	})
+	if err != nil {
+		t.Fatalf("failed to create quiz: %v", err)
+	}
+
+	round, err := quiz.StartRound(func(string, []*Participant) error { return nil })
+	if err != nil {
+		t.Fatalf("failed to start round: %v", err)
+	}
+	round.StartedAt = time.Now()
+
+	correct, _ := round.Question.Correct()
+	wrong := (correct + 1) % len(round.Question.Answers)
+	for _, sub := range []struct {
+		name   string
+		choice int
+	}{
+		{"alice", correct},
+		{"bob", wrong},
+		{"carol", correct},
+		{"dave", correct},
+	} {
+		if err := round.NewParticipant(sub.name, sub.choice, time.Now().UnixMilli()); err != nil {
+			t.Fatalf("submission from %s was rejected: %v", sub.name, err)
+		}
+	}
+
+	select {
+	case <-round.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("round did not end after 3 correct answers")
+	}
+
+	if quiz.InProgress() || !round.Complete {
+		t.Error("round ended early but is still in progress")
+	}
+	if score := quiz.Score(); score["alice"] != 6 || score["carol"] != 4 || score["dave"] != 2 {
+		t.Errorf("unexpected score %v", score)
+	}
+}
+
+func TestNewParticipantRejectsOutsideWindow(t *testing.T) {
+	quiz := newTestQuiz(t, 1)
+	round, err := quiz.StartRound(func(string, []*Participant) error { return nil })
+	if err != nil {
+		t.Fatalf("failed to start round: %v", err)
+	}
+	round.StartedAt = time.Now()
+
+	var windowErr *OutsideWindowError
+	early := round.StartedAt.Add(-time.Minute).UnixMilli()
+	err = round.NewParticipant("alice", 0, early)
+	if !errors.As(err, &windowErr) {
+		t.Fatalf("expected an answer from before the round to be rejected, got %v", err)
+	}
+	if !windowErr.EndedAt.IsZero() {
+		t.Errorf("open round reported as ended at %s", windowErr.EndedAt)
+	}
+
+	if err = round.NewParticipant("bob", 0, time.Now().UnixMilli()); err != nil {
+		t.Fatalf("answer in the round was rejected: %v", err)
+	}
+	if err = round.NewParticipant("bob", 1, time.Now().UnixMilli()); !errors.Is(err, ErrAlreadyAnswered) {
+		t.Errorf("expected a second answer to be rejected, got %v", err)
+	}
+
+	<-round.Done()
+
+	err = round.NewParticipant("carol", 0, time.Now().UnixMilli())
+	if !errors.As(err, &windowErr) || windowErr.EndedAt.IsZero() {
+		t.Errorf("expected an answer after the round to be rejected, got %v", err)
+	}
+	if len(round.Participants) != 1 {
+		t.Errorf("expected only bob to participate, got %d participants", len(round.Participants))
+	}
+}
+
+// newTestDB returns a fresh database with empty tables, set as the global
+// executor.
+func newTestDB(t testing.TB) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "trivia.db"))
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	boil.SetDB(db)
+
+	if err = migrateQuestions(context.Background(), db); err != nil {
+		t.Fatalf("failed to migrate questions: %v", err)
+	}
+	return db
+}
+
+func insertQuestion(t testing.TB, db *sql.DB, question *models.Question) int64 {
+	t.Helper()
+
+	if question.Source == "" {
+		question.Source = "test"
+	}
+	if err := question.Insert(context.Background(), db, boil.Infer()); err != nil {
+		t.Fatalf("failed to insert question %q: %v", question.Question, err)
+	}
+	return question.ID.Int64
+}
+
+func TestLintQuestions(t *testing.T) {
+	db := newTestDB(t)
+
+	insertQuestion(t, db, &models.Question{Question: "fine", Answer: "a", Choices: "a,b,c"})
+	ids := map[Problem]int64{
+		ProblemEmptyAnswer:      insertQuestion(t, db, &models.Question{Question: "empty", Answer: " ", Choices: "a, "}),
+		ProblemTooFewChoices:    insertQuestion(t, db, &models.Question{Question: "few", Answer: "a", Choices: "a"}),
+		ProblemAnswerNotChoice:  insertQuestion(t, db, &models.Question{Question: "missing", Answer: "d", Choices: "a,b,c"}),
+		ProblemHTMLEntities:     insertQuestion(t, db, &models.Question{Question: "it&#039;s", Answer: "a", Choices: "a,b"}),
+		ProblemDuplicateChoices: insertQuestion(t, db, &models.Question{Question: "dupes", Answer: "a", Choices: "a,b,A"}),
+		ProblemLongChoices:      insertQuestion(t, db, &models.Question{Question: "long", Answer: "a", Choices: "a," + strings.Repeat("b", MaxChoicesLength)}),
+	}
+	insertQuestion(t, db, &models.Question{Question: "removed", Answer: "x", Choices: "x", Removed: "1"})
+
+	results, err := LintQuestions(context.Background(), db, 5)
+	if err != nil {
+		t.Fatalf("failed to lint questions: %v", err)
+	}
+
+	for _, result := range results {
+		want := ids[result.Problem]
+		if result.Count != 1 || len(result.Samples) != 1 || result.Samples[0] != want {
+			t.Errorf("%s: expected only question %d to be flagged, got %s", result.Problem, want, result)
+		}
+	}
+}
+
+func TestHistory(t *testing.T) {
+	db := newTestDB(t)
+	lboard, err := NewChannelLeaderboard(zap.NewNop().Sugar(), db, "a")
+	if err != nil {
+		t.Fatalf("failed to create leaderboard: %v", err)
+	}
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	seed := []struct {
+		name    string
+		channel string
+		correct bool
+	}{
+		{"alice", "a", true},
+		{"alice", "a", false},
+		{"alice", "a", true},
+		{"alice", "a", true},
+		{"alice", "a", false},
+		{"alice", "a", true},
+		{"alice", "a", false},
+		{"alice", "a", true},
+		{"bob", "a", false},
+		{"alice", "b", false},
+	}
+	for i, s := range seed {
+		record := &models.Participation{
+			Name:       s.name,
+			Channel:    s.channel,
+			Correct:    s.correct,
+			AnsweredAt: start.Add(time.Duration(i) * time.Minute),
+		}
+		if err = record.InsertG(context.Background(), boil.Infer()); err != nil {
+			t.Fatalf("failed to seed participation: %v", err)
+		}
+	}
+
+	history, err := lboard.History("alice", 3)
+	if err != nil {
+		t.Fatalf("failed to get history: %v", err)
+	}
+
+	if history.Answered != 8 || history.Correct != 5 {
+		t.Errorf("expected 5 of 8 answers correct, got %d of %d", history.Correct, history.Answered)
+	}
+	if got := history.Accuracy(); got != 62.5 {
+		t.Errorf("expected an accuracy of 62.5%%, got %v", got)
+	}
+
+	if len(history.Recent) != 3 {
+		t.Fatalf("expected 3 recent answers, got %d", len(history.Recent))
+	}
+	for i, want := range []bool{true, false, true} {
+		if history.Recent[i].Correct != want {
+			t.Errorf("recent answer %d: expected correct=%v", i, want)
+		}
+	}
+	if !history.Recent[0].AnsweredAt.Equal(start.Add(7 * time.Minute)) {
+		t.Errorf("expected the newest answer first, got one from %s", history.Recent[0].AnsweredAt)
+	}
+
+	if empty, err := lboard.History("carol", 3); err != nil || empty.Answered != 0 || empty.Accuracy() != 0 {
+		t.Errorf("expected carol to have no history, got %+v, %v", empty, err)
+	}
+}
+
+func TestStreaks(t *testing.T) {
+	quiz := newTestQuiz(t, 4)
+
+	playRound(t, quiz, submission{"alice", true, time.Second}, submission{"bob", true, time.Second})
+	playRound(t, quiz, submission{"alice", true, time.Second}, submission{"bob", false, time.Second})
+	playRound(t, quiz, submission{"alice", true, time.Second}, submission{"bob", true, time.Second})
+	// bob's streak also ends by sitting a round out
+	playRound(t, quiz, submission{"carol", false, time.Second})
+
+	streaks := quiz.Streaks()
+	if streaks["alice"] != 3 || streaks["bob"] != 1 {
+		t.Errorf("expected streaks of 3 and 1, got %v", streaks)
+	}
+	if _, ok := streaks["carol"]; ok {
+		t.Errorf("expected no streak for carol, got %d", streaks["carol"])
+	}
+}
+
+func TestPlayers(t *testing.T) {
+	quiz := newTestQuiz(t, 3)
+	if players := quiz.Players(); players != 0 {
+		t.Errorf("expected no players before the first round, got %d", players)
+	}
+
+	playRound(t, quiz, submission{"alice", true, time.Second}, submission{"bob", false, time.Second})
+	playRound(t, quiz, submission{"bob", true, time.Second}, submission{"carol", true, time.Second})
+	playRound(t, quiz, submission{"alice", false, time.Second}, submission{"carol", true, time.Second})
+
+	if players := quiz.Players(); players != 3 {
+		t.Errorf("expected 3 distinct players, got %d", players)
+	}
+}
+
+func TestUpdateStreaksOnlyWhenExceeded(t *testing.T) {
+	db := newTestDB(t)
+	lboard, err := NewChannelLeaderboard(zap.NewNop().Sugar(), db, "a")
+	if err != nil {
+		t.Fatalf("failed to create leaderboard: %v", err)
+	}
+	if err = lboard.Update(map[string]int{"alice": 10, "bob": 5}); err != nil {
+		t.Fatalf("failed to update leaderboard: %v", err)
+	}
+
+	for _, step := range []struct {
+		streak int
+		want   int64
+	}{
+		{3, 3},
+		{2, 3},
+		{4, 4},
+	} {
+		if err = lboard.UpdateStreaks(map[string]int{"alice": step.streak}); err != nil {
+			t.Fatalf("failed to update streaks: %v", err)
+		}
+
+		users, err := lboard.Streaks(5)
+		if err != nil {
+			t.Fatalf("failed to get streaks: %v", err)
+		}
+		if len(users) != 1 || users[0].Name != "alice" || users[0].MaxStreak != step.want {
+			t.Errorf("after a streak of %d: expected only alice with %d, got %v", step.streak, step.want, users)
+		}
+	}
+}
+
+func TestAnswersEqual(t *testing.T) {
+	for _, tc := range []struct {
+		a, b string
+		want bool
+	}{
+		{"Paris", "Paris", true},
+		{" Paris ", "paris", true},
+		{"New  York\t", "new york", true},
+		{"NEW YORK", " New York", true},
+		{"Paris", "Pari", false},
+		{"NewYork", "New York", false},
+		{"", " ", true},
+	} {
+		if got := answersEqual(tc.a, tc.b); got != tc.want {
+			t.Errorf("answersEqual(%q, %q) = %v, want %v", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
+
+func TestNewQuestionFromModelNormalizesChoices(t *testing.T) {
+	question := newQuestionFromModel(&models.Question{
+		Question: "Which city is the capital of France?",
+		Answer:   "paris",
+		Choices:  "Berlin, Paris ,Madrid",
+	})
+
+	idx, ans := question.Correct()
+	if ans == nil || idx != 1 {
+		t.Fatalf("expected the padded choice %q to be correct, got %d", question.Answers[1].Value, idx)
+	}
+	for i, choice := range question.Answers {
+		if choice.Correct != (i == idx) {
+			t.Errorf("expected only choice %d to be correct, %q is %v", idx, choice.Value, choice.Correct)
+		}
+	}
+}
+
+func TestAllCorrectScoring(t *testing.T) {
+	for _, tc := range []struct {
+		scoring AllCorrectScoring
+		want    map[string]int
+	}{
+		{AllCorrectRanked, map[string]int{"alice": 6, "bob": 4, "carol": 2, "dave": 1}},
+		{AllCorrectFlat, map[string]int{"alice": 1, "bob": 1, "carol": 1, "dave": 1}},
+	} {
+		t.Run(tc.scoring.String(), func(t *testing.T) {
+			quiz, err := NewQuizWithOptions(zap.NewNop().Sugar(), newSliceSource(), QuizOptions{
+				Size:       2,
+				Duration:   20 * time.Millisecond,
+				AllCorrect: tc.scoring,
+			})
+			if err != nil {
+				t.Fatalf("failed to create quiz: %v", err)
+			}
+
+			playRound(t, quiz,
+				submission{"alice", true, 1 * time.Second},
+				submission{"bob", true, 2 * time.Second},
+				submission{"carol", true, 3 * time.Second},
+				submission{"dave", true, 4 * time.Second},
+			)
+			for name, points := range tc.want {
+				if got := quiz.Score()[name]; got != points {
+					t.Errorf("expected %s to score %d, got %d", name, points, got)
+				}
+			}
+
+			// a round with a wrong answer is always ranked
+			playRound(t, quiz,
+				submission{"alice", true, 1 * time.Second},
+				submission{"bob", false, 2 * time.Second},
+			)
+			if got := quiz.Score()["alice"]; got != tc.want["alice"]+6 {
+				t.Errorf("expected alice to score 6 more, got %d", got-tc.want["alice"])
+			}
+		})
+	}
+}
+
+func TestParseAllCorrectScoring(t *testing.T) {
+	for _, scoring := range []AllCorrectScoring{AllCorrectRanked, AllCorrectFlat} {
+		if got, err := ParseAllCorrectScoring(scoring.String()); err != nil || got != scoring {
+			t.Errorf("failed to parse %s: got %s, %v", scoring, got, err)
+		}
+	}
+	if _, err := ParseAllCorrectScoring("random"); err == nil {
+		t.Error("expected an unknown scoring to fail")
+	}
+}
+
+func TestCreateIndexesIsIdempotent(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	missing, err := MissingIndexes(ctx, db)
+	if err != nil {
+		t.Fatalf("failed to check indexes: %v", err)
+	}
+	if len(missing) != len(recommendedIndexes) {
+		t.Errorf("expected %d missing indexes on a new database, got %v", len(recommendedIndexes), missing)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err = CreateIndexes(ctx, db); err != nil {
+			t.Fatalf("failed to create indexes on attempt %d: %v", i+1, err)
+		}
+	}
+
+	if missing, err = MissingIndexes(ctx, db); err != nil || len(missing) != 0 {
+		t.Errorf("expected no missing indexes, got %v, %v", missing, err)
+	}
+}
+
+func TestFilteredExcludesQuestions(t *testing.T) {
+	db := newTestDB(t)
+
+	ids := []int64{}
+	for _, q := range []string{"one", "two", "three", "four"} {
+		ids = append(ids, insertQuestion(t, db, &models.Question{Question: q, Answer: "a", Choices: "a,b", Category: null.StringFrom("test")}))
+	}
+
+	source := (&DBSource{db: db}).Filtered(Filter{Category: "test", Exclude: ids[:3]})
+	for i := 0; i < 20; i++ {
+		question, err := source.Question()
+		if err != nil {
+			t.Fatalf("failed to draw a question: %v", err)
+		}
+		if question.ID != ids[3] {
+			t.Fatalf("expected only question %d to be drawn, got %d", ids[3], question.ID)
+		}
+	}
+
+	source = (&DBSource{db: db}).Filtered(Filter{Exclude: ids})
+	if _, err := source.Question(); !errors.Is(err, ErrNoQuestions) {
+		t.Errorf("expected no questions once all are excluded, got %v", err)
+	}
+}
+
+func TestDoublePointsRound(t *testing.T) {
+	quiz, err := NewQuizWithOptions(zap.NewNop().Sugar(), newSliceSource(), QuizOptions{
+		Size:        2,
+		Duration:    20 * time.Millisecond,
+		WarmUp:      true,
+		DoubleRound: 2,
+	})
+	if err != nil {
+		t.Fatalf("failed to create quiz: %v", err)
+	}
+
+	for _, round := range quiz.Rounds {
+		want := 1
+		if round.Num == 2 {
+			want = 2
+		}
+		if round.Multiplier != want {
+			t.Errorf("round %d: expected a multiplier of %d, got %d", round.Num, want, round.Multiplier)
+		}
+	}
+
+	for range quiz.Rounds {
+		playRound(t, quiz,
+			submission{"alice", true, 1 * time.Second},
+			submission{"bob", true, 2 * time.Second},
+		)
+	}
+
+	// 6 and 4 points in round 1, doubled to 12 and 8 in round 2
+	if score := quiz.Score(); score["alice"] != 18 || score["bob"] != 12 {
+		t.Errorf("expected 18 and 12 points, got %v", score)
+	}
+}
+
+func TestMatchChoice(t *testing.T) {
+	question := &Question{Answers: []*Answer{
+		{Value: "New York"},
+		{Value: "New Delhi"},
+		{Value: "Paris "},
+		{Value: "Par"},
+	}}
+
+	for _, tc := range []struct {
+		data string
+		want int
+		err  error
+	}{
+		{"paris", 2, nil},
+		{"  new   york", 0, nil},
+		{"new d", 1, nil},
+		// an exact match wins over the answers it is the start of
+		{"par", 3, nil},
+		{"new", 0, ErrAmbiguousAnswer},
+		{"London", 0, ErrInvalidAnswer},
+		{" ", 0, ErrInvalidAnswer},
+	} {
+		got, err := question.MatchChoice(tc.data)
+		if !errors.Is(err, tc.err) || (err == nil && got != tc.want) {
+			t.Errorf("MatchChoice(%q) = %d, %v, want %d, %v", tc.data, got, err, tc.want, tc.err)
+		}
+	}
+}
+
+func TestDefaultFilterPersists(t *testing.T) {
+	db := newTestDB(t)
+	logger := zap.NewNop().Sugar()
+	lboard, err := NewChannelLeaderboard(logger, db, "a")
+	if err != nil {
+		t.Fatalf("failed to create leaderboard: %v", err)
+	}
+
+	for _, filter := range []Filter{{Category: "History"}, {Category: "Geography", Difficulty: "hard"}} {
+		if err = lboard.SetDefaultFilter(filter); err != nil {
+			t.Fatalf("failed to set default filter: %v", err)
+		}
+	}
+
+	reopened, err := NewChannelLeaderboard(logger, db, "a")
+	if err != nil {
+		t.Fatalf("failed to reopen leaderboard: %v", err)
+	}
+	if got, err := reopened.DefaultFilter(); err != nil || got.Category != "Geography" || got.Difficulty != "hard" {
+		t.Errorf("expected the latest defaults, got %+v, %v", got, err)
+	}
+
+	other, err := NewChannelLeaderboard(logger, db, "b")
+	if err != nil {
+		t.Fatalf("failed to create leaderboard: %v", err)
+	}
+	if got, err := other.DefaultFilter(); err != nil || !got.IsZero() {
+		t.Errorf("expected another channel to have no defaults, got %+v, %v", got, err)
+	}
+}
+
+func TestSortedScoreBreaksRemainingTiesOnName(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		quiz := newTestQuiz(t, 1)
+		for _, name := range []string{"carol", "alice", "dave", "bob"} {
+			quiz.Scoreboard[name] = 0
+		}
+		quiz.Scoreboard["dave"] = 2
+
+		names := []string{}
+		for _, score := range quiz.SortedScore() {
+			names = append(names, score.Name)
+		}
+		if got := strings.Join(names, " "); got != "dave alice bob carol" {
+			t.Fatalf("run %d: unexpected ranking %s", i, got)
+		}
+	}
+}
+
+func TestNewQuizSkipsTooFewChoices(t *testing.T) {
+	sparse := &Question{
+		Question: "What is the capital of Italy?",
+		Type:     "multiple",
+		Answers: []*Answer{
+			{Value: "Rome", Correct: true},
+			{Value: "Milan"},
+			{Value: " "},
+		},
+	}
+	source := newSliceSource()
+	source.questions = append([]*Question{sparse}, source.questions...)
+
+	quiz, err := NewQuiz(zap.NewNop().Sugar(), 1, time.Second, source)
+	if err != nil {
+		t.Fatalf("failed to create quiz: %v", err)
+	}
+	if got := quiz.Rounds[0].Question.Question; got == sparse.Question {
+		t.Error("expected the multiple choice question with too few choices to be skipped")
+	}
+
+	// the same choices are enough for a question which isn't multiple choice
+	sparse.Type = ""
+	source.questions = []*Question{sparse}
+	if _, err = NewQuiz(zap.NewNop().Sugar(), 1, time.Second, source); err != nil {
+		t.Errorf("expected an untyped question with two choices to be asked: %v", err)
+	}
+
+	problems := QuestionProblems(&models.Question{Question: "sparse", Answer: "a", Choices: "a,b,c", Type: null.StringFrom("multiple")})
+	if len(problems) != 1 || problems[0] != ProblemTooFewChoices {
+		t.Errorf("expected lint to report too few choices, got %v", problems)
+	}
+}
+
+func TestSubmissions(t *testing.T) {
+	db := newTestDB(t)
+
+	alice := []int64{}
+	for i := 0; i < 3; i++ {
+		alice = append(alice, insertQuestion(t, db, &models.Question{
+			Question: fmt.Sprintf("alice %d", i), Answer: "a", Choices: "a,b", Source: SubmissionSource("alice"), Removed: "0",
+		}))
+	}
+	insertQuestion(t, db, &models.Question{Question: "bob", Answer: "a", Choices: "a,b", Source: SubmissionSource("bob"), Removed: "0"})
+	insertQuestion(t, db, &models.Question{Question: "imported", Answer: "a", Choices: "a,b", Source: "opentdb", Removed: "0"})
+
+	count, questions, err := Submissions(context.Background(), db, "alice", 2)
+	if err != nil {
+		t.Fatalf("failed to get submissions: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("expected alice to have submitted 3 questions, got %d", count)
+	}
+	if len(questions) != 2 || questions[0].ID.Int64 != alice[2] || questions[1].ID.Int64 != alice[1] {
+		t.Errorf("expected alice's latest 2 questions, got %v", questions)
+	}
+
+	if count, _, err = Submissions(context.Background(), db, "carol", 2); err != nil || count != 0 {
+		t.Errorf("expected carol to have no submissions, got %d, %v", count, err)
+	}
+}
+
+func TestChangeAnswers(t *testing.T) {
+	quiz, err := NewQuizWithOptions(zap.NewNop().Sugar(), newSliceSource(), QuizOptions{
+		Size:          1,
+		Duration:      50 * time.Millisecond,
+		ChangeAnswers: true,
+	})
+	if err != nil {
+		t.Fatalf("failed to create quiz: %v", err)
+	}
+
+	round, err := quiz.StartRound(func(string, []*Participant) error { return nil })
+	if err != nil {
+		t.Fatalf("failed to start round: %v", err)
+	}
+	round.StartedAt = time.UnixMilli(time.Now().UnixMilli())
+
+	correct, _ := round.Question.Correct()
+	wrong := (correct + 1) % len(round.Question.Answers)
+	if err = round.NewParticipant("alice", wrong, round.StartedAt.Add(time.Second).UnixMilli()); err != nil {
+		t.Fatalf("first answer was rejected: %v", err)
+	}
+	if !round.HasAnswered("alice") {
+		t.Error("expected alice to have answered")
+	}
+	if err = round.NewParticipant("alice", correct, round.StartedAt.Add(2*time.Second).UnixMilli()); err != nil {
+		t.Fatalf("changed answer was rejected: %v", err)
+	}
+
+	if len(round.Participants) != 1 {
+		t.Fatalf("expected a single participant, got %d", len(round.Participants))
+	}
+	if got := round.Participants[0].TimeToSubmission; got != 2*time.Second {
+		t.Errorf("expected the latest answer's time, got %s", got)
+	}
+	if round.Votes[wrong] != 0 || round.Votes[correct] != 1 {
+		t.Errorf("expected the vote to move to the latest answer, got %v", round.Votes)
+	}
+
+	for quiz.InProgress() {
+		time.Sleep(time.Millisecond)
+	}
+	if got := quiz.Score()["alice"]; got != 6 {
+		t.Errorf("expected the latest answer to score 6 points, got %d", got)
+	}
+}
+
+func TestQuestionExtremes(t *testing.T) {
+	db := newTestDB(t)
+	if _, err := NewLeaderboard(zap.NewNop().Sugar(), db); err != nil {
+		t.Fatalf("failed to create leaderboard: %v", err)
+	}
+
+	answeredAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	seed := func(text string, correct, answered int, removed string) int64 {
+		id := insertQuestion(t, db, &models.Question{Question: text, Answer: "a", Choices: "a,b", Removed: removed})
+		for i := 0; i < answered; i++ {
+			record := &models.Participation{
+				Name:       fmt.Sprint("player", i),
+				QuestionID: null.Int64From(id),
+				Correct:    i < correct,
+				AnsweredAt: answeredAt,
+			}
+			if err := record.InsertG(context.Background(), boil.Infer()); err != nil {
+				t.Fatalf("failed to seed participation: %v", err)
+			}
+		}
+		return id
+	}
+
+	hard := seed("hard", 1, 10, "0")
+	seed("medium", 5, 10, "0")
+	easy := seed("easy", 9, 10, "0")
+	seed("lucky", 1, 1, "0")
+	seed("removed", 0, 10, "1")
+
+	hardest, easiest, err := QuestionExtremes(context.Background(), db, 2, 5)
+	if err != nil {
+		t.Fatalf("failed to get extremes: %v", err)
+	}
+
+	if len(hardest) != 2 || hardest[0].ID != hard || hardest[0].Rate() != 10 {
+		t.Errorf("expected the hard question to be hardest at 10%%, got %v", hardest)
+	}
+	if len(easiest) != 2 || easiest[0].ID != easy || easiest[0].Rate() != 90 {
+		t.Errorf("expected the easy question to be easiest at 90%%, got %v", easiest)
+	}
+	for _, rate := range append(hardest, easiest...) {
+		if rate.Question == "lucky" || rate.Question == "removed" {
+			t.Errorf("expected %q not to be ranked", rate.Question)
+		}
+	}
+}
+
+func TestFreshnessFavorsFresherQuestions(t *testing.T) {
+	db := newTestDB(t)
+	now := time.Now()
+
+	fresh := insertQuestion(t, db, &models.Question{Question: "fresh", Answer: "a", Choices: "a,b"})
+	stale := insertQuestion(t, db, &models.Question{Question: "stale", Answer: "a", Choices: "a,b"})
+	for i := 0; i < 4; i++ {
+		if err := MarkAsked(context.Background(), db, stale, now); err != nil {
+			t.Fatalf("failed to mark question as asked: %v", err)
+		}
+	}
+
+	question, err := models.FindQuestion(context.Background(), db, null.Int64From(stale))
+	if err != nil {
+		t.Fatalf("failed to find question: %v", err)
+	}
+	if question.Used != 4 || !question.LastAsked.Valid {
+		t.Fatalf("expected the stale question to be used 4 times, got %d", question.Used)
+	}
+
+	if Freshness(0, null.Time{}, now) != 1 {
+		t.Error("expected a question never asked to be perfectly fresh")
+	}
+	if Freshness(1, null.TimeFrom(now), now) >= Freshness(1, null.TimeFrom(now.Add(-7*24*time.Hour)), now) {
+		t.Error("expected a question asked recently to be staler than one asked long ago")
+	}
+
+	source := (&DBSource{db: db, freshness: 1}).Filtered(Filter{})
+	counts := map[int64]int{}
+	for i := 0; i < 300; i++ {
+		question, err := source.Question()
+		if err != nil {
+			t.Fatalf("failed to draw question: %v", err)
+		}
+		counts[question.ID]++
+	}
+
+	// weighted 1 to about 1/6, the fresh question is expected about 257 times
+	if counts[fresh] < 200 || counts[stale] == 0 {
+		t.Errorf("expected the fresh question to be drawn most but not always, got %d fresh and %d stale", counts[fresh], counts[stale])
+	}
+}
+
+func TestPointsDecay(t *testing.T) {
+	db := newTestDB(t)
+	lboard, err := NewLeaderboard(zap.NewNop().Sugar(), db)
+	if err != nil {
+		t.Fatalf("failed to create leaderboard: %v", err)
+	}
+	lboard.SetDecay(0.1)
+
+	if err = lboard.Update(map[string]int{"alice": 100, "bob": 80}); err != nil {
+		t.Fatalf("failed to update leaderboard: %v", err)
+	}
+	legacy := &models.User{Name: "carol", Points: 90, GamesPlayed: 1}
+	if err = legacy.InsertG(context.Background(), boil.Infer()); err != nil {
+		t.Fatalf("failed to insert user: %v", err)
+	}
+
+	// alice hasn't played for 3 and a half days
+	gap := null.TimeFrom(time.Now().Add(-84 * time.Hour))
+	_, err = models.Users(models.UserWhere.Name.EQ("alice")).UpdateAllG(context.Background(), models.M{models.UserColumns.LastActive: gap})
+	if err != nil {
+		t.Fatalf("failed to age alice: %v", err)
+	}
+
+	users, err := lboard.Highscores(0)
+	if err != nil {
+		t.Fatalf("failed to get highscores: %v", err)
+	}
+	got := []string{}
+	for _, user := range users {
+		got = append(got, fmt.Sprintf("%s %d", user.Name, user.Points))
+	}
+	// 100 points decayed by 10% on each of 3 whole days, while carol has no
+	// record of being active
+	if want := "carol 90, bob 80, alice 73"; strings.Join(got, ", ") != want {
+		t.Errorf("expected %s, got %s", want, strings.Join(got, ", "))
+	}
+
+	if err = lboard.Update(map[string]int{"alice": 10}); err != nil {
+		t.Fatalf("failed to update leaderboard: %v", err)
+	}
+	alice, err := models.Users(models.UserWhere.Name.EQ("alice")).OneG(context.Background())
+	if err != nil {
+		t.Fatalf("failed to get alice: %v", err)
+	}
+	if alice.Points != 83 {
+		t.Errorf("expected the decay to be stored once alice played again, got %d points", alice.Points)
+	}
+
+	lboard.SetDecay(0)
+	if users, err = lboard.Highscores(1); err != nil || users[0].Name != "carol" || users[0].Points != 90 {
+		t.Errorf("expected carol to lead without decay, got %v, %v", users, err)
+	}
+}
+
+func TestBalancedSource(t *testing.T) {
+	db := newTestDB(t)
+	for _, category := range []string{"Art", "History", "Science"} {
+		for i := 0; i < 2; i++ {
+			insertQuestion(t, db, &models.Question{
+				Question: fmt.Sprintf("%s %d", category, i),
+				Answer:   "a",
+				Choices:  "a,b",
+				Category: null.StringFrom(category),
+			})
+		}
+	}
+
+	source, err := NewBalancedSource(&DBSource{db: db}, Filter{Category: "Art"})
+	if err != nil {
+		t.Fatalf("failed to create balanced source: %v", err)
+	}
+	quiz, err := NewQuizWithOptions(zap.NewNop().Sugar(), source, QuizOptions{Size: 5, Duration: time.Second})
+	if err != nil {
+		t.Fatalf("failed to create quiz: %v", err)
+	}
+
+	counts := map[string]int{}
+	for i, round := range quiz.Rounds {
+		counts[round.Question.Category]++
+		// the first 3 rounds cover every category before any repeats
+		if i < 3 && counts[round.Question.Category] > 1 {
+			t.Errorf("round %d repeated category %s before covering the others", round.Num, round.Question.Category)
+		}
+	}
+	if len(counts) != 3 {
+		t.Errorf("expected the quiz to touch 3 categories, got %v", counts)
+	}
+}
+
+func TestUpdateMergesScores(t *testing.T) {
+	db := newTestDB(t)
+	lboard, err := NewChannelLeaderboard(zap.NewNop().Sugar(), db, "a")
+	if err != nil {
+		t.Fatalf("failed to create leaderboard: %v", err)
+	}
+	other, err := NewChannelLeaderboard(zap.NewNop().Sugar(), db, "b")
+	if err != nil {
+		t.Fatalf("failed to create leaderboard: %v", err)
+	}
+
+	for _, entries := range []map[string]int{
+		{"alice": 10, "bob": 4},
+		{"alice": 6, "carol": 2},
+	} {
+		if err = lboard.Update(entries); err != nil {
+			t.Fatalf("failed to update leaderboard: %v", err)
+		}
+	}
+	if err = other.Update(map[string]int{"alice": 100}); err != nil {
+		t.Fatalf("failed to update leaderboard: %v", err)
+	}
+
+	assertTotals := func(want string) {
+		t.Helper()
+		users, err := lboard.Highscores(0)
+		if err != nil {
+			t.Fatalf("failed to get highscores: %v", err)
+		}
+		got := []string{}
+		for _, user := range users {
+			got = append(got, fmt.Sprintf("%s %d/%d", user.Name, user.Points, user.GamesPlayed))
+		}
+		if strings.Join(got, ", ") != want {
+			t.Errorf("expected %s, got %s", want, strings.Join(got, ", "))
+		}
+	}
+	assertTotals("alice 16/2, bob 4/1, carol 2/1")
+
+	// a failed update leaves every total as it was
+	_, err = db.Exec(`CREATE TRIGGER reject_mallory BEFORE INSERT ON users
+		WHEN NEW.name = 'mallory' BEGIN SELECT RAISE(ABORT, 'rejected'); END`)
+	if err != nil {
+		t.Fatalf("failed to create trigger: %v", err)
+	}
+	if err = lboard.Update(map[string]int{"alice": 6, "bob": 6, "mallory": 6}); err == nil {
+		t.Fatal("expected the update to fail")
+	}
+	assertTotals("alice 16/2, bob 4/1, carol 2/1")
+}
+
+func TestAverageTime(t *testing.T) {
+	quiz := newTestQuiz(t, 1)
+	round, err := quiz.StartRound(func(string, []*Participant) error { return nil })
+	if err != nil {
+		t.Fatalf("failed to start round: %v", err)
+	}
+	round.StartedAt = time.UnixMilli(time.Now().UnixMilli())
+
+	if _, ok := round.AverageTime(); ok {
+		t.Error("expected no average before anyone answered")
+	}
+
+	for i, after := range []time.Duration{time.Second, 2500 * time.Millisecond, 5500 * time.Millisecond} {
+		name := fmt.Sprintf("player%d", i)
+		if err = round.NewParticipant(name, 0, round.StartedAt.Add(after).UnixMilli()); err != nil {
+			t.Fatalf("answer of %s was rejected: %v", name, err)
+		}
+	}
+
+	if avg, ok := round.AverageTime(); !ok || avg != 3*time.Second {
+		t.Errorf("expected an average of 3s, got %s", avg)
+	}
+	round.End()
+	<-round.Done()
+}
+
+func TestBurstDetection(t *testing.T) {
+	for _, burst := range []BurstDetection{{}, {Size: 3, Window: 5 * time.Millisecond}} {
+		quiz, err := NewQuizWithOptions(zap.NewNop().Sugar(), newSliceSource(), QuizOptions{
+			Size:     1,
+			Duration: 50 * time.Millisecond,
+			Burst:    burst,
+		})
+		if err != nil {
+			t.Fatalf("failed to create quiz: %v", err)
+		}
+		round, err := quiz.StartRound(func(string, []*Participant) error { return nil })
+		if err != nil {
+			t.Fatalf("failed to start round: %v", err)
+		}
+		round.StartedAt = time.UnixMilli(time.Now().UnixMilli())
+
+		answers := []struct {
+			name  string
+			after time.Duration
+		}{
+			{"alice", 10 * time.Millisecond},
+			{"bob", 12 * time.Millisecond},
+			{"carol", 30 * time.Millisecond},
+			{"dave", 14 * time.Millisecond},
+		}
+		for _, a := range answers {
+			if err = round.NewParticipant(a.name, 0, round.StartedAt.Add(a.after).UnixMilli()); err != nil {
+				t.Fatalf("answer of %s was rejected: %v", a.name, err)
+			}
+		}
+
+		got := round.Suspicious()
+		if burst.enabled() {
+			if want := "alice,bob,dave"; strings.Join(got, ",") != want {
+				t.Errorf("expected %v to be flagged, got %v", want, got)
+			}
+		} else if len(got) != 0 {
+			t.Errorf("expected nothing flagged without burst detection, got %v", got)
+		}
+		<-round.Done()
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	quiz := newTestQuiz(t, 2)
+
+	snapshot := func() QuizState {
+		t.Helper()
+		data, err := quiz.Snapshot()
+		if err != nil {
+			t.Fatalf("failed to take snapshot: %v", err)
+		}
+		var state QuizState
+		if err = json.Unmarshal(data, &state); err != nil {
+			t.Fatalf("failed to parse snapshot %s: %v", data, err)
+		}
+		return state
+	}
+
+	if state := snapshot(); state.InProgress || state.CurrentRound != -1 || len(state.Rounds) != 2 {
+		t.Errorf("unexpected snapshot before the quiz started %+v", state)
+	}
+
+	round, err := quiz.StartRound(func(string, []*Participant) error { return nil })
+	if err != nil {
+		t.Fatalf("failed to start round: %v", err)
+	}
+	round.Open(time.Now())
+	correct, _ := round.Question.Correct()
+	if err = round.NewParticipant("alice", correct, time.Now().UnixMilli()); err != nil {
+		t.Fatalf("failed to answer: %v", err)
+	}
+
+	state := snapshot()
+	if !state.InProgress || state.CurrentRound != 0 || state.Size != 2 {
+		t.Errorf("expected the first round in progress, got %+v", state)
+	}
+	current := state.Rounds[0]
+	if current.Complete || len(current.Participants) != 1 || current.Participants[0].Name != "alice" || current.Votes[correct] != 1 {
+		t.Errorf("expected alice's answer in the current round, got %+v", current)
+	}
+	if len(current.Answers) != len(round.Question.Answers) || !current.Answers[correct].Correct {
+		t.Errorf("expected the answers with the correct one marked, got %+v", current.Answers)
+	}
+
+	<-round.Done()
+	state = snapshot()
+	if state.InProgress || !state.Rounds[0].Complete || state.Rounds[1].Complete || state.Scoreboard["alice"] != 6 {
+		t.Errorf("expected alice scored after the first round, got %+v", state)
+	}
+}
+
+func TestTiedAnswers(t *testing.T) {
+	tests := []struct {
+		ties TieScoring
+		want map[string]int
+	}{
+		{TiesByArrival, map[string]int{"alice": 6, "bob": 4, "carol": 2, "dave": 1}},
+		{TiesShared, map[string]int{"alice": 6, "bob": 6, "carol": 2, "dave": 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ties.String(), func(t *testing.T) {
+			quiz, err := NewQuizWithOptions(zap.NewNop().Sugar(), newSliceSource(), QuizOptions{
+				Size:     1,
+				Duration: 20 * time.Millisecond,
+				Ties:     tt.ties,
+			})
+			if err != nil {
+				t.Fatalf("failed to create quiz: %v", err)
+			}
+
+			round := playRound(t, quiz,
+				submission{"alice", true, 100 * time.Millisecond},
+				submission{"bob", true, 100 * time.Millisecond},
+				submission{"carol", true, 150 * time.Millisecond},
+				submission{"dave", true, 150 * time.Millisecond},
+			)
+
+			score := quiz.Score()
+			for name, points := range tt.want {
+				if score[name] != points {
+					t.Errorf("expected %s to score %d, got %d", name, points, score[name])
+				}
+			}
+
+			winners, _ := round.DetermineOutcome()
+			if winners[0].Name != "alice" || winners[1].Name != "bob" {
+				t.Errorf("expected ties to keep the order they arrived in, got %v", winners)
+			}
+		})
+	}
+}
+
+func TestDailyQuizIsIdentical(t *testing.T) {
+	db := newTestDB(t)
+	for i := 0; i < 20; i++ {
+		insertQuestion(t, db, &models.Question{
+			Question: fmt.Sprintf("question %d", i),
+			Answer:   "a",
+			Choices:  "a,b,c,d",
+		})
+	}
+
+	build := func(day time.Time) []string {
+		t.Helper()
+
+		seed := DailySeed(day)
+		source, err := (&DBSource{db: db}).Seeded(Filter{}, seed)
+		if err != nil {
+			t.Fatalf("failed to seed source: %v", err)
+		}
+		quiz, err := NewQuizWithOptions(zap.NewNop().Sugar(), source, QuizOptions{Size: 5, Duration: time.Second, Seed: seed})
+		if err != nil {
+			t.Fatalf("failed to create quiz: %v", err)
+		}
+
+		asked := []string{}
+		for _, round := range quiz.Rounds {
+			if err = quiz.orderAnswers(round.Question); err != nil {
+				t.Fatalf("failed to order answers: %v", err)
+			}
+			answers := []string{}
+			for _, ans := range round.Question.Answers {
+				answers = append(answers, ans.Value)
+			}
+			asked = append(asked, round.Question.Question+" "+strings.Join(answers, ","))
+		}
+		return asked
+	}
+
+	morning := time.Date(2024, time.March, 1, 8, 0, 0, 0, time.UTC)
+	first, second := build(morning), build(morning.Add(12*time.Hour))
+	if strings.Join(first, "|") != strings.Join(second, "|") {
+		t.Errorf("expected the same quiz all day, got %q and %q", first, second)
+	}
+
+	if next := build(morning.Add(24 * time.Hour)); strings.Join(first, "|") == strings.Join(next, "|") {
+		t.Errorf("expected the next day's quiz to differ, got %q both days", first)
+	}
+}
+
+func TestMergePlayers(t *testing.T) {
+	db := newTestDB(t)
+	lboard, err := NewChannelLeaderboard(zap.NewNop().Sugar(), db, "")
+	if err != nil {
+		t.Fatalf("failed to create leaderboard: %v", err)
+	}
+	if err = lboard.Update(map[string]int{"alice": 10, "alice_": 6, "bob": 4}); err != nil {
+		t.Fatalf("failed to update leaderboard: %v", err)
+	}
+
+	totals := func() string {
+		t.Helper()
+		users, err := lboard.Highscores(0)
+		if err != nil {
+			t.Fatalf("failed to get highscores: %v", err)
+		}
+		got := []string{}
+		for _, user := range users {
+			got = append(got, fmt.Sprintf("%s %d/%d", user.Name, user.Points, user.GamesPlayed))
+		}
+		return strings.Join(got, ", ")
+	}
+
+	if err = lboard.Merge("alice", "alice_"); err != nil {
+		t.Fatalf("failed to merge: %v", err)
+	}
+	if got, want := totals(), "alice_ 16/2, bob 4/1"; got != want {
+		t.Errorf("expected %s after merging, got %s", want, got)
+	}
+
+	if err = lboard.Merge("bob", "robert"); err != nil {
+		t.Fatalf("failed to merge into a new name: %v", err)
+	}
+	if got, want := totals(), "alice_ 16/2, robert 4/1"; got != want {
+		t.Errorf("expected %s after renaming, got %s", want, got)
+	}
+
+	if err = lboard.Merge("alice_", "alice_"); err == nil {
+		t.Error("expected merging a player into themselves to fail")
+	}
+	if err = lboard.Merge("carol", "alice_"); !errors.Is(err, ErrNotOnLeaderboard) {
+		t.Errorf("expected merging an unknown player to fail with %v, got %v", ErrNotOnLeaderboard, err)
+	}
+	if got, want := totals(), "alice_ 16/2, robert 4/1"; got != want {
+		t.Errorf("expected failed merges to change nothing, got %s", got)
+	}
+}
+
+func TestGracePeriod(t *testing.T) {
+	quiz, err := NewQuizWithOptions(zap.NewNop().Sugar(), newSliceSource(), QuizOptions{
+		Size:       1,
+		Duration:   20 * time.Millisecond,
+		Grace:      100 * time.Millisecond,
+		LatePoints: 1,
+	})
+	if err != nil {
+		t.Fatalf("failed to create quiz: %v", err)
+	}
+	round, err := quiz.StartRound(func(string, []*Participant) error { return nil })
+	if err != nil {
+		t.Fatalf("failed to start round: %v", err)
+	}
+	round.StartedAt = time.Now()
+	correct, _ := round.Question.Correct()
+
+	if err = round.NewParticipant("alice", correct, time.Now().UnixMilli()); err != nil {
+		t.Fatalf("answer in the round was rejected: %v", err)
+	}
+	for round.IsOpen() {
+		time.Sleep(time.Millisecond)
+	}
+	if err = round.NewParticipant("bob", correct, time.Now().UnixMilli()); err != nil {
+		t.Fatalf("answer in the grace period was rejected: %v", err)
+	}
+
+	<-round.Done()
+
+	var windowErr *OutsideWindowError
+	if err = round.NewParticipant("carol", correct, time.Now().UnixMilli()); !errors.As(err, &windowErr) {
+		t.Errorf("expected an answer after the grace period to be rejected, got %v", err)
+	}
+
+	winners, losers := round.DetermineOutcome()
+	if len(winners) != 1 || winners[0].Name != "alice" {
+		t.Errorf("expected only alice to win, got %v", winners)
+	}
+	if len(losers) != 1 || losers[0].Name != "bob" || !losers[0].Late {
+		t.Errorf("expected bob's answer to be counted as late, got %v", losers)
+	}
+	if alice, bob := quiz.Scoreboard["alice"], quiz.Scoreboard["bob"]; bob != 1 || alice <= bob {
+		t.Errorf("expected bob to score only the late point below alice, got %d and %d", bob, alice)
+	}
+}
+
+func TestFileSource(t *testing.T) {
+	source, err := NewFileSource(filepath.Join("testdata", "questions.json"))
+	if err != nil {
+		t.Fatalf("failed to load questions: %v", err)
+	}
+	if categories, _ := source.Categories(); strings.Join(categories, ",") != "Geography,History" {
+		t.Errorf("unexpected categories %q", categories)
+	}
+
+	quiz, err := NewQuizWithOptions(zap.NewNop().Sugar(), source.Filtered(Filter{Category: "geography"}), QuizOptions{
+		Size:     3,
+		Duration: 20 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("failed to create quiz: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		round := playRound(t, quiz, submission{"alice", true, time.Millisecond})
+		if round.Question.Category != "Geography" || len(round.Question.Answers) != 4 {
+			t.Errorf("round %d asked %q of %s", round.Num, round.Question.Question, round.Question.Category)
+		}
+	}
+	if quiz.Scoreboard["alice"] == 0 {
+		t.Error("expected alice to score for answering correctly")
+	}
+
+	if _, err = source.Filtered(Filter{Difficulty: "hard"}).Question(); !errors.Is(err, ErrNoQuestions) {
+		t.Errorf("expected no hard questions, got %v", err)
+	}
+
+	malformed := filepath.Join(t.TempDir(), "malformed.json")
+	data := `[
+		{"question": "What is 1 + 1?", "answer": "2", "choices": ["2", "3"]},
+		{"question": "What is 2 + 2?", "answer": "4", "choices": ["3", "5"]},
+		{"question": "", "answer": "a", "choices": ["a", "b"]}
+	]`
+	if err = os.WriteFile(malformed, []byte(data), 0o600); err != nil {
+		t.Fatalf("failed to write questions: %v", err)
+	}
+	_, err = NewFileSource(malformed)
+	if err == nil || strings.Contains(err.Error(), "question 1 ") ||
+		!strings.Contains(err.Error(), "question 2 ") || !strings.Contains(err.Error(), "question 3 ") {
+		t.Errorf("expected questions 2 and 3 to be reported, got %v", err)
+	}
+}
+
+func TestDistinctQuestions(t *testing.T) {
+	question := func(text string) *Question {
+		return &Question{Question: text, Answers: []*Answer{{Value: "a", Correct: true}, {Value: "b"}}}
+	}
+	// a tiny pool which repeats itself, like random draws would
+	newSource := func() Source {
+		return &sliceSource{questions: []*Question{
+			question("A"), question("A"), question("B"), question("A"), question("C"), question("B"),
+		}}
+	}
+	build := func(size int) (*Quiz, []string) {
+		t.Helper()
+		quiz, err := NewQuizWithOptions(zap.NewNop().Sugar(), newSource(), QuizOptions{
+			Size:     size,
+			Duration: 20 * time.Millisecond,
+			Distinct: true,
+		})
+		if err != nil {
+			t.Fatalf("failed to create quiz: %v", err)
+		}
+		asked := []string{}
+		for _, round := range quiz.Rounds {
+			asked = append(asked, round.Question.Question)
+		}
+		return quiz, asked
+	}
+
+	if _, asked := build(3); strings.Join(asked, ",") != "A,B,C" {
+		t.Errorf("expected each question once, got %q", asked)
+	}
+
+	quiz, asked := build(5)
+	if strings.Join(asked, ",") != "A,B,C" {
+		t.Errorf("expected the quiz to stop once the questions ran out, got %q", asked)
+	}
+	if quiz.Size() != 3 || !quiz.Rounds[2].Final {
+		t.Errorf("expected the third round to be the final of 3, got %d rounds with final %t", quiz.Size(), quiz.Rounds[2].Final)
+	}
+
+	if _, err := NewQuizWithOptions(zap.NewNop().Sugar(), newSource(), QuizOptions{Size: 5}); err != nil {
+		t.Errorf("expected repeats to be allowed by default, got %v", err)
+	}
+}
+
+// insertQuestions fills db with n questions spread over categories.
+func insertQuestions(tb testing.TB, db *sql.DB, n int, categories ...string) {
+	tb.Helper()
+
+	tx, err := db.Begin()
+	if err != nil {
+		tb.Fatalf("failed to begin: %v", err)
+	}
+	for i := 0; i < n; i++ {
+		question := &models.Question{
+			Question: fmt.Sprintf("Question %d?", i),
+			Answer:   "a",
+			Choices:  "a,b,c",
+			Source:   "test",
+			Category: null.StringFrom(categories[i%len(categories)]),
+		}
+		if err = question.Insert(context.Background(), tx, boil.Infer()); err != nil {
+			tb.Fatalf("failed to insert question %d: %v", i, err)
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		tb.Fatalf("failed to commit: %v", err)
+	}
+}
+
+func BenchmarkQuizGeneration(b *testing.B) {
+	db := newTestDB(b)
+	insertQuestions(b, db, 20000, "Geography", "History", "Science", "Sports")
+	source := &DBSource{db: db}
+	logger := zap.NewNop().Sugar()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := NewQuizWithOptions(logger, source.Filtered(Filter{Category: "History"}), QuizOptions{Size: 10})
+		if err != nil {
+			b.Fatalf("failed to create quiz: %v", err)
+		}
+	}
+}
+
+func TestFilteredDBSourceSelection(t *testing.T) {
+	db := newTestDB(t)
+	insertQuestions(t, db, 30, "Geography", "History", "Science")
+
+	// removed and excluded questions must never be drawn
+	if _, err := db.Exec("UPDATE questions SET removed = 1 WHERE question = 'Question 1?'"); err != nil {
+		t.Fatalf("failed to remove question: %v", err)
+	}
+	var excluded int64
+	if err := db.QueryRow("SELECT id FROM questions WHERE question = 'Question 4?'").Scan(&excluded); err != nil {
+		t.Fatalf("failed to find question: %v", err)
+	}
+
+	source := (&DBSource{db: db}).Filtered(Filter{Category: "history", Exclude: []int64{excluded}})
+	drawn := map[string]bool{}
+	for i := 0; i < 8; i++ {
+		question, err := source.Question()
+		if err != nil {
+			t.Fatalf("failed to draw question %d: %v", i, err)
+		}
+		if question.Category != "History" {
+			t.Errorf("drew %q of %s", question.Question, question.Category)
+		}
+		if drawn[question.Question] {
+			t.Errorf("drew %q twice before drawing every question", question.Question)
+		}
+		drawn[question.Question] = true
+	}
+	for _, skipped := range []string{"Question 1?", "Question 4?"} {
+		if drawn[skipped] {
+			t.Errorf("drew %q, which is removed or excluded", skipped)
+		}
+	}
+
+	// every question was drawn, so the next starts over
+	if _, err := source.Question(); err != nil {
+		t.Errorf("expected to draw again once every question was drawn, got %v", err)
+	}
+
+	if _, err := (&DBSource{db: db}).Filtered(Filter{Category: "Sports"}).Question(); !errors.Is(err, ErrNoQuestions) {
+		t.Errorf("expected no questions for an empty category, got %v", err)
+	}
+}
+
+func TestTotals(t *testing.T) {
+	db := newTestDB(t)
+	lboard, err := NewChannelLeaderboard(zap.NewNop().Sugar(), db, "")
+	if err != nil {
+		t.Fatalf("failed to create leaderboard: %v", err)
+	}
+
+	if points, players, err := lboard.Totals(); err != nil || points != 0 || players != 0 {
+		t.Errorf("expected no totals on an empty leaderboard, got %d points of %d players, %v", points, players, err)
+	}
+
+	if err = lboard.Update(map[string]int{"alice": 10, "bob": 6}); err != nil {
+		t.Fatalf("failed to update leaderboard: %v", err)
+	}
+	if err = lboard.Update(map[string]int{"alice": 4, "carol": 2}); err != nil {
+		t.Fatalf("failed to update leaderboard: %v", err)
+	}
+
+	// other channels are counted separately
+	other, err := NewChannelLeaderboard(zap.NewNop().Sugar(), db, "other")
+	if err != nil {
+		t.Fatalf("failed to create leaderboard: %v", err)
+	}
+	if err = other.Update(map[string]int{"dave": 100}); err != nil {
+		t.Fatalf("failed to update leaderboard: %v", err)
+	}
+
+	if points, players, err := lboard.Totals(); err != nil || points != 22 || players != 3 {
+		t.Errorf("expected 22 points of 3 players, got %d points of %d players, %v", points, players, err)
+	}
+}
+
+func TestOnlyCorrectAnswersScore(t *testing.T) {
+	quiz := newTestQuiz(t, 1)
+
+	round := playRound(t, quiz,
+		submission{"alice", false, 100 * time.Millisecond},
+		submission{"bob", false, 200 * time.Millisecond},
+		submission{"carol", true, 1 * time.Second},
+		submission{"dave", false, 1500 * time.Millisecond},
+		submission{"erin", true, 2 * time.Second},
+	)
+
+	winners, losers := round.DetermineOutcome()
+	if len(winners) != 2 || winners[0].Name != "carol" || winners[1].Name != "erin" {
+		t.Errorf("expected only the correct answers to win, fastest first, got %v", winners)
+	}
+	if len(losers) != 3 {
+		t.Errorf("expected the 3 wrong answers to lose, got %v", losers)
+	}
+
+	want := map[string]int{"alice": 0, "bob": 0, "carol": 6, "dave": 0, "erin": 4}
+	if got := quiz.Score(); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected scores %v, got %v", want, got)
+	}
+
+	// a question without a correct answer has no winners, whatever was picked
+	for _, ans := range round.Question.Answers {
+		ans.Correct = false
+	}
+	if winners, _ = round.DetermineOutcome(); len(winners) != 0 {
+		t.Errorf("expected no winners without a correct answer, got %v", winners)
+	}
+}
+
+func TestDeletedQuestionIsSubstituted(t *testing.T) {
+	db := newTestDB(t)
+	insertQuestions(t, db, 10, "General")
+
+	source, err := (&DBSource{db: db}).Seeded(Filter{}, 1)
+	if err != nil {
+		t.Fatalf("failed to seed source: %v", err)
+	}
+	scheduled := append([]int64{}, source.(*seededDBSource).ids...)
+
+	// delete the question of the second round after it was scheduled
+	if _, err = db.Exec("DELETE FROM questions WHERE id = ?", scheduled[1]); err != nil {
+		t.Fatalf("failed to delete question: %v", err)
+	}
+
+	quiz, err := NewQuizWithOptions(zap.NewNop().Sugar(), source, QuizOptions{Size: 3, Duration: time.Second})
+	if err != nil {
+		t.Fatalf("expected the deleted question to be substituted, got %v", err)
+	}
+
+	want := []int64{scheduled[0], scheduled[2], scheduled[3]}
+	for i, round := range quiz.Rounds {
+		if round.Question.ID != want[i] || round.Num != i+1 {
+			t.Errorf("expected round %d to ask question %d, got round %d asking %d", i+1, want[i], round.Num, round.Question.ID)
+		}
+	}
+	if len(quiz.Rounds) != 3 || !quiz.Rounds[2].Final {
+		t.Errorf("expected 3 rounds, the last final, got %d", len(quiz.Rounds))
+	}
+}
+
+func TestNewQuizFromIDs(t *testing.T) {
+	db := newTestDB(t)
+	insertQuestions(t, db, 5, "General")
+	logger := zap.NewNop().Sugar()
+
+	ids := []int64{4, 2, 5}
+	quiz, err := NewQuizFromIDs(context.Background(), db, logger, ids, QuizOptions{Size: 10, WarmUp: true, Duration: time.Second})
+	if err != nil {
+		t.Fatalf("failed to build quiz: %v", err)
+	}
+	if quiz.Size() != len(ids) || len(quiz.Rounds) != len(ids) {
+		t.Fatalf("expected %d rounds, got %d", len(ids), len(quiz.Rounds))
+	}
+	for i, round := range quiz.Rounds {
+		if round.Question.ID != ids[i] || round.Num != i+1 {
+			t.Errorf("expected round %d to ask question %d, got round %d asking %d", i+1, ids[i], round.Num, round.Question.ID)
+		}
+	}
+	if !quiz.Rounds[len(ids)-1].Final {
+		t.Error("expected the last round to be final")
+	}
+
+	_, err = NewQuizFromIDs(context.Background(), db, logger, []int64{1, 99, 3, 100}, QuizOptions{Duration: time.Second})
+	if !errors.Is(err, ErrNoQuestions) || !strings.Contains(err.Error(), "IDs 99, 100") {
+		t.Errorf("expected the missing IDs to be listed, got %v", err)
+	}
+}
+
+func TestOversizedChoices(t *testing.T) {
+	blob := strings.Repeat("x", MaxChoicesLength)
+	oversized := &Question{
+		Question: "What is the capital of Germany?",
+		Answers: []*Answer{
+			{Value: "Berlin", Correct: true},
+			{Value: blob},
+		},
+	}
+	source := newSliceSource()
+	source.questions = append([]*Question{oversized}, source.questions...)
+
+	quiz, err := NewQuiz(zap.NewNop().Sugar(), 1, time.Second, source)
+	if err != nil {
+		t.Fatalf("failed to create quiz: %v", err)
+	}
+	if got := quiz.Rounds[0].Question.Question; got == oversized.Question {
+		t.Error("expected the question with oversized choices to be skipped")
+	}
+
+	problems := QuestionProblems(&models.Question{Question: "oversized", Answer: "a", Choices: "a," + blob})
+	if len(problems) != 1 || problems[0] != ProblemLongChoices {
+		t.Errorf("expected lint to report oversized choices, got %v", problems)
+	}
+
+	path := filepath.Join(t.TempDir(), "questions.json")
+	data := fmt.Sprintf(`[{"question": "Q?", "answer": "a", "choices": ["a", %q]}]`, blob)
+	if err = os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatalf("failed to write questions: %v", err)
+	}
+	if _, err = NewFileSource(path); err == nil || !strings.Contains(err.Error(), "choices 401 characters long") {
+		t.Errorf("expected the oversized question to be rejected on import, got %v", err)
+	}
+}
+
+func TestStrengths(t *testing.T) {
+	db := newTestDB(t)
+	lboard, err := NewChannelLeaderboard(zap.NewNop().Sugar(), db, "a")
+	if err != nil {
+		t.Fatalf("failed to create leaderboard: %v", err)
+	}
+
+	ids := map[string]int64{}
+	for _, category := range []string{"Geography", "History", "Sports", "Music"} {
+		ids[category] = insertQuestion(t, db, &models.Question{
+			Question: category + "?",
+			Answer:   "a",
+			Choices:  "a,b",
+			Category: null.StringFrom(category),
+		})
+	}
+	uncategorized := insertQuestion(t, db, &models.Question{Question: "none?", Answer: "a", Choices: "a,b"})
+
+	seed := []struct {
+		name     string
+		channel  string
+		question int64
+		correct  []bool
+	}{
+		{"alice", "a", ids["Geography"], []bool{true, true, true, false}},
+		{"alice", "a", ids["History"], []bool{true, false, false, false}},
+		{"alice", "a", ids["Sports"], []bool{true, true, false}},
+		// too few answers to tell
+		{"alice", "a", ids["Music"], []bool{true, true}},
+		{"alice", "a", uncategorized, []bool{false, false, false}},
+		{"alice", "b", ids["History"], []bool{true, true, true}},
+		{"bob", "a", ids["History"], []bool{true, true, true}},
+	}
+	for _, s := range seed {
+		for _, correct := range s.correct {
+			record := &models.Participation{
+				Name:       s.name,
+				Channel:    s.channel,
+				QuestionID: null.Int64From(s.question),
+				Correct:    correct,
+				AnsweredAt: time.Now(),
+			}
+			if err = record.InsertG(context.Background(), boil.Infer()); err != nil {
+				t.Fatalf("failed to seed participation: %v", err)
+			}
+		}
+	}
+
+	records, err := lboard.Strengths("alice", 3)
+	if err != nil {
+		t.Fatalf("failed to get strengths: %v", err)
+	}
+
+	got := []string{}
+	for _, record := range records {
+		got = append(got, fmt.Sprintf("%s %d/%d", record.Category, record.Correct, record.Answered))
+	}
+	want := []string{"Geography 3/4", "Sports 2/3", "History 1/4"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected categories %v, got %v", want, got)
+	}
+}
+
+func TestRequeueSkipped(t *testing.T) {
+	for _, requeue := range []bool{false, true} {
+		t.Run(fmt.Sprintf("requeue=%t", requeue), func(t *testing.T) {
+			source := &sliceSource{}
+			for _, text := range []string{"first", "second", "third"} {
+				source.questions = append(source.questions, &Question{
+					Question: text,
+					Answers:  []*Answer{{Value: "yes", Correct: true}, {Value: "no"}},
+				})
+			}
+			quiz, err := NewQuizWithOptions(zap.NewNop().Sugar(), source, QuizOptions{
+				Size:           3,
+				Duration:       200 * time.Millisecond,
+				RequeueSkipped: requeue,
+			})
+			if err != nil {
+				t.Fatalf("failed to create quiz: %v", err)
+			}
+
+			playRound(t, quiz)
+			round, err := quiz.StartRound(func(string, []*Participant) error { return nil })
+			if err != nil {
+				t.Fatalf("failed to start round: %v", err)
+			}
+			round.Open(time.Now())
+			correct, _ := round.Question.Correct()
+			if err = round.NewParticipant("alice", correct, time.Now().UnixMilli()); err != nil {
+				t.Fatalf("failed to answer: %v", err)
+			}
+			if votes := round.VoteSkip("bob"); votes != 1 {
+				t.Errorf("expected 1 vote to skip, got %d", votes)
+			}
+			if !round.Skip() {
+				t.Fatal("expected the open round to be skipped")
+			}
+			<-round.Done()
+			if round.Skip() {
+				t.Error("expected a closed round not to be skipped again")
+			}
+
+			if points := quiz.Score()["alice"]; points != 0 {
+				t.Errorf("expected a skipped round to score nothing, alice has %d points", points)
+			}
+
+			want := []string{"first", "second", "third"}
+			if requeue {
+				want = append(want, "second")
+			}
+			got := []string{}
+			for _, r := range quiz.Rounds {
+				got = append(got, r.Question.Question)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("expected the rounds to ask %v, got %v", want, got)
+			}
+
+			last := quiz.Rounds[len(quiz.Rounds)-1]
+			if !last.Final || quiz.Rounds[2].Final != !requeue {
+				t.Errorf("expected only the last round to be final")
+			}
+			if last.Num != len(want) || quiz.Size() != len(want) {
+				t.Errorf("expected the last round to be %d of %d, got %d of %d", len(want), len(want), last.Num, quiz.Size())
+			}
+			if want := map[bool]int{true: 4}[requeue]; round.RequeuedAs() != want {
+				t.Errorf("expected the skipped round to be asked again as %d, got %d", want, round.RequeuedAs())
+			}
+		})
+	}
+}
+
+func TestAnswerAliases(t *testing.T) {
+	db := newTestDB(t)
+	id := insertQuestion(t, db, &models.Question{
+		Question: "Which country has 50 states?",
+		Answer:   "United States",
+		Choices:  "United States,Canada,Mexico",
+		Aliases:  null.StringFrom("USA,United States of America"),
+	})
+
+	quiz, err := NewQuizFromIDs(context.Background(), db, zap.NewNop().Sugar(), []int64{id}, QuizOptions{Duration: time.Second})
+	if err != nil {
+		t.Fatalf("failed to create quiz: %v", err)
+	}
+	question := quiz.Rounds[0].Question
+	correct, _ := question.Correct()
+
+	for _, data := range []string{"United States", "usa", "united  states of AMERICA"} {
+		if idx, err := question.MatchChoice(data); err != nil || idx != correct {
+			t.Errorf("MatchChoice(%q) = %d, %v, want the correct answer %d", data, idx, err, correct)
+		}
+	}
+	if idx, err := question.MatchChoice("canada"); err != nil || idx == correct {
+		t.Errorf("MatchChoice(%q) = %d, %v, want a wrong answer", "canada", idx, err)
+	}
+}
+
+func TestRankingCache(t *testing.T) {
+	db := newTestDB(t)
+	lboard, err := NewChannelLeaderboard(zap.NewNop().Sugar(), db, "a")
+	if err != nil {
+		t.Fatalf("failed to create leaderboard: %v", err)
+	}
+
+	names := func(users models.UserSlice) []string {
+		got := []string{}
+		for _, user := range users {
+			got = append(got, fmt.Sprintf("%s %d", user.Name, user.Points))
+		}
+		return got
+	}
+	ranking := func() models.UserSlice {
+		t.Helper()
+		users, err := lboard.Ranking()
+		if err != nil {
+			t.Fatalf("failed to rank: %v", err)
+		}
+		return users
+	}
+
+	if err = lboard.Update(map[string]int{"alice": 5, "bob": 3, "carol": 3}); err != nil {
+		t.Fatalf("failed to update: %v", err)
+	}
+	first := ranking()
+	if want := []string{"alice 5", "bob 3", "carol 3"}; !reflect.DeepEqual(names(first), want) {
+		t.Fatalf("got ranking %v, want %v", names(first), want)
+	}
+	if again := ranking(); again[0] != first[0] {
+		t.Error("expected the ranking to be cached until the leaderboard changes")
+	}
+
+	// concurrent updates each invalidate the ranking, leaving none stale
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if err := lboard.Update(map[string]int{"bob": 1}); err != nil {
+				t.Errorf("failed to update: %v", err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if _, err := lboard.Ranking(); err != nil {
+				t.Errorf("failed to rank: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if want, got := []string{"bob 8", "alice 5", "carol 3"}, names(ranking()); !reflect.DeepEqual(got, want) {
+		t.Errorf("got ranking %v after updating, want %v", got, want)
+	}
+	if top, err := lboard.Highscores(1); err != nil || len(top) != 1 || top[0].Name != "bob" {
+		t.Errorf("expected the highscores to follow the ranking, got %v, %v", names(top), err)
+	}
+}
+
+func TestCanonicalCategories(t *testing.T) {
+	for name, want := range map[string]string{
+		"History":              "History",
+		" history ":            "History",
+		"science  &\tnature":   "Science & nature",
+		"Entertainment: Film ": "Entertainment: Film",
+		"   ":                  "",
+	} {
+		if got := CanonicalCategory(name); got != want {
+			t.Errorf("CanonicalCategory(%q) = %q, want %q", name, got, want)
+		}
+	}
+
+	db := newTestDB(t)
+	for i, category := range []string{"History", "History", "history ", " HISTORY", "Science & Nature", "science  &  nature", " "} {
+		insertQuestion(t, db, &models.Question{
+			Question: fmt.Sprintf("question %d", i),
+			Answer:   "a",
+			Choices:  "a,b",
+			Category: null.StringFrom(category),
+		})
+	}
+	if err := canonicalizeCategories(context.Background(), db); err != nil {
+		t.Fatalf("failed to canonicalize categories: %v", err)
+	}
+
+	source := &DBSource{db: db}
+	categories, err := source.Categories()
+	if err != nil {
+		t.Fatalf("failed to list categories: %v", err)
+	}
+	if want := []string{"History", "Science & Nature"}; !reflect.DeepEqual(categories, want) {
+		t.Errorf("got categories %q, want %q", categories, want)
+	}
+	ids, err := (&filteredDBSource{filter: Filter{Category: "  history"}}).ids(context.Background())
+	if err != nil {
+		t.Fatalf("failed to filter questions: %v", err)
+	}
+	if len(ids) != 4 {
+		t.Errorf("expected every spelling of history to be asked, got %d questions", len(ids))
+	}
+	if uncategorized, _ := models.Questions(models.QuestionWhere.Category.IsNull()).Count(context.Background(), db); uncategorized != 1 {
+		t.Errorf("expected the blank category to be cleared, got %d questions without one", uncategorized)
+	}
+
+	path := filepath.Join(t.TempDir(), "questions.json")
+	data := `[
+		{"question": "A?", "answer": "a", "choices": ["a", "b"], "category": "geography"},
+		{"question": "B?", "answer": "a", "choices": ["a", "b"], "category": "Geography "},
+		{"question": "C?", "answer": "a", "choices": ["a", "b"], "category": "Geography"}
+	]`
+	if err = os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatalf("failed to write questions: %v", err)
+	}
+	file, err := NewFileSource(path)
+	if err != nil {
+		t.Fatalf("failed to load questions: %v", err)
+	}
+	if categories, _ = file.Categories(); !reflect.DeepEqual(categories, []string{"Geography"}) {
+		t.Errorf("got categories %q, want only Geography", categories)
+	}
+}
@@ -0,0 +1,320 @@
+package triviabot
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+
+	"github.com/dustin/go-humanize/english"
+)
+
+// Announcements are text/template sources of the messages announced in chat,
+// letting operators change the bot's tone without editing code. Empty fields
+// keep the default wording.
+type Announcements struct {
+	// Start is announced when a quiz is started, with startData.
+	Start string
+	// Round asks a round's question, with roundData.
+	Round string
+	// RoundComplete reveals a round's answer, with roundCompleteData.
+	RoundComplete string
+	// QuizComplete announces the winners of a quiz, with quizCompleteData.
+	QuizComplete string
+	// Timeout is announced before the results of a quiz which ran past its
+	// maximum duration, with timeoutData.
+	Timeout string
+	// Cooldown refuses to start a quiz too soon after the last, with
+	// cooldownData.
+	Cooldown string
+	// Countdown tells how long is left to answer a round, with countdownData.
+	Countdown string
+}
+
+var DefaultAnnouncements = Announcements{
+	Start:         "Quiz starting soon! {{ if .Public }}Type the `<number>` of your answer in chat{{ else }}`/w trivia <number>`{{ end }} to answer.{{ with .Double }} Double points in round {{ . }}!{{ end }}{{ with .Scoring }} Scoring: {{ . }}.{{ end }}",
+	Round:         "{{ if .WarmUp }}Warm-up round, no points{{ else if and .Final .FinalLabel }}{{ .FinalLabel }}{{ else }}Round {{ .Num }}/{{ .Total }}{{ end }}{{ if .Double }} (double points){{ end }}: `{{ .Question }}`{{ if .Truncated }} (see `trivia repeat` for the rest){{ end }}{{ range .Answers }} `{{ .Num }}) {{ .Value }}`{{ end }}{{ if .Media }} {{ .Media }}{{ end }}{{ if .Source }} (via {{ .Source }}){{ end }}",
+	RoundComplete: "Round complete! The correct answer is {{ .Correct }}.{{ if .Winners }} {{ .Winners }}{{ with .Emote }} {{ . }}{{ end }}{{ else }} No one answered correctly{{ with .Emote }} {{ . }}{{ end }}{{ end }}",
+	QuizComplete:  "Quiz complete! The following users are awarded points: {{ if .Winners }}{{ .Winners }}{{ with .Tiebreak }}. {{ .Winner }} and {{ .RunnerUp }} tied on points, {{ .Winner }} wins on speed{{ end }}{{ else }}No one!{{ end }}{{ with .Players }} ({{ . }} took part){{ end }}{{ with .Emote }} {{ . }}{{ end }}",
+	Timeout:       "Out of time! The quiz ran past {{ .Limit }}, here are the results so far.",
+	Cooldown:      "on cooldown for {{ .TimeLeft }}{{ with .Emote }} {{ . }}{{ end }}",
+	Countdown:     "{{ .Left }} left",
+}
+
+// Emotes are the reactions given in announcements, as .Emote, so each
+// platform can use its own.
+type Emotes struct {
+	// Correct reacts to someone answering or scoring correctly.
+	Correct string
+	// NoCorrect reacts to no one answering or scoring correctly.
+	NoCorrect string
+	// Cooldown reacts to a quiz being started too soon.
+	Cooldown string
+}
+
+var DefaultEmotes = Emotes{
+	NoCorrect: "DuckerZ",
+	Cooldown:  "PepoSleep",
+}
+
+// outcome returns the emote reacting to whether anyone was correct.
+func (e Emotes) outcome(correct bool) string {
+	if correct {
+		return e.Correct
+	}
+	return e.NoCorrect
+}
+
+type startData struct {
+	Starter string
+	// Public is set when answers are typed in chat rather than whispered.
+	Public bool
+	// Double lists the numbers of the rounds worth double points, like
+	// "2 and 3", or is empty if there are none.
+	Double string
+	// Scoring explains how the quiz is scored, like "1st +6, 2nd +4, ...",
+	// or is empty unless enabled with WithScoringBreakdown.
+	Scoring string
+}
+
+type answerData struct {
+	Num   int
+	Value string
+}
+
+// series lists items like "a, b, and c" joined by conjunction, or returns
+// none if there are no items, so an empty list never renders as a dangling
+// phrase.
+func series(items []string, conjunction, none string) string {
+	switch len(items) {
+	case 0:
+		return none
+	case 1:
+		return items[0]
+	default:
+		return english.OxfordWordSeries(items, conjunction)
+	}
+}
+
+// unknown stands in for what isn't known about a question when announcing it,
+// so templates never render an empty value.
+const unknown = "unknown"
+
+// orUnknown returns s, or unknown if it is empty.
+func orUnknown(s string) string {
+	if strings.TrimSpace(s) == "" {
+		return unknown
+	}
+	return s
+}
+
+type roundData struct {
+	// Num counts the scored rounds from 1, out of Total.
+	Num   int
+	Total int
+	Final bool
+	// FinalLabel is what the final round is called, or empty to number it
+	// like any other, see WithFinalRoundLabel.
+	FinalLabel string
+	WarmUp     bool
+	Double     bool
+	Question   string
+	// Category, Difficulty and Type describe the question, each "unknown"
+	// when the question has none.
+	Category   string
+	Difficulty string
+	Type       string
+	// Truncated is set when Question was cut short, see
+	// WithMaxQuestionLength.
+	Truncated bool
+	// Media is a URL to an image or audio clip, or empty if the question has
+	// none.
+	Media string
+	// Source credits where the question was collected from, if known and
+	// the bot is set to, see WithAttribution.
+	Source  string
+	Answers []answerData
+}
+
+type roundCompleteData struct {
+	Num int
+	// Correct is the correct answer formatted as it was asked, like
+	// "`3) Paris`".
+	Correct string
+	// CorrectNum is the number the correct answer was asked with, and
+	// CorrectValue its text.
+	CorrectNum   int
+	CorrectValue string
+	// Winners lists the fastest correct answers, or is empty if no one
+	// answered correctly.
+	Winners string
+	Emote   string
+	// Answered is how many players answered, of whom AnsweredCorrectly got
+	// it right.
+	Answered          int
+	AnsweredCorrectly int
+}
+
+type quizCompleteData struct {
+	// Winners lists the players awarded points, or is empty if no one was.
+	Winners string
+	// Tiebreak is set when the top two finished on equal points, see
+	// tiebreak.
+	Tiebreak *tiebreakData
+	// Players counts who answered at least one round, like "3 players", or
+	// is empty if no one did.
+	Players string
+	Emote   string
+}
+
+// tiebreakData names the winner of a quiz who was faster than RunnerUp, on
+// equal points.
+type tiebreakData struct {
+	Winner   string
+	RunnerUp string
+}
+
+type timeoutData struct {
+	Limit time.Duration
+}
+
+type cooldownData struct {
+	TimeLeft time.Duration
+	Emote    string
+}
+
+type countdownData struct {
+	Num  int
+	Left time.Duration
+}
+
+// announcer renders the compiled Announcements.
+type announcer struct {
+	start         *template.Template
+	round         *template.Template
+	roundComplete *template.Template
+	quizComplete  *template.Template
+	timeout       *template.Template
+	cooldown      *template.Template
+	countdown     *template.Template
+}
+
+// compile parses the announcement templates, falling back to the defaults for
+// empty ones, and checks each renders with sample data.
+func (a Announcements) compile() (*announcer, error) {
+	compiled := &announcer{}
+	for _, tpl := range []struct {
+		name   string
+		source string
+		dflt   string
+		sample any
+		dest   **template.Template
+	}{
+		{"start", a.Start, DefaultAnnouncements.Start, startData{}, &compiled.start},
+		{"round", a.Round, DefaultAnnouncements.Round, roundData{Answers: []answerData{{}}}, &compiled.round},
+		{"round complete", a.RoundComplete, DefaultAnnouncements.RoundComplete, roundCompleteData{}, &compiled.roundComplete},
+		{"quiz complete", a.QuizComplete, DefaultAnnouncements.QuizComplete, quizCompleteData{}, &compiled.quizComplete},
+		{"timeout", a.Timeout, DefaultAnnouncements.Timeout, timeoutData{}, &compiled.timeout},
+		{"cooldown", a.Cooldown, DefaultAnnouncements.Cooldown, cooldownData{}, &compiled.cooldown},
+		{"countdown", a.Countdown, DefaultAnnouncements.Countdown, countdownData{}, &compiled.countdown},
+	} {
+		source := tpl.source
+		if source == "" {
+			source = tpl.dflt
+		}
+
+		parsed, err := template.New(tpl.name).Option("missingkey=error").Parse(source)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s announcement: %w", tpl.name, err)
+		}
+		if err = parsed.Execute(io.Discard, tpl.sample); err != nil {
+			return nil, fmt.Errorf("failed to render %s announcement: %w", tpl.name, err)
+		}
+		*tpl.dest = parsed
+	}
+
+	return compiled, nil
+}
+
+func render(tpl *template.Template, data any) (string, error) {
+	var sb strings.Builder
+	if err := tpl.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("failed to render %s announcement: %w", tpl.Name(), err)
+	}
+	return sb.String(), nil
+}
+
+// AnswerCase is how the text of answers is cased when shown, be it among the
+// choices of a round or as the correct answer, see WithAnswerCase.
+type AnswerCase int
+
+const (
+	// AnswerCaseOriginal shows answers as they were imported.
+	AnswerCaseOriginal AnswerCase = iota
+	// AnswerCaseLower shows answers in lower case.
+	AnswerCaseLower
+	// AnswerCaseTitle capitalizes the first letter of every word of answers,
+	// lowering the rest.
+	AnswerCaseTitle
+)
+
+func (c AnswerCase) String() string {
+	switch c {
+	case AnswerCaseOriginal:
+		return "original"
+	case AnswerCaseLower:
+		return "lower"
+	case AnswerCaseTitle:
+		return "title"
+	default:
+		return fmt.Sprintf("AnswerCase(%d)", int(c))
+	}
+}
+
+// ParseAnswerCase returns the AnswerCase named s.
+func ParseAnswerCase(s string) (AnswerCase, error) {
+	for _, c := range []AnswerCase{AnswerCaseOriginal, AnswerCaseLower, AnswerCaseTitle} {
+		if c.String() == s {
+			return c, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown answer case %q, want original, lower or title", s)
+}
+
+// show returns value as answers are shown, cased and without the quotes some
+// imports wrap answers in.
+func (c AnswerCase) show(value string) string {
+	value = strings.TrimSpace(value)
+	for _, quotes := range []string{`""`, "''", "“”", "‘’"} {
+		open, close := string([]rune(quotes)[0]), string([]rune(quotes)[1])
+		if len(value) > len(open)+len(close) && strings.HasPrefix(value, open) && strings.HasSuffix(value, close) {
+			value = strings.TrimSpace(value[len(open) : len(value)-len(close)])
+			break
+		}
+	}
+
+	switch c {
+	case AnswerCaseLower:
+		return strings.ToLower(value)
+	case AnswerCaseTitle:
+		words := strings.Split(strings.ToLower(value), " ")
+		for i, word := range words {
+			runes := []rune(word)
+			if len(runes) > 0 {
+				runes[0] = unicode.ToUpper(runes[0])
+			}
+			words[i] = string(runes)
+		}
+		return strings.Join(words, " ")
+	default:
+		return value
+	}
+}
+
+// choice returns the answer asked at idx, with value, the way it was shown
+// among the choices, like "`2) Paris`".
+func (c AnswerCase) choice(idx int, value string) string {
+	return fmt.Sprintf("`%d) %s`", idx+1, c.show(value))
+}
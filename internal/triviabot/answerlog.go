@@ -0,0 +1,77 @@
+package triviabot
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/jbpratt/bots/internal/trivia"
+)
+
+// answerLog appends a record of every completed round to a file, one JSON
+// object per line, for finding the questions which are too easy, too hard or
+// have a misleading choice. Records never name the players.
+type answerLog struct {
+	mu   sync.Mutex
+	path string
+}
+
+// answerRecord is what answerLog writes of a round.
+type answerRecord struct {
+	// QuestionID is zero for questions which did not come from the database,
+	// which are told apart by Question instead.
+	QuestionID int64  `json:"question_id"`
+	Question   string `json:"question,omitempty"`
+	// Choices are in the order they were asked, which Correct and Votes
+	// index.
+	Choices []string  `json:"choices"`
+	Correct int       `json:"correct"`
+	Votes   []int     `json:"votes"`
+	WarmUp  bool      `json:"warm_up,omitempty"`
+	EndedAt time.Time `json:"ended_at"`
+}
+
+func newAnswerRecord(round *trivia.Round, at time.Time) answerRecord {
+	record := answerRecord{
+		QuestionID: round.Question.ID,
+		Choices:    []string{},
+		Votes:      make([]int, len(round.Question.Answers)),
+		WarmUp:     round.WarmUp,
+		EndedAt:    at,
+	}
+	if record.QuestionID == 0 {
+		record.Question = round.Question.Question
+	}
+	record.Correct, _ = round.Question.Correct()
+	for _, ans := range round.Question.Answers {
+		record.Choices = append(record.Choices, ans.Value)
+	}
+	for _, p := range round.Answers() {
+		record.Votes[p.Choice]++
+	}
+	return record
+}
+
+// write appends the record of round to the log.
+func (l *answerLog) write(round *trivia.Round) error {
+	data, err := json.Marshal(newAnswerRecord(round, time.Now()))
+	if err != nil {
+		return fmt.Errorf("failed to marshal answers: %w", err)
+	}
+
+	// rooms complete rounds concurrently, keep their lines whole
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open answer log: %w", err)
+	}
+	if _, err = f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write answer log: %w", err)
+	}
+	return f.Close()
+}
@@ -0,0 +1,111 @@
+package triviabot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jbpratt/bots/internal/trivia"
+)
+
+var (
+	// ErrNoRound is returned when delivering an answer while no round it
+	// could be meant for is in progress.
+	ErrNoRound = errors.New("no round in progress")
+	// ErrThrottled is returned when delivering answers from a user faster
+	// than the answer interval allows.
+	ErrThrottled = errors.New("answered too quickly")
+	// ErrStillThrottled is ErrThrottled for the answers after the first of a
+	// run of throttled ones, so a user is only told once.
+	ErrStillThrottled = fmt.Errorf("%w again", ErrThrottled)
+)
+
+// Submission is a player's pick of the answers to the round in progress.
+type Submission struct {
+	User string
+	// Channel is the room the answer was given in, or empty when the input
+	// method can't tell, like whispers. An answer without a channel goes to
+	// the only room playing.
+	Channel string
+	// Choice is the index of the picked answer. A choice of no answer, like
+	// -1 for one which couldn't be read, fails with trivia.ErrInvalidAnswer
+	// but counts towards the answer interval.
+	Choice int
+	// Time is when the answer was given, in Unix milliseconds.
+	Time int64
+	// room is the room the answer was meant for, if the source already knows,
+	// like whispers naming their channel.
+	room *room
+}
+
+// AnswerSource is a way for players to answer, like whispering, clicking a
+// vote or reacting to the question, for platforms which support it. Answers
+// typed in chat, see WithPublicAnswers, are taken by the bot itself.
+type AnswerSource interface {
+	// Listen delivers each answer given until ctx is done. deliver returns
+	// why an answer was not counted, if it wasn't: ErrNoRound, ErrThrottled
+	// or an error of trivia.Round.NewParticipant, so the player can be told.
+	Listen(ctx context.Context, deliver func(Submission) error) error
+}
+
+// listenForAnswers starts listening to every AnswerSource until ctx is done.
+func (t *TriviaBot) listenForAnswers(ctx context.Context) {
+	for _, source := range t.answerSources {
+		go func(source AnswerSource) {
+			err := source.Listen(ctx, t.deliverAnswer)
+			if err != nil && !errors.Is(err, context.Canceled) {
+				t.logger.Errorw("answer source stopped", "error", err)
+			}
+		}(source)
+	}
+}
+
+// deliverAnswer counts sub towards the round it is meant for.
+func (t *TriviaBot) deliverAnswer(sub Submission) error {
+	r := t.submissionRoom(sub)
+	if r == nil {
+		return ErrNoRound
+	}
+
+	round := r.activeRound()
+	if round == nil {
+		return ErrNoRound
+	}
+	if ok, warn := r.throttle.allow(round, sub.User, time.Now()); !ok {
+		if !warn {
+			return ErrStillThrottled
+		}
+		return ErrThrottled
+	}
+	return submitAnswer(round, sub)
+}
+
+// submissionRoom returns the room with a round in progress which sub is
+// meant for, if any.
+func (t *TriviaBot) submissionRoom(sub Submission) *room {
+	if sub.room != nil {
+		if !sub.room.roundInProgress() {
+			return nil
+		}
+		return sub.room
+	}
+	if sub.Channel != "" {
+		r := t.existingRoom(sub.Channel)
+		if r == nil || !r.roundInProgress() {
+			return nil
+		}
+		return r
+	}
+
+	if playing := t.playingRooms(); len(playing) == 1 {
+		return playing[0]
+	}
+	return nil
+}
+
+// submitAnswer counts sub as an answer to round. Public answers are submitted
+// the same way as those of an AnswerSource once parsed.
+func submitAnswer(round *trivia.Round, sub Submission) error {
+	return round.NewParticipant(sub.User, sub.Choice, sub.Time)
+}
@@ -0,0 +1,132 @@
+package triviabot
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+// leaderboardEntry is a player's all time standing served by the API.
+type leaderboardEntry struct {
+	Name        string `json:"name"`
+	Points      int64  `json:"points"`
+	GamesPlayed int64  `json:"gamesPlayed"`
+}
+
+// quizStatus is the state of a room's quiz served by the API. It never
+// includes the correct answer.
+type quizStatus struct {
+	InProgress bool         `json:"inProgress"`
+	Round      *roundStatus `json:"round,omitempty"`
+}
+
+type roundStatus struct {
+	Num   int  `json:"num"`
+	Total int  `json:"total"`
+	Final bool `json:"final"`
+	// InProgress is false between rounds, once the round has been scored.
+	InProgress bool     `json:"inProgress"`
+	Question   string   `json:"question"`
+	Answers    []string `json:"answers"`
+}
+
+// apiHandler serves read only JSON for web overlays. Both endpoints accept a
+// channel query parameter, defaulting to the server's default channel, and
+// answer as if nothing was played in channels the bot hasn't seen.
+func (t *TriviaBot) apiHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/leaderboard", t.handleLeaderboard)
+	mux.HandleFunc("/status", t.handleStatus)
+	return mux
+}
+
+func (t *TriviaBot) handleLeaderboard(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	limit := 10
+	if n := req.URL.Query().Get("n"); n != "" {
+		parsed, err := strconv.Atoi(n)
+		if err != nil || parsed < 1 {
+			http.Error(w, "n must be a positive number", http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+
+	// rooms are only made for channels the bot has seen, never for a
+	// request, so anyone polling can't grow them
+	entries := []leaderboardEntry{}
+	r := t.existingRoom(req.URL.Query().Get("channel"))
+	if r == nil {
+		t.writeJSON(w, entries)
+		return
+	}
+
+	users, err := r.leaderboard.Highscores(limit)
+	if err != nil {
+		t.logger.Errorw("failed to get highscores for the api", "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	for _, user := range users {
+		entries = append(entries, leaderboardEntry{
+			Name:        user.Name,
+			Points:      user.Points,
+			GamesPlayed: user.GamesPlayed,
+		})
+	}
+
+	t.writeJSON(w, entries)
+}
+
+func (t *TriviaBot) handleStatus(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	status := quizStatus{}
+	r := t.existingRoom(req.URL.Query().Get("channel"))
+	if r == nil {
+		t.writeJSON(w, status)
+		return
+	}
+
+	status.InProgress = r.running.Load()
+
+	quiz := r.currentQuiz()
+	if quiz == nil {
+		t.writeJSON(w, status)
+		return
+	}
+
+	// one snapshot, so the round can't move on halfway through
+	state := quiz.State()
+	if state.CurrentRound >= 0 {
+		round := state.Rounds[state.CurrentRound]
+		status.Round = &roundStatus{
+			Num:        round.Num,
+			Total:      state.Size,
+			Final:      round.Final,
+			InProgress: state.InProgress,
+			Question:   round.Question,
+			Answers:    []string{},
+		}
+		for _, ans := range round.Answers {
+			status.Round.Answers = append(status.Round.Answers, ans.Value)
+		}
+	}
+
+	t.writeJSON(w, status)
+}
+
+func (t *TriviaBot) writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		t.logger.Errorw("failed to write api response", "error", err)
+	}
+}
@@ -0,0 +1,61 @@
+package triviabot
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// askedQuestions is the set of question IDs asked since the bot started, so
+// `trivia start -exclude-used` can avoid repeating them within a session, and
+// when each was last asked, for the question cooldown.
+type askedQuestions struct {
+	mu  sync.Mutex
+	ids map[int64]time.Time
+}
+
+// add records id as asked now. Questions which did not come from the
+// database have no ID and are ignored.
+func (a *askedQuestions) add(id int64) {
+	if id == 0 {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.ids == nil {
+		a.ids = map[int64]time.Time{}
+	}
+	a.ids[id] = time.Now()
+}
+
+// list returns the asked IDs in ascending order.
+func (a *askedQuestions) list() []int64 {
+	return a.since(time.Time{})
+}
+
+// since returns the IDs last asked after cutoff in ascending order.
+func (a *askedQuestions) since(cutoff time.Time) []int64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	ids := []int64{}
+	for id, at := range a.ids {
+		if at.After(cutoff) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// reset forgets every asked ID, returning how many there were.
+func (a *askedQuestions) reset() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	n := len(a.ids)
+	a.ids = nil
+	return n
+}
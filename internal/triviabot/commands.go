@@ -0,0 +1,1478 @@
+package triviabot
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/dustin/go-humanize/english"
+	"github.com/jbpratt/bots/internal/bot"
+	"github.com/jbpratt/bots/internal/trivia"
+	"github.com/jbpratt/bots/internal/trivia/models"
+)
+
+// command is a chat command invoked with `trivia <name> [args]`. The help
+// output is generated from the registered commands so it stays in sync.
+type command struct {
+	name        string
+	aliases     []string
+	description string
+	// admin commands may only be run by mods.
+	admin bool
+	// cooldown describes how often the command may be run, if limited.
+	cooldown string
+	// flags returns the command's FlagSet, bound to fresh values, when the
+	// command accepts flags. It is used both to parse and to render usage.
+	flags func() *flag.FlagSet
+	run   func(ctx context.Context, r *room, msg *bot.Msg, args []string) error
+}
+
+func (c *command) matches(name string) bool {
+	if c.name == name {
+		return true
+	}
+	for _, alias := range c.aliases {
+		if alias == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *command) usage() string {
+	usage := "trivia " + c.name
+	if c.flags == nil {
+		return usage
+	}
+
+	c.flags().VisitAll(func(f *flag.Flag) {
+		name, _ := flag.UnquoteUsage(f)
+		if name == "" {
+			usage += fmt.Sprintf(" [-%s]", f.Name)
+		} else {
+			usage += fmt.Sprintf(" [-%s %s]", f.Name, name)
+		}
+	})
+	return usage
+}
+
+func (c *command) help() string {
+	help := fmt.Sprintf("`%s` %s", c.usage(), c.description)
+	if c.admin {
+		help += " (mods only)"
+	}
+	if c.flags == nil {
+		return help
+	}
+
+	details := []string{}
+	c.flags().VisitAll(func(f *flag.Flag) {
+		_, usage := flag.UnquoteUsage(f)
+		detail := fmt.Sprintf("-%s %s", f.Name, usage)
+		if f.DefValue != "" && f.DefValue != "false" && f.DefValue != "0" {
+			detail += fmt.Sprintf(" (default %s)", f.DefValue)
+		}
+		details = append(details, detail)
+	})
+	return help + " " + strings.Join(details, ", ")
+}
+
+// summary names the command along with whether it is for mods, takes flags
+// and has a cooldown, for `trivia commands`.
+func (c *command) summary() string {
+	notes := []string{}
+	if c.admin {
+		notes = append(notes, "mods only")
+	}
+	if c.flags != nil {
+		notes = append(notes, "takes flags")
+	}
+	if c.cooldown != "" {
+		notes = append(notes, c.cooldown)
+	}
+
+	if len(notes) == 0 {
+		return c.name
+	}
+	return fmt.Sprintf("%s (%s)", c.name, strings.Join(notes, ", "))
+}
+
+func (t *TriviaBot) registerCommands() {
+	// the commands starting a quiz share the room's cooldown
+	quizCooldown := ""
+	if t.cooldown > 0 {
+		quizCooldown = fmt.Sprintf("%s between quizzes", t.cooldown)
+	}
+
+	t.commands = []*command{
+		{
+			name:        "commands",
+			description: "Lists every command, marking those for mods, those taking flags and those with a cooldown.",
+			run:         t.runCommands,
+		},
+		{
+			name:        "config",
+			description: "Shows the category and difficulty asked when a quiz is started without them, or sets one with `trivia config category|difficulty <value>` (mods only). `any` clears it. `trivia config show` lists the bot's settings (mods only).",
+			run:         t.runConfig,
+		},
+		{
+			name:        "daily",
+			description: "Starts today's daily challenge, asking everyone the same questions, once a day.",
+			cooldown:    "once a day",
+			run:         t.runDaily,
+		},
+		{
+			name:        "extremes",
+			description: "Whispers the questions answered correctly least and most often.",
+			admin:       true,
+			run:         t.runExtremes,
+		},
+		{
+			name:        "help",
+			aliases:     []string{"info"},
+			description: "Lists commands, or describes the given one.",
+			run:         t.runHelp,
+		},
+		{
+			name:        "history",
+			description: "Shows the recent answers and accuracy of the given user, or yourself.",
+			run:         t.runHistory,
+		},
+		{
+			name:        "leaderboard",
+			aliases:     []string{"highscore"},
+			description: "Links the all time leaderboard.",
+			run: func(ctx context.Context, r *room, msg *bot.Msg, args []string) error {
+				return r.send(t.leaderboardIngress)
+			},
+		},
+		{
+			name:        "lint",
+			description: "Whispers a report of likely broken questions.",
+			admin:       true,
+			run:         t.runLint,
+		},
+		{
+			name:        "merge",
+			description: "Moves a player's points onto their new name with `trivia merge <old> <new>`, after a name change.",
+			admin:       true,
+			run:         t.runMerge,
+		},
+		{
+			name:        "mine",
+			description: "Lists the questions you have submitted and whether each has been asked yet or was removed.",
+			run:         t.runMine,
+		},
+		{
+			name:        "next",
+			description: "Starts the next round of a quiz waiting for the host. Otherwise shows or sets, with `trivia next category <name>`, the category of only the next quiz started without one of its own, for themed events.",
+			admin:       true,
+			run:         t.runNext,
+		},
+		{
+			name:        "odds",
+			description: "Shows what everyone picked in the last round, once it has closed, as a bar chart with `trivia odds chart`.",
+			run:         t.runOdds,
+		},
+		{
+			name:        "pause",
+			description: "Freezes the timer of the round in progress, rejecting answers until `trivia resume`, for breaks during live events.",
+			admin:       true,
+			run:         t.runPause,
+		},
+		{
+			name:        "players",
+			description: "Shows how many players have answered a round of the quiz in progress, or of the last quiz.",
+			run:         t.runPlayers,
+		},
+		{
+			name:        "poll",
+			aliases:     []string{"vote-category"},
+			description: "Lets chat vote on the category of the next quiz by typing its number, then starts it with the flags of `trivia start` in the winning category. No quiz is started if no one votes.",
+			admin:       true,
+			cooldown:    quizCooldown,
+			flags: func() *flag.FlagSet {
+				return newStartFlagSet(&startOptions{})
+			},
+			run: t.runPoll,
+		},
+		{
+			name:        "preview",
+			description: "Whispers the questions and answers of a quiz of the given number of rounds, taking the flags of `trivia start`, without starting it.",
+			admin:       true,
+			flags: func() *flag.FlagSet {
+				return newStartFlagSet(&startOptions{})
+			},
+			run: t.runPreview,
+		},
+		{
+			name:        "quiet",
+			description: "Shows whether quiet mode is on, or turns it on or off with `trivia quiet on|off` (mods only). Quiet mode only sends the questions, answers and results.",
+			run:         t.runQuiet,
+		},
+		{
+			name:        "recap",
+			description: "Lists the questions and answers of the last quiz, with how fast they were answered, once it has ended.",
+			run:         t.runRecap,
+		},
+		{
+			name:        "repeat",
+			description: "Repeats the question and answers of the round in progress, in full.",
+			run:         t.runRepeat,
+		},
+		{
+			name:        "reset-used",
+			description: "Forgets which questions were asked this session, so -exclude-used may ask them again.",
+			admin:       true,
+			run: func(ctx context.Context, r *room, msg *bot.Msg, args []string) error {
+				return r.send(fmt.Sprintf("Forgot %d asked questions", t.asked.reset()))
+			},
+		},
+		{
+			name:        "resume",
+			description: "Continues the round frozen with `trivia pause`, with the time it had left.",
+			admin:       true,
+			run:         t.runResume,
+		},
+		{
+			name:        "skip",
+			description: "Votes to skip the round in progress, which is skipped once enough players have voted or a mod skips it.",
+			run:         t.runSkip,
+		},
+		{
+			name:        "speed",
+			description: "Starts a speed quiz, whose short rounds end at the first correct answer, the only one scored. Takes the flags of `trivia start` but -duration and -early.",
+			cooldown:    quizCooldown,
+			flags: func() *flag.FlagSet {
+				return newStartFlagSet(&startOptions{})
+			},
+			run: t.runSpeed,
+		},
+		{
+			name:        "start",
+			aliases:     []string{"new"},
+			description: "Starts a new quiz.",
+			cooldown:    quizCooldown,
+			flags: func() *flag.FlagSet {
+				return newStartFlagSet(&startOptions{})
+			},
+			run: t.runStart,
+		},
+		{
+			name:        "streaks",
+			aliases:     []string{"streak"},
+			description: "Lists the longest runs of correct answers within a quiz.",
+			run:         t.runStreaks,
+		},
+		{
+			name:        "strengths",
+			aliases:     []string{"weaknesses"},
+			description: "Shows the categories the given user, or yourself, answers most and least accurately.",
+			run:         t.runStrengths,
+		},
+		{
+			name:        "total",
+			aliases:     []string{"totals"},
+			description: "Shows how many points were awarded all time, and to how many players.",
+			run:         t.runTotal,
+		},
+		{
+			name:        "unstick",
+			description: "Cancels the quiz in progress and frees the room for a new one, for when a quiz is stuck.",
+			admin:       true,
+			run:         t.runUnstick,
+		},
+		{
+			name:        "uptime",
+			description: "Shows how long the bot has been running and how many quizzes it has hosted.",
+			run: func(ctx context.Context, r *room, msg *bot.Msg, args []string) error {
+				return r.send(formatUptime(t.startedAt, t.quizzesHosted.Load()))
+			},
+		},
+		{
+			name:        "whoami",
+			description: "Whispers the name and role the bot sees you as, to debug permissions.",
+			run:         t.runWhoami,
+		},
+	}
+}
+
+func (t *TriviaBot) lookupCommand(name string) *command {
+	for _, cmd := range t.commands {
+		if cmd.matches(name) {
+			return cmd
+		}
+	}
+	return nil
+}
+
+// parseCommand splits a `trivia <name> [args]` message into its command name
+// and arguments.
+func parseCommand(data string) (string, []string, bool) {
+	fields := strings.Fields(data)
+	if len(fields) < 2 || (fields[0] != "trivia" && fields[0] != "!trivia") {
+		return "", nil, false
+	}
+	return strings.ToLower(fields[1]), fields[2:], true
+}
+
+func (t *TriviaBot) runHelp(ctx context.Context, r *room, msg *bot.Msg, args []string) error {
+	if len(args) > 0 {
+		cmd := t.lookupCommand(strings.ToLower(args[0]))
+		if cmd == nil {
+			return r.send(fmt.Sprintf("unknown command %q, see `trivia help`", args[0]))
+		}
+		return r.send(cmd.help())
+	}
+
+	names := []string{}
+	for _, cmd := range t.commands {
+		names = append(names, cmd.name)
+	}
+
+	return r.send(fmt.Sprintf(
+		"Commands: %s. Details with `trivia help <command>`. Start a new round with `trivia start`. Whisper me the number beside the answer `/w trivia 2`, or true/false for true or false questions.",
+		strings.Join(names, ", "),
+	))
+}
+
+func (t *TriviaBot) runCommands(ctx context.Context, r *room, msg *bot.Msg, args []string) error {
+	entries := []string{}
+	for _, cmd := range t.commands {
+		entries = append(entries, cmd.summary())
+	}
+	entries[0] = "Commands: " + entries[0]
+	return r.sendAll(entries, ", ")
+}
+
+type startOptions struct {
+	duration    time.Duration
+	size        int
+	force       bool
+	endEarly    int
+	warmUp      bool
+	double      int
+	excludeUsed bool
+	balanced    bool
+	seed        int64
+	// daily quizzes ask the same questions in every channel, ignoring the
+	// channel defaults.
+	daily bool
+	// preview quizzes are only whispered to the host, not played.
+	preview bool
+	filter  trivia.Filter
+}
+
+// defaultCategory reports whether the quiz asks the room's default category,
+// or the next category overriding it, not having picked one of its own.
+func (o *startOptions) defaultCategory() bool {
+	// balanced quizzes pick their own categories
+	return o.filter.Category == "" && !o.balanced && !o.daily
+}
+
+func newStartFlagSet(opts *startOptions) *flag.FlagSet {
+	fs := flag.NewFlagSet("start", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.DurationVar(&opts.duration, "duration", 30*time.Second, "time to answer each round")
+	fs.IntVar(&opts.size, "size", 3, "`number` of rounds")
+	fs.StringVar(&opts.filter.Category, "category", "", "only ask questions from this `category`")
+	fs.StringVar(&opts.filter.Difficulty, "difficulty", "", "only ask questions of this `difficulty` (easy, medium or hard)")
+	fs.IntVar(&opts.endEarly, "early", 0, "end each round once this `number` of players answered correctly")
+	fs.BoolVar(&opts.warmUp, "warmup", false, "play an example round for no points first")
+	fs.IntVar(&opts.double, "double", 0, "make round `number` worth double points")
+	fs.BoolVar(&opts.excludeUsed, "exclude-used", false, "skip questions already asked since the bot started")
+	fs.BoolVar(&opts.balanced, "balanced", false, "ask each round from a different category")
+	fs.Int64Var(&opts.seed, "seed", 0, "ask the same questions, in the same order, as every quiz with this `number`")
+	fs.BoolVar(&opts.force, "force", false, "skip the cooldown (mods only)")
+	return fs
+}
+
+// checkStartOptions returns what is wrong with opts, if anything.
+func (t *TriviaBot) checkStartOptions(opts *startOptions) string {
+	if opts.size < 1 {
+		return "a quiz needs at least one round"
+	}
+
+	if (t.minRoundDuration > 0 && opts.duration < t.minRoundDuration) ||
+		(t.maxRoundDuration > 0 && opts.duration > t.maxRoundDuration) {
+		return fmt.Sprintf("-duration must be between %s and %s", t.minRoundDuration, t.maxRoundDuration)
+	}
+
+	if opts.double < 0 || opts.double > opts.size {
+		return fmt.Sprintf("-double must be a round from 1 to %d", opts.size)
+	}
+
+	if opts.endEarly < 0 {
+		return "-early cannot be negative"
+	}
+
+	if opts.balanced && opts.filter.Category != "" {
+		return "-balanced cannot be combined with -category"
+	}
+
+	if opts.balanced && opts.seed != 0 {
+		return "-balanced cannot be combined with -seed"
+	}
+
+	return ""
+}
+
+// newQuiz builds the quiz described by opts, asking the room's default
+// category and difficulty unless opts picks its own. If not nil, next replaces
+// the default category, see `trivia next category`. When no quiz can be built
+// for opts, the reason is returned to be told to the user instead.
+func (t *TriviaBot) newQuiz(r *room, opts *startOptions, next *string) (*trivia.Quiz, string, error) {
+	t.sourceMu.Lock()
+	defer t.sourceMu.Unlock()
+
+	defaults, err := t.channelDefaults(r)
+	if err != nil {
+		return nil, "", err
+	}
+	if opts.defaultCategory() {
+		opts.filter.Category = defaults.Category
+		if next != nil {
+			opts.filter.Category = *next
+		}
+	}
+	if opts.filter.Difficulty == "" && !opts.daily {
+		opts.filter.Difficulty = defaults.Difficulty
+	}
+
+	if opts.excludeUsed {
+		opts.filter.Exclude = t.asked.list()
+	} else if t.questionCooldown > 0 && opts.seed == 0 {
+		// daily challenges ask the same questions everywhere, and sources
+		// which can't exclude questions go without the cooldown
+		if _, ok := t.source.(trivia.FilterableSource); ok {
+			opts.filter.Exclude = t.asked.since(time.Now().Add(-t.questionCooldown))
+		}
+	}
+
+	source := t.source
+	if opts.seed != 0 {
+		seedable, ok := t.source.(trivia.SeedableSource)
+		if !ok {
+			return nil, "the question source does not support -seed or daily challenges", nil
+		}
+
+		if source, err = seedable.Seeded(opts.filter, opts.seed); err != nil {
+			if errors.Is(err, trivia.ErrNoQuestions) {
+				return nil, fmt.Sprintf("no questions found for %s", opts.filter), nil
+			}
+			return nil, "", err
+		}
+	} else if !opts.filter.IsZero() || opts.balanced {
+		filterable, ok := t.source.(trivia.FilterableSource)
+		if !ok {
+			return nil, "the question source does not support -category, -difficulty, -exclude-used or -balanced", nil
+		}
+
+		if opts.balanced {
+			if source, err = trivia.NewBalancedSource(filterable, opts.filter); err != nil {
+				return nil, "", err
+			}
+		} else {
+			source = filterable.Filtered(opts.filter)
+		}
+	} else if filterable, ok := t.source.(trivia.FilterableSource); ok && opts.preview {
+		// drawn from a source of its own, a preview leaves the questions of
+		// the shared sequence to be asked
+		source = filterable.Filtered(opts.filter)
+	}
+
+	quiz, err := trivia.NewQuizWithOptions(t.logger, source, trivia.QuizOptions{
+		Size:           opts.size,
+		Duration:       opts.duration,
+		EndEarly:       opts.endEarly,
+		WarmUp:         opts.warmUp,
+		AllCorrect:     t.allCorrect,
+		Ties:           t.ties,
+		ChangeAnswers:  t.changeAnswers,
+		DoubleRound:    opts.double,
+		DoubleChance:   t.doubleChance,
+		Burst:          t.burst,
+		Seed:           opts.seed,
+		Grace:          t.grace,
+		LatePoints:     t.latePoints,
+		Distinct:       t.distinctQuestions,
+		RequeueSkipped: t.requeueSkipped,
+	})
+	if err != nil {
+		if errors.Is(err, trivia.ErrNoQuestions) {
+			return nil, fmt.Sprintf("no questions found for %s", opts.filter), nil
+		}
+		return nil, "", fmt.Errorf("failed to create a new quiz: %w", err)
+	}
+
+	return quiz, "", nil
+}
+
+func (t *TriviaBot) runStart(ctx context.Context, r *room, msg *bot.Msg, args []string) error {
+	opts := &startOptions{}
+	if err := newStartFlagSet(opts).Parse(args); err != nil {
+		return r.send(fmt.Sprintf("invalid flags: %v, see `trivia help start`", err))
+	}
+
+	if problem := t.checkStartOptions(opts); problem != "" {
+		return r.send(problem)
+	}
+
+	_, err := t.startQuiz(ctx, r, msg, opts)
+	return err
+}
+
+// startQuiz starts the quiz described by opts in the room, reporting whether
+// it was launched or msg's sender was told why not.
+func (t *TriviaBot) startQuiz(ctx context.Context, r *room, msg *bot.Msg, opts *startOptions) (bool, error) {
+	if opts.force && !t.isAdmin(msg) {
+		return false, r.send("only mods can skip the cooldown")
+	}
+
+	// claim the room before anything else so simultaneous starts can't both
+	// launch a quiz
+	claim, problem := t.claimRoom(r)
+	if problem != "" {
+		return false, r.send(problem)
+	}
+	launched := false
+	defer func() {
+		if !launched {
+			t.releaseRoom(r, claim)
+		}
+	}()
+
+	if cooldown, err := t.checkCooldown(r, msg, opts); err != nil || cooldown != "" {
+		if err != nil {
+			return false, err
+		}
+		return false, r.send(cooldown)
+	}
+
+	// only a quiz started with the default category uses up the next one
+	var next *string
+	if opts.defaultCategory() {
+		next = r.nextCategory.Swap(nil)
+	}
+	quiz, problem, err := t.newQuiz(r, opts, next)
+	if err != nil {
+		return false, err
+	}
+	if problem != "" {
+		return false, r.send(problem)
+	}
+	r.setQuiz(quiz)
+
+	launched = true
+	t.launch(ctx, r, claim, func(ctx context.Context) error {
+		return t.runQuiz(ctx, r, msg.User)
+	})
+
+	return true, nil
+}
+
+func (t *TriviaBot) runSpeed(ctx context.Context, r *room, msg *bot.Msg, args []string) error {
+	opts := &startOptions{}
+	fs := newStartFlagSet(opts)
+	if err := fs.Parse(args); err != nil {
+		return r.send(fmt.Sprintf("invalid flags: %v, see `trivia help speed`", err))
+	}
+
+	preset := false
+	fs.Visit(func(f *flag.Flag) {
+		preset = preset || f.Name == "duration" || f.Name == "early"
+	})
+	if preset {
+		return r.send("speed quizzes set their own -duration and -early")
+	}
+	// ending each round at the first correct answer leaves it the only winner
+	opts.duration, opts.endEarly = t.speedDuration, 1
+
+	if problem := t.checkStartOptions(opts); problem != "" {
+		return r.send(problem)
+	}
+
+	_, err := t.startQuiz(ctx, r, msg, opts)
+	return err
+}
+
+func (t *TriviaBot) runDaily(ctx context.Context, r *room, msg *bot.Msg, args []string) error {
+	r.dailyMu.Lock()
+	defer r.dailyMu.Unlock()
+
+	now := time.Now()
+	today := now.UTC().Format(time.DateOnly)
+	if r.lastDaily == today {
+		return r.send("today's daily challenge has already been played, come back tomorrow")
+	}
+
+	// every daily challenge is played the same way so scores compare
+	opts := &startOptions{}
+	if err := newStartFlagSet(opts).Parse(nil); err != nil {
+		return err
+	}
+	opts.seed = trivia.DailySeed(now)
+	opts.daily = true
+
+	launched, err := t.startQuiz(ctx, r, msg, opts)
+	if launched {
+		r.lastDaily = today
+	}
+	return err
+}
+
+// checkCooldown returns the message telling msg's sender to wait, if the
+// room's cooldown hasn't passed and they can't skip it.
+func (t *TriviaBot) checkCooldown(r *room, msg *bot.Msg, opts *startOptions) (string, error) {
+	cooldownStart := time.Now().Add(-t.cooldown)
+	if opts.force || !r.lastQuizEndedAt.After(cooldownStart) {
+		return "", nil
+	}
+	if t.bypassesCooldown(msg) {
+		r.logger.Infow("skipping the cooldown for a privileged user", "user", msg.User)
+		return "", nil
+	}
+
+	timeLeft := r.lastQuizEndedAt.Sub(cooldownStart).Round(time.Second)
+	return render(t.announce.cooldown, cooldownData{TimeLeft: timeLeft, Emote: t.emotes.Cooldown})
+}
+
+// claimRoom marks the room as running a quiz, returning the claim to release
+// it with, or why it can't if it already is or the bot is running its maximum
+// number of quizzes.
+func (t *TriviaBot) claimRoom(r *room) (uint64, string) {
+	r.claimMu.Lock()
+	defer r.claimMu.Unlock()
+
+	if r.running.Load() {
+		return 0, "a quiz is already in progress"
+	}
+	if running := t.runningQuizzes.Add(1); t.maxQuizzes > 0 && running > int32(t.maxQuizzes) {
+		t.runningQuizzes.Add(-1)
+		r.logger.Infow("too many quizzes running", "max", t.maxQuizzes)
+		return 0, fmt.Sprintf("%d quizzes are already running, try again once one ends", t.maxQuizzes)
+	}
+	r.claim++
+	r.running.Store(true)
+	return r.claim, ""
+}
+
+// releaseRoom frees the room if claim, made by claimRoom, still holds it.
+// A quiz stopped by `trivia unstick` which returns after the next was
+// started so leaves the room to the next, and releasing a claim twice, as
+// `trivia unstick` and the quiz it stopped both do, counts once.
+func (t *TriviaBot) releaseRoom(r *room, claim uint64) {
+	r.claimMu.Lock()
+	defer r.claimMu.Unlock()
+
+	if r.claim == claim && r.running.Load() {
+		r.running.Store(false)
+		t.runningQuizzes.Add(-1)
+	}
+}
+
+// currentClaim returns the latest claim made on the room.
+func (r *room) currentClaim() uint64 {
+	r.claimMu.Lock()
+	defer r.claimMu.Unlock()
+	return r.claim
+}
+
+// launch runs play in the background on the room claimed by the caller with
+// claim, releasing it once play returns. Closing the bot cancels play's ctx.
+func (t *TriviaBot) launch(ctx context.Context, r *room, claim uint64, play func(ctx context.Context) error) {
+	ctx, cancel := context.WithCancel(ctx)
+	r.setCancel(cancel)
+
+	t.quizzes.Add(1)
+	go func() {
+		defer t.quizzes.Done()
+		defer t.releaseRoom(r, claim)
+		defer cancel()
+		if err := play(ctx); err != nil {
+			t.logger.Fatalf("failed while running the quiz: %v", err)
+		}
+	}()
+}
+
+// channelDefaults returns the filter of the questions asked in the room when
+// a quiz is started without one, falling back on the bot's defaults.
+func (t *TriviaBot) channelDefaults(r *room) (trivia.Filter, error) {
+	defaults, err := r.leaderboard.DefaultFilter()
+	if err != nil {
+		return trivia.Filter{}, fmt.Errorf("failed to get channel defaults: %w", err)
+	}
+	if defaults.Category == "" {
+		defaults.Category = t.defaultFilter.Category
+	}
+	if defaults.Difficulty == "" {
+		defaults.Difficulty = t.defaultFilter.Difficulty
+	}
+	return defaults, nil
+}
+
+func (t *TriviaBot) runConfig(ctx context.Context, r *room, msg *bot.Msg, args []string) error {
+	if len(args) == 0 {
+		defaults, err := t.channelDefaults(r)
+		if err != nil {
+			return err
+		}
+		return r.send(fmt.Sprintf("Quizzes ask %s by default", defaults))
+	}
+	if strings.EqualFold(args[0], "show") {
+		if !t.isAdmin(msg) {
+			return r.send("only mods can see the settings")
+		}
+		settings, err := t.formatSettings(r)
+		if err != nil {
+			return err
+		}
+		return r.send(settings)
+	}
+
+	filter, err := r.leaderboard.DefaultFilter()
+	if err != nil {
+		return fmt.Errorf("failed to get channel defaults: %w", err)
+	}
+	if len(args) < 2 {
+		return r.send("usage: `trivia config category|difficulty <value>`")
+	}
+	if !t.isAdmin(msg) {
+		return r.send("only mods can change the config")
+	}
+
+	filterable, ok := t.source.(trivia.FilterableSource)
+	if !ok {
+		return r.send("the question source does not support categories or difficulties")
+	}
+
+	setting, value := strings.ToLower(args[0]), strings.Join(args[1:], " ")
+	var (
+		values []string
+		field  *string
+	)
+	switch setting {
+	case "category":
+		values, err = filterable.Categories()
+		field = &filter.Category
+	case "difficulty":
+		values, err = filterable.Difficulties()
+		field = &filter.Difficulty
+	default:
+		return r.send(fmt.Sprintf("unknown setting %q, see `trivia help config`", args[0]))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to list %s values: %w", setting, err)
+	}
+
+	// any clears the setting
+	*field = ""
+	if !strings.EqualFold(value, "any") {
+		for _, v := range values {
+			if strings.EqualFold(v, value) {
+				*field = v
+				break
+			}
+		}
+		if *field == "" {
+			return r.send(fmt.Sprintf("unknown %s %q, pick one of: %s", setting, value, strings.Join(values, ", ")))
+		}
+	}
+
+	if err = r.leaderboard.SetDefaultFilter(filter); err != nil {
+		return fmt.Errorf("failed to set channel defaults: %w", err)
+	}
+
+	return r.send(fmt.Sprintf("Quizzes now ask %s by default", filter))
+}
+
+// formatSettings describes the settings the bot is running with in the room,
+// for operators to check. Credentials, like the chat token, are never kept by
+// the bot so can't be shown.
+func (t *TriviaBot) formatSettings(r *room) (string, error) {
+	defaults, err := t.channelDefaults(r)
+	if err != nil {
+		return "", err
+	}
+
+	rounds := "any duration"
+	if t.minRoundDuration > 0 || t.maxRoundDuration > 0 {
+		rounds = fmt.Sprintf("%s to %s", durationOr(t.minRoundDuration, "0s"), durationOr(t.maxRoundDuration, "unlimited"))
+	}
+	scoring := trivia.QuizOptions{
+		AllCorrect:   t.allCorrect,
+		Ties:         t.ties,
+		DoubleChance: t.doubleChance,
+		Grace:        t.grace,
+		LatePoints:   t.latePoints,
+	}.ScoringBreakdown()
+
+	return fmt.Sprintf(
+		"Cooldown %s, question cooldown %s, rounds %s, max quiz length %s, asking %s by default. Scoring: %s. %s, %s. Quiet mode %s, manual advance %s, public answers %s",
+		t.cooldown, durationOr(t.questionCooldown, "off"), rounds, durationOr(t.maxQuizDuration, "unlimited"), defaults, scoring,
+		english.Plural(len(t.admins), "admin", ""), english.Plural(len(t.judges), "judge", ""),
+		onOff(r.quiet.Load()), onOff(t.manualAdvance), onOff(t.publicAnswers),
+	), nil
+}
+
+// durationOr formats d, or returns zero if d is zero.
+func durationOr(d time.Duration, zero string) string {
+	if d <= 0 {
+		return zero
+	}
+	return d.String()
+}
+
+// onOff formats on as the state of a setting.
+func onOff(on bool) string {
+	if on {
+		return "on"
+	}
+	return "off"
+}
+
+// isAdmin reports whether the sender of msg may run the commands reserved for
+// mods.
+func (t *TriviaBot) isAdmin(msg *bot.Msg) bool {
+	if msg.IsMod() {
+		return true
+	}
+	for _, user := range t.admins {
+		if strings.EqualFold(user, msg.User) {
+			return true
+		}
+	}
+	return false
+}
+
+// bypassesCooldown reports whether the sender of msg may start a quiz during
+// the cooldown without -force.
+func (t *TriviaBot) bypassesCooldown(msg *bot.Msg) bool {
+	if t.cooldownBypassMods && t.isAdmin(msg) {
+		return true
+	}
+	for _, user := range t.cooldownBypassUsers {
+		if strings.EqualFold(user, msg.User) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *TriviaBot) runOdds(ctx context.Context, r *room, msg *bot.Msg, args []string) error {
+	quiz := r.currentQuiz()
+	if quiz == nil {
+		return r.send("no round has been played yet")
+	}
+	// one snapshot, so the round can't close halfway through
+	state := quiz.State()
+	if state.CurrentRound < 0 {
+		return r.send("no round has been played yet")
+	}
+
+	round := state.Rounds[state.CurrentRound]
+	if !round.Complete {
+		return r.send("the odds are revealed once the round closes")
+	}
+	// with few answers, the odds would give away who picked what
+	if answered := len(round.Participants); answered < t.minOddsAnswers {
+		return r.send(fmt.Sprintf("the odds are only revealed once at least %d players answer, %d did", t.minOddsAnswers, answered))
+	}
+
+	if len(args) > 0 {
+		if !strings.EqualFold(args[0], "chart") {
+			return r.send("usage: `trivia odds [chart]`")
+		}
+		return r.send(formatOddsChart(round, t.answerCase))
+	}
+	return r.send(formatOdds(round, t.answerCase))
+}
+
+// chartWidth is the number of blocks of each bar of `trivia odds chart`.
+const chartWidth = 10
+
+// formatOddsChart draws the share of participants who picked each answer of
+// round as a bar of blocks, so it can be read at a glance on stream.
+func formatOddsChart(round trivia.RoundState, casing AnswerCase) string {
+	total := len(round.Participants)
+	if total == 0 {
+		return fmt.Sprintf("No one answered round %d", round.Num)
+	}
+
+	bars := []string{}
+	for idx := range round.Answers {
+		votes := 0
+		if idx < len(round.Votes) {
+			votes = round.Votes[idx]
+		}
+		choice := casing.choice(idx, round.Answers[idx].Value)
+		bars = append(bars, fmt.Sprintf("%s %s %d%%", choice, bar(votes, total), votes*100/total))
+	}
+
+	return fmt.Sprintf("Round %d: %s", round.Num, strings.Join(bars, " "))
+}
+
+// bar draws count out of total as chartWidth blocks, filled in proportion,
+// rounding to the nearest block.
+func bar(count, total int) string {
+	filled := int(math.Round(float64(count) * chartWidth / float64(total)))
+	return strings.Repeat("█", filled) + strings.Repeat("░", chartWidth-filled)
+}
+
+// formatOdds describes how many participants picked each answer of round.
+func formatOdds(round trivia.RoundState, casing AnswerCase) string {
+	total := len(round.Participants)
+	if total == 0 {
+		return fmt.Sprintf("No one answered round %d", round.Num)
+	}
+
+	most := 0
+	entries := []string{}
+	for idx, ans := range round.Answers {
+		votes := 0
+		if idx < len(round.Votes) {
+			votes = round.Votes[idx]
+		}
+		if votes > round.Votes[most] {
+			most = idx
+		}
+		entries = append(entries, fmt.Sprintf("%s %d%%", casing.choice(idx, ans.Value), votes*100/total))
+	}
+
+	return fmt.Sprintf(
+		"Most picked: %s (%d%%). Round %d: %s",
+		casing.choice(most, round.Answers[most].Value), round.Votes[most]*100/total,
+		round.Num, strings.Join(entries, ", "),
+	)
+}
+
+func (t *TriviaBot) runPlayers(ctx context.Context, r *room, msg *bot.Msg, args []string) error {
+	quiz := r.currentQuiz()
+	if quiz == nil {
+		return r.send("no quiz has been played yet")
+	}
+
+	players := english.Plural(quiz.Players(), "player", "")
+	if r.running.Load() {
+		return r.send(fmt.Sprintf("%s answered so far", players))
+	}
+	return r.send(fmt.Sprintf("%s answered the last quiz", players))
+}
+
+func (t *TriviaBot) runRecap(ctx context.Context, r *room, msg *bot.Msg, args []string) error {
+	if r.running.Load() || r.roundInProgress() {
+		return r.send("the recap is available once the quiz ends")
+	}
+
+	var state *trivia.QuizState
+	if quiz := r.currentQuiz(); quiz != nil {
+		current := quiz.State()
+		state = &current
+	} else if t.archiveQuizzes {
+		var err error
+		if state, err = r.leaderboard.LastQuiz(); err != nil {
+			return err
+		}
+	}
+	if state == nil {
+		return r.send("no quiz has been played yet")
+	}
+
+	entries := formatRecap(*state, t.answerCase)
+	if len(entries) == 0 {
+		return r.send("no rounds of the last quiz were completed")
+	}
+
+	return r.sendAll(entries, " | ")
+}
+
+// formatRecap describes each completed round of quiz, with its answer
+// numbered as it was shown and the average time taken to answer it, leaving
+// out rounds a timed out quiz never played.
+func formatRecap(quiz trivia.QuizState, casing AnswerCase) []string {
+	entries := []string{}
+	for _, round := range quiz.Rounds {
+		if !round.Complete {
+			continue
+		}
+
+		label := fmt.Sprintf("Round %d", round.Num)
+		if round.WarmUp {
+			label = "Warm-up"
+		}
+		answer := "`none`"
+		for idx, ans := range round.Answers {
+			if ans.Correct {
+				answer = casing.choice(idx, ans.Value)
+			}
+		}
+		entry := fmt.Sprintf("%s: %s %s", label, round.Question, answer)
+
+		if avg, ok := round.AverageTime(); ok {
+			entry += fmt.Sprintf(" (answered in %s on average)", avg.Round(100*time.Millisecond))
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// formatRoundAnswer describes the question of round along with its answer.
+func formatRoundAnswer(round *trivia.Round, casing AnswerCase) string {
+	label := fmt.Sprintf("Round %d", round.Num)
+	if round.WarmUp {
+		label = "Warm-up"
+	}
+	answer := "none"
+	if _, ans := round.Question.Correct(); ans != nil {
+		answer = casing.show(ans.Value)
+	}
+	return fmt.Sprintf("%s: %s `%s`", label, round.Question.Question, answer)
+}
+
+func (t *TriviaBot) runMerge(ctx context.Context, r *room, msg *bot.Msg, args []string) error {
+	if len(args) != 2 {
+		return r.send("usage: `trivia merge <old> <new>`")
+	}
+	old, target := args[0], args[1]
+	if old == target {
+		return r.send("cannot merge a player into themselves")
+	}
+
+	if err := r.leaderboard.Merge(old, target); err != nil {
+		if errors.Is(err, trivia.ErrNotOnLeaderboard) {
+			return r.send(err.Error())
+		}
+		return err
+	}
+
+	// the published page shows the default channel's leaderboard
+	if r.channel == "" {
+		if err := t.generateLeaderboardPage(); err != nil {
+			return fmt.Errorf("failed to generate leaderboard: %w", err)
+		}
+	}
+
+	return r.send(fmt.Sprintf("Merged %s into %s", old, target))
+}
+
+func (t *TriviaBot) runRepeat(ctx context.Context, r *room, msg *bot.Msg, args []string) error {
+	quiz := r.currentQuiz()
+	if quiz == nil || !quiz.InProgress() {
+		return r.send("no round is in progress")
+	}
+
+	output, err := t.formatRound(r, quiz.CurrentRound(), 0)
+	if err != nil {
+		return err
+	}
+	return r.send(output)
+}
+
+func (t *TriviaBot) runNext(ctx context.Context, r *room, msg *bot.Msg, args []string) error {
+	if len(args) == 0 {
+		if r.advanceRound() {
+			r.logger.Infow("next round started by the host", "user", msg.User)
+			return nil
+		}
+		next := r.nextCategory.Load()
+		if next == nil {
+			return r.send("The next quiz asks the default category")
+		}
+		return r.send(fmt.Sprintf("The next quiz asks %s", trivia.Filter{Category: *next}))
+	}
+	if len(args) < 2 || !strings.EqualFold(args[0], "category") {
+		return r.send("usage: `trivia next category <name>`")
+	}
+
+	filterable, ok := t.source.(trivia.FilterableSource)
+	if !ok {
+		return r.send("the question source does not support categories")
+	}
+	categories, err := filterable.Categories()
+	if err != nil {
+		return fmt.Errorf("failed to list categories: %w", err)
+	}
+
+	// any asks every category, whatever the default
+	value, category := strings.Join(args[1:], " "), ""
+	if !strings.EqualFold(value, "any") {
+		for _, c := range categories {
+			if strings.EqualFold(c, value) {
+				category = c
+				break
+			}
+		}
+		if category == "" {
+			return r.send(fmt.Sprintf("unknown category %q, pick one of: %s", value, strings.Join(categories, ", ")))
+		}
+	}
+
+	r.nextCategory.Store(&category)
+	r.logger.Infow("next category set", "user", msg.User, "category", category)
+	return r.send(fmt.Sprintf("The next quiz asks %s, then the default again", trivia.Filter{Category: category}))
+}
+
+// runUnstick recovers a room whose quiz never finished, which would otherwise
+// refuse new quizzes until the bot restarts.
+func (t *TriviaBot) runUnstick(ctx context.Context, r *room, msg *bot.Msg, args []string) error {
+	if !r.running.Load() && !r.roundInProgress() {
+		return r.send("no quiz is in progress")
+	}
+
+	r.cancelQuiz()
+	if quiz := r.currentQuiz(); quiz != nil {
+		quiz.Reset()
+	}
+	r.poll.Store(nil)
+	t.releaseRoom(r, r.currentClaim())
+
+	r.logger.Warnw("quiz unstuck", "user", msg.User)
+	return r.send("The quiz was reset, a new one may be started")
+}
+
+func (t *TriviaBot) runPause(ctx context.Context, r *room, msg *bot.Msg, args []string) error {
+	if !r.roundInProgress() {
+		return r.send("no round is in progress")
+	}
+	if err := r.currentQuiz().Pause(); err != nil {
+		return r.send(fmt.Sprintf("the round cannot be paused: %v", err))
+	}
+
+	r.logger.Infow("round paused", "user", msg.User)
+	return r.send("The quiz is paused, answers are not accepted until it resumes")
+}
+
+func (t *TriviaBot) runResume(ctx context.Context, r *room, msg *bot.Msg, args []string) error {
+	if !r.roundInProgress() {
+		return r.send("no round is in progress")
+	}
+	left, err := r.currentQuiz().Resume()
+	if err != nil {
+		return r.send(fmt.Sprintf("the round cannot be resumed: %v", err))
+	}
+
+	r.logger.Infow("round resumed", "user", msg.User, "left", left)
+	return r.send(fmt.Sprintf("The quiz resumes, %s left to answer!", left.Round(time.Second)))
+}
+
+// runSkip counts msg.User's vote to skip the round in progress, skipping it
+// once t.skipVotes players have voted, or at once for admins.
+func (t *TriviaBot) runSkip(ctx context.Context, r *room, msg *bot.Msg, args []string) error {
+	quiz := r.currentQuiz()
+	if quiz == nil || !quiz.InProgress() {
+		return r.send("no round is in progress")
+	}
+	round := quiz.CurrentRound()
+
+	votes := round.VoteSkip(msg.User)
+	if votes == 0 {
+		return r.send("the round has already closed")
+	}
+	if votes < t.skipVotes && !t.isAdmin(msg) {
+		return r.sendNonEssential(fmt.Sprintf("%d of %d votes to skip the round", votes, t.skipVotes))
+	}
+
+	if !round.Skip() {
+		return r.send("the round has already closed")
+	}
+	r.logger.Infow("round skipped", "user", msg.User, "votes", votes)
+	return nil
+}
+
+func (t *TriviaBot) runQuiet(ctx context.Context, r *room, msg *bot.Msg, args []string) error {
+	if len(args) == 0 {
+		if r.quiet.Load() {
+			return r.send("Quiet mode is on")
+		}
+		return r.send("Quiet mode is off")
+	}
+	if !t.isAdmin(msg) {
+		return r.send("only mods can change quiet mode")
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "on":
+		r.quiet.Store(true)
+		return r.send("Quiet mode is now on, only the questions, answers and results will be sent")
+	case "off":
+		r.quiet.Store(false)
+		return r.send("Quiet mode is now off")
+	default:
+		return r.send("usage: `trivia quiet on|off`")
+	}
+}
+
+func (t *TriviaBot) runPreview(ctx context.Context, r *room, msg *bot.Msg, args []string) error {
+	// the number of rounds may come before or after the flags
+	size := 0
+	if len(args) > 0 {
+		if n, err := strconv.Atoi(args[0]); err == nil {
+			size, args = n, args[1:]
+		}
+	}
+
+	opts := &startOptions{}
+	fs := newStartFlagSet(opts)
+	if err := fs.Parse(args); err != nil {
+		return t.bot.SendPriv(fmt.Sprintf("invalid flags: %v, see `trivia help preview`", err), msg.User)
+	}
+	if size == 0 && fs.NArg() > 0 {
+		n, err := strconv.Atoi(fs.Arg(0))
+		if err != nil {
+			return t.bot.SendPriv(fmt.Sprintf("invalid number of rounds %q", fs.Arg(0)), msg.User)
+		}
+		size = n
+	}
+	if size != 0 {
+		opts.size = size
+	}
+	opts.preview = true
+
+	if problem := t.checkStartOptions(opts); problem != "" {
+		return t.bot.SendPriv(problem, msg.User)
+	}
+
+	// previewed as the next quiz would be asked, leaving it to that quiz
+	quiz, problem, err := t.newQuiz(r, opts, r.nextCategory.Load())
+	if err != nil {
+		return err
+	}
+	if problem != "" {
+		return t.bot.SendPriv(problem, msg.User)
+	}
+
+	entries := []string{}
+	for _, round := range quiz.Rounds {
+		entries = append(entries, formatRoundAnswer(round, t.answerCase))
+	}
+	for _, pm := range splitMessages(entries, " | ", maxMessageLength) {
+		if err = t.bot.SendPriv(pm, msg.User); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *TriviaBot) runHistory(ctx context.Context, r *room, msg *bot.Msg, args []string) error {
+	name := msg.User
+	if len(args) > 0 {
+		name = args[0]
+	}
+
+	history, err := r.leaderboard.History(name, 5)
+	if err != nil {
+		return fmt.Errorf("failed to get history: %w", err)
+	}
+
+	return r.send(formatHistory(history))
+}
+
+// formatHistory summarises a player's history on a single chat line.
+func formatHistory(history *trivia.History) string {
+	if history.Answered == 0 {
+		return fmt.Sprintf("%s has not answered any questions yet", history.Name)
+	}
+
+	recent := []string{}
+	for _, answer := range history.Recent {
+		if answer.Correct {
+			recent = append(recent, "✓")
+		} else {
+			recent = append(recent, "✗")
+		}
+	}
+
+	return fmt.Sprintf(
+		"%s: %d/%d correct (%.0f%%), last %d: %s",
+		history.Name, history.Correct, history.Answered, history.Accuracy(),
+		len(recent), strings.Join(recent, " "),
+	)
+}
+
+// strengthsShown is the most categories listed as strengths, and as
+// weaknesses, by `trivia strengths`, each counting only if answered at least
+// minCategoryAnswers times.
+const (
+	strengthsShown     = 3
+	minCategoryAnswers = 3
+)
+
+func (t *TriviaBot) runStrengths(ctx context.Context, r *room, msg *bot.Msg, args []string) error {
+	name := msg.User
+	if len(args) > 0 {
+		name = args[0]
+	}
+
+	records, err := r.leaderboard.Strengths(name, minCategoryAnswers)
+	if err != nil {
+		return fmt.Errorf("failed to get strengths: %w", err)
+	}
+
+	return r.send(formatStrengths(name, records))
+}
+
+// formatStrengths lists the best and worst of records, ranked most accurate
+// first, never listing a category as both.
+func formatStrengths(name string, records []*trivia.CategoryRecord) string {
+	if len(records) == 0 {
+		return fmt.Sprintf("%s has not answered enough questions of any category yet", name)
+	}
+
+	best := records[:min(strengthsShown, (len(records)+1)/2)]
+	worst := []*trivia.CategoryRecord{}
+	for i := len(records) - 1; i >= max(len(best), len(records)-strengthsShown); i-- {
+		worst = append(worst, records[i])
+	}
+
+	output := fmt.Sprintf("%s is best at %s", name, formatCategoryRecords(best))
+	if len(worst) > 0 {
+		output += fmt.Sprintf("; worst at %s", formatCategoryRecords(worst))
+	}
+	return output
+}
+
+func formatCategoryRecords(records []*trivia.CategoryRecord) string {
+	entries := []string{}
+	for _, record := range records {
+		entries = append(entries, fmt.Sprintf("%s (%.0f%% of %d)", record.Category, record.Accuracy(), record.Answered))
+	}
+	return strings.Join(entries, ", ")
+}
+
+func (t *TriviaBot) runMine(ctx context.Context, r *room, msg *bot.Msg, args []string) error {
+	count, questions, err := trivia.Submissions(ctx, t.db, msg.User, 5)
+	if err != nil {
+		return fmt.Errorf("failed to get submissions: %w", err)
+	}
+
+	return r.send(formatSubmissions(msg.User, count, questions))
+}
+
+// formatSubmissions lists a user's submitted questions by ID and status.
+func formatSubmissions(name string, count int64, questions models.QuestionSlice) string {
+	if count == 0 {
+		return fmt.Sprintf("%s has not submitted any questions", name)
+	}
+
+	entries := []string{}
+	for _, question := range questions {
+		status := "not asked yet"
+		switch {
+		case question.Removed != "0":
+			status = "removed"
+		case question.Used > 0:
+			status = "asked"
+		}
+		entries = append(entries, fmt.Sprintf("#%d %s", question.ID.Int64, status))
+	}
+
+	output := fmt.Sprintf("%s's questions: %s", name, strings.Join(entries, ", "))
+	if count > int64(len(questions)) {
+		output += fmt.Sprintf(" (latest %d of %d)", len(questions), count)
+	}
+	return output
+}
+
+func (t *TriviaBot) runStreaks(ctx context.Context, r *room, msg *bot.Msg, args []string) error {
+	users, err := r.leaderboard.Streaks(5)
+	if err != nil {
+		return fmt.Errorf("failed to get streaks: %w", err)
+	}
+
+	if len(users) == 0 {
+		return r.send("No one has answered correctly yet")
+	}
+
+	entries := []string{}
+	for _, user := range users {
+		entries = append(entries, fmt.Sprintf("%s %d", user.Name, user.MaxStreak))
+	}
+
+	return r.send("Longest streaks: " + strings.Join(entries, ", "))
+}
+
+func (t *TriviaBot) runTotal(ctx context.Context, r *room, msg *bot.Msg, args []string) error {
+	points, players, err := r.leaderboard.Totals()
+	if err != nil {
+		return fmt.Errorf("failed to get totals: %w", err)
+	}
+
+	if players == 0 {
+		return r.send("No points have been awarded yet")
+	}
+	return r.send(fmt.Sprintf("%s points awarded all time to %s",
+		humanize.Comma(points), english.Plural(players, "player", "")))
+}
+
+func (t *TriviaBot) runWhoami(ctx context.Context, r *room, msg *bot.Msg, args []string) error {
+	role := "player"
+	if msg.IsMod() {
+		role = "mod"
+	} else if t.isAdmin(msg) {
+		role = "admin"
+	}
+
+	features := "none"
+	if len(msg.Features) > 0 {
+		features = strings.Join(msg.Features, ", ")
+	}
+
+	return t.bot.SendPriv(fmt.Sprintf(
+		"You are %s, seen as a %s (features: %s, skips the cooldown: %t)",
+		msg.User, role, features, t.bypassesCooldown(msg),
+	), msg.User)
+}
+
+// formatUptime describes how long ago the bot started and how many quizzes
+// it has hosted since.
+func formatUptime(startedAt time.Time, hosted int64) string {
+	return fmt.Sprintf(
+		"Started %s, hosted %s this session",
+		humanize.Time(startedAt), english.Plural(int(hosted), "quiz", "quizzes"),
+	)
+}
+
+func (t *TriviaBot) runExtremes(ctx context.Context, r *room, msg *bot.Msg, args []string) error {
+	hardest, easiest, err := trivia.QuestionExtremes(ctx, t.db, 3, 5)
+	if err != nil {
+		return t.bot.SendPriv(fmt.Sprintf("Error: %q", err), msg.User)
+	}
+
+	if len(hardest) == 0 {
+		return t.bot.SendPriv("No question has been answered often enough to rank yet", msg.User)
+	}
+
+	return t.bot.SendPriv(fmt.Sprintf(
+		"Hardest: %s. Easiest: %s",
+		formatRates(hardest), formatRates(easiest),
+	), msg.User)
+}
+
+func formatRates(rates []*trivia.QuestionRate) string {
+	entries := []string{}
+	for _, rate := range rates {
+		entries = append(entries, rate.String())
+	}
+	return strings.Join(entries, ", ")
+}
+
+func (t *TriviaBot) runLint(ctx context.Context, r *room, msg *bot.Msg, args []string) error {
+	results, err := trivia.LintQuestions(ctx, t.db, 5)
+	if err != nil {
+		return t.bot.SendPriv(fmt.Sprintf("Error: %q", err), msg.User)
+	}
+
+	report := []string{}
+	for _, result := range results {
+		report = append(report, result.String())
+	}
+
+	return t.bot.SendPriv("Lint: "+strings.Join(report, "; "), msg.User)
+}
@@ -0,0 +1,346 @@
+package triviabot
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/jbpratt/bots/internal/trivia"
+)
+
+// Config holds every setting of a TriviaBot, so it can be loaded from a JSON
+// file with LoadConfig. Zero values keep the defaults.
+type Config struct {
+	URL                string `json:"url"`
+	JWT                string `json:"jwt"`
+	DBPath             string `json:"db_path"`
+	LeaderboardPage    string `json:"leaderboard_page"`
+	LeaderboardIngress string `json:"leaderboard_ingress"`
+	// QuestionsFile asks the questions of a JSON file, see trivia.FileSource,
+	// instead of those in the database.
+	QuestionsFile string `json:"questions_file"`
+
+	// Cooldown is the time to wait between quizzes, 5m by default.
+	Cooldown Duration `json:"cooldown"`
+	// QuestionCooldown keeps questions out of quizzes for this long after
+	// they were asked.
+	QuestionCooldown Duration `json:"question_cooldown"`
+	// MaxQuizzes is how many channels may run a quiz at once, unlimited if 0.
+	MaxQuizzes       int        `json:"max_quizzes"`
+	MaxQuizDuration  Duration   `json:"max_quiz_duration"`
+	MinRoundDuration Duration   `json:"min_round_duration"`
+	MaxRoundDuration Duration   `json:"max_round_duration"`
+	Countdown        []Duration `json:"countdown"`
+	// RateLimitPause is how long to hold back messages once the chat server
+	// rate limits the bot, 2s by default.
+	RateLimitPause Duration `json:"rate_limit_pause"`
+	// PollDuration is how long `trivia poll` collects votes, 30s by default.
+	PollDuration Duration `json:"poll_duration"`
+	// SpeedDuration is the time to answer each round of `trivia speed`, 10s
+	// by default.
+	SpeedDuration Duration `json:"speed_duration"`
+
+	// Category and Difficulty are asked in channels which haven't configured
+	// their own with `trivia config`.
+	Category   string `json:"category"`
+	Difficulty string `json:"difficulty"`
+
+	// Admins may run the commands reserved for mods.
+	Admins []string `json:"admins"`
+	Judges []string `json:"judges"`
+	// CooldownBypass lists the users who skip the cooldown, including every
+	// mod with "mods".
+	CooldownBypass []string `json:"cooldown_bypass"`
+
+	// AllCorrect is how rounds everyone answered correctly are scored,
+	// "ranked" or "flat".
+	AllCorrect string `json:"all_correct"`
+	// Ties is how correct answers sent at the same time are ranked,
+	// "arrival" or "shared".
+	Ties          string  `json:"ties"`
+	DoubleChance  float64 `json:"double_chance"`
+	PublicAnswers bool    `json:"public_answers"`
+	// AnswerCase is how answers are cased when shown, "original", "lower" or
+	// "title".
+	AnswerCase string `json:"answer_case"`
+	// Quiet only sends the messages essential to play.
+	Quiet bool `json:"quiet"`
+	// SkipVotes is how many players must vote to skip a round, 3 by default,
+	// and RequeueSkipped asks skipped questions again at the end of the quiz.
+	SkipVotes      int  `json:"skip_votes"`
+	RequeueSkipped bool `json:"requeue_skipped"`
+	// ManualAdvance waits for an admin to type `trivia next` between rounds.
+	ManualAdvance bool `json:"manual_advance"`
+	// RoundDelayJitter varies the delay between rounds by up to this
+	// fraction either way, at most 0.5.
+	RoundDelayJitter float64 `json:"round_delay_jitter"`
+	// MaxQuestionLength cuts longer questions short, asking them whole if 0.
+	MaxQuestionLength int `json:"max_question_length"`
+	// MinOddsAnswers is the fewest answers a round needs for `trivia odds`
+	// to reveal what was picked.
+	MinOddsAnswers int `json:"min_odds_answers"`
+	// Attribution credits where each question was collected from when asking
+	// it.
+	Attribution bool `json:"attribution"`
+	// ArchiveQuizzes stores finished quizzes to recap them after a restart.
+	ArchiveQuizzes bool `json:"archive_quizzes"`
+	// AnswerLog is a file to append how many picked each choice of every
+	// round to, without naming anyone.
+	AnswerLog string `json:"answer_log"`
+	// AnswerCounts adds how many answered each round, and correctly, to its
+	// results.
+	AnswerCounts bool `json:"answer_counts"`
+	// AnswerFeedback whispers everyone who answered a round whether they got
+	// it right once it closes.
+	AnswerFeedback bool `json:"answer_feedback"`
+	// ScoringBreakdown explains how points are scored when a quiz starts.
+	ScoringBreakdown bool  `json:"scoring_breakdown"`
+	TextAnswers      *bool `json:"text_answers"`
+	// FinalRoundLabel is what the last round is called, "Final round" by
+	// default, numbering it like any other if empty.
+	FinalRoundLabel *string `json:"final_round_label"`
+	ChangeAnswers   bool    `json:"change_answers"`
+	// DistinctQuestions never asks a question twice in one quiz.
+	DistinctQuestions bool    `json:"distinct_questions"`
+	Freshness         float64 `json:"freshness"`
+	// Grace keeps accepting answers for this long after a round closes, a
+	// correct one scoring GracePoints.
+	Grace       Duration `json:"grace"`
+	GracePoints int      `json:"grace_points"`
+	// BurstSize answers within BurstWindow of each other are flagged as
+	// suspicious, if at least 2.
+	BurstSize   int      `json:"burst_size"`
+	BurstWindow Duration `json:"burst_window"`
+	// PointsDecay is the fraction of their points players lose per day they
+	// don't play.
+	PointsDecay float64 `json:"points_decay"`
+
+	Emotes        *Emotes        `json:"emotes"`
+	Announcements *Announcements `json:"announcements"`
+
+	CreateIndexes bool   `json:"create_indexes"`
+	API           string `json:"api"`
+
+	// Options are applied after the settings above, overriding them.
+	Options []Option `json:"-"`
+}
+
+// Duration is a time.Duration written like "30s" in JSON.
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string like \"30s\": %w", err)
+	}
+
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// LoadConfig reads a Config from the JSON file at path.
+func LoadConfig(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	var cfg Config
+	if err = json.Unmarshal(data, &cfg); err != nil {
+		return Config{}, fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+
+	return cfg, nil
+}
+
+// options returns the Options applying the settings of c, followed by
+// c.Options.
+func (c Config) options() ([]Option, error) {
+	opts := []Option{}
+
+	if c.QuestionsFile != "" {
+		source, err := trivia.NewFileSource(c.QuestionsFile)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithSource(source))
+	}
+
+	if c.Cooldown > 0 {
+		opts = append(opts, WithCooldown(time.Duration(c.Cooldown)))
+	}
+	if c.QuestionCooldown > 0 {
+		opts = append(opts, WithQuestionCooldown(time.Duration(c.QuestionCooldown)))
+	}
+	if c.MaxQuizzes > 0 {
+		opts = append(opts, WithMaxQuizzes(c.MaxQuizzes))
+	}
+	if c.MaxQuizDuration > 0 {
+		opts = append(opts, WithMaxQuizDuration(time.Duration(c.MaxQuizDuration)))
+	}
+	if c.MinRoundDuration > 0 || c.MaxRoundDuration > 0 {
+		min, max := time.Duration(c.MinRoundDuration), time.Duration(c.MaxRoundDuration)
+		opts = append(opts, func(t *TriviaBot) {
+			if min > 0 {
+				t.minRoundDuration = min
+			}
+			if max > 0 {
+				t.maxRoundDuration = max
+			}
+		})
+	}
+	if c.RateLimitPause > 0 {
+		opts = append(opts, WithRateLimitPause(time.Duration(c.RateLimitPause)))
+	}
+	if c.PollDuration > 0 {
+		opts = append(opts, WithPollDuration(time.Duration(c.PollDuration)))
+	}
+	if c.SpeedDuration > 0 {
+		opts = append(opts, WithSpeedDuration(time.Duration(c.SpeedDuration)))
+	}
+	if len(c.Countdown) > 0 {
+		remaining := []time.Duration{}
+		for _, d := range c.Countdown {
+			remaining = append(remaining, time.Duration(d))
+		}
+		opts = append(opts, WithCountdown(remaining...))
+	}
+
+	if c.Category != "" || c.Difficulty != "" {
+		opts = append(opts, WithDefaultFilter(trivia.Filter{Category: c.Category, Difficulty: c.Difficulty}))
+	}
+
+	if len(c.Admins) > 0 {
+		opts = append(opts, WithAdmins(c.Admins...))
+	}
+	if len(c.Judges) > 0 {
+		opts = append(opts, WithJudges(c.Judges...))
+	}
+	if len(c.CooldownBypass) > 0 {
+		mods, users := false, []string{}
+		for _, user := range c.CooldownBypass {
+			if user == "mods" {
+				mods = true
+			} else {
+				users = append(users, user)
+			}
+		}
+		opts = append(opts, WithCooldownBypass(mods, users...))
+	}
+
+	if c.AllCorrect != "" {
+		scoring, err := trivia.ParseAllCorrectScoring(c.AllCorrect)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithAllCorrectScoring(scoring))
+	}
+	if c.Ties != "" {
+		scoring, err := trivia.ParseTieScoring(c.Ties)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithTieScoring(scoring))
+	}
+	if c.AnswerCase != "" {
+		casing, err := ParseAnswerCase(c.AnswerCase)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithAnswerCase(casing))
+	}
+	if c.DoubleChance > 0 {
+		opts = append(opts, WithDoublePoints(c.DoubleChance))
+	}
+	if c.PublicAnswers {
+		opts = append(opts, WithPublicAnswers())
+	}
+	if c.Quiet {
+		opts = append(opts, WithQuietMode())
+	}
+	if c.SkipVotes > 0 {
+		opts = append(opts, WithSkipVotes(c.SkipVotes))
+	}
+	if c.RequeueSkipped {
+		opts = append(opts, WithRequeueSkipped())
+	}
+	if c.ManualAdvance {
+		opts = append(opts, WithManualAdvance())
+	}
+	if c.RoundDelayJitter > 0 {
+		opts = append(opts, WithRoundDelayJitter(c.RoundDelayJitter))
+	}
+	if c.MaxQuestionLength > 0 {
+		opts = append(opts, WithMaxQuestionLength(c.MaxQuestionLength))
+	}
+	if c.MinOddsAnswers > 0 {
+		opts = append(opts, WithMinOddsAnswers(c.MinOddsAnswers))
+	}
+	if c.Attribution {
+		opts = append(opts, WithAttribution())
+	}
+	if c.ArchiveQuizzes {
+		opts = append(opts, WithQuizArchive())
+	}
+	if c.AnswerLog != "" {
+		opts = append(opts, WithAnswerLog(c.AnswerLog))
+	}
+	if c.AnswerCounts {
+		opts = append(opts, WithAnswerCounts())
+	}
+	if c.AnswerFeedback {
+		opts = append(opts, WithAnswerFeedback())
+	}
+	if c.ScoringBreakdown {
+		opts = append(opts, WithScoringBreakdown())
+	}
+	if c.FinalRoundLabel != nil {
+		opts = append(opts, WithFinalRoundLabel(*c.FinalRoundLabel))
+	}
+	if c.TextAnswers != nil {
+		opts = append(opts, WithTextAnswers(*c.TextAnswers))
+	}
+	if c.ChangeAnswers {
+		opts = append(opts, WithAnswerChanges())
+	}
+	if c.DistinctQuestions {
+		opts = append(opts, WithDistinctQuestions())
+	}
+	if c.Freshness > 0 {
+		opts = append(opts, WithFreshness(c.Freshness))
+	}
+	if c.Grace > 0 {
+		opts = append(opts, WithGracePeriod(time.Duration(c.Grace), c.GracePoints))
+	}
+	if c.BurstSize > 1 {
+		opts = append(opts, WithBurstDetection(c.BurstSize, time.Duration(c.BurstWindow)))
+	}
+	if c.PointsDecay > 0 {
+		opts = append(opts, WithPointsDecay(c.PointsDecay))
+	}
+
+	if c.Emotes != nil {
+		opts = append(opts, WithEmotes(*c.Emotes))
+	}
+	if c.Announcements != nil {
+		opts = append(opts, WithAnnouncements(*c.Announcements))
+	}
+
+	if c.CreateIndexes {
+		opts = append(opts, WithIndexes())
+	}
+	if c.API != "" {
+		opts = append(opts, WithAPI(c.API))
+	}
+
+	return append(opts, c.Options...), nil
+}
@@ -0,0 +1,168 @@
+package triviabot
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/jbpratt/bots/internal/bot"
+	"github.com/jbpratt/bots/internal/trivia"
+)
+
+// pollChoices is the most categories offered in a poll.
+const pollChoices = 4
+
+// categoryPoll collects chat's votes on the category of the next quiz.
+type categoryPoll struct {
+	categories []string
+	mu         sync.Mutex
+	// votes holds the index of the category each user voted for.
+	votes map[string]int
+}
+
+func newCategoryPoll(categories []string) *categoryPoll {
+	return &categoryPoll{categories: categories, votes: map[string]int{}}
+}
+
+// vote counts data as user's vote if it is the number of a category, replacing
+// their earlier vote, and reports whether it was one.
+func (p *categoryPoll) vote(user, data string) bool {
+	num, err := strconv.Atoi(strings.TrimSpace(data))
+	if err != nil || num < 1 || num > len(p.categories) {
+		return false
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.votes[user] = num - 1
+	return true
+}
+
+// winner returns the category with the most votes and how many it got. Ties
+// go to the category listed first.
+func (p *categoryPoll) winner() (string, int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	counts := make([]int, len(p.categories))
+	for _, idx := range p.votes {
+		counts[idx]++
+	}
+
+	best := 0
+	for i, count := range counts {
+		if count > counts[best] {
+			best = i
+		}
+	}
+	return p.categories[best], counts[best]
+}
+
+func (p *categoryPoll) String() string {
+	choices := []string{}
+	for i, category := range p.categories {
+		choices = append(choices, fmt.Sprintf("%d) %s", i+1, category))
+	}
+	return series(choices, "or", "no categories")
+}
+
+func (t *TriviaBot) runPoll(ctx context.Context, r *room, msg *bot.Msg, args []string) error {
+	opts := &startOptions{}
+	if err := newStartFlagSet(opts).Parse(args); err != nil {
+		return r.send(fmt.Sprintf("invalid flags: %v, see `trivia help poll`", err))
+	}
+	if opts.filter.Category != "" || opts.balanced {
+		return r.send("the poll picks the category, so it cannot be combined with -category or -balanced")
+	}
+	if problem := t.checkStartOptions(opts); problem != "" {
+		return r.send(problem)
+	}
+
+	source, ok := t.source.(trivia.FilterableSource)
+	if !ok {
+		return r.send("the question source has no categories to vote on")
+	}
+	categories, err := source.Categories()
+	if err != nil {
+		return fmt.Errorf("failed to list categories: %w", err)
+	}
+	if len(categories) < 2 {
+		return r.send("there aren't enough categories to vote on")
+	}
+	rand.Shuffle(len(categories), func(i, j int) {
+		categories[i], categories[j] = categories[j], categories[i]
+	})
+	if len(categories) > pollChoices {
+		categories = categories[:pollChoices]
+	}
+
+	// the poll holds the room like a quiz, which it turns into
+	claim, problem := t.claimRoom(r)
+	if problem != "" {
+		return r.send(problem)
+	}
+	launched := false
+	defer func() {
+		if !launched {
+			t.releaseRoom(r, claim)
+		}
+	}()
+
+	if cooldown, err := t.checkCooldown(r, msg, opts); err != nil || cooldown != "" {
+		if err != nil {
+			return err
+		}
+		return r.send(cooldown)
+	}
+
+	poll := newCategoryPoll(categories)
+	r.poll.Store(poll)
+	output := fmt.Sprintf("Vote for the category of the next quiz by typing its number in the next %s: %s", t.pollDuration, poll)
+	if err = r.send(output); err != nil {
+		r.poll.Store(nil)
+		return fmt.Errorf("failed to send the poll: %w", err)
+	}
+
+	launched = true
+	t.launch(ctx, r, claim, func(ctx context.Context) error {
+		return t.runPolledQuiz(ctx, r, msg.User, opts, poll)
+	})
+
+	return nil
+}
+
+// runPolledQuiz closes poll once the voting time is up and runs the quiz
+// described by opts in the winning category. Without any votes, the quiz is
+// called off.
+func (t *TriviaBot) runPolledQuiz(ctx context.Context, r *room, user string, opts *startOptions, poll *categoryPoll) error {
+	err := sleep(ctx, t.pollDuration)
+	r.poll.Store(nil)
+	if err != nil {
+		r.logger.Infow("poll cancelled", "error", err)
+		return nil
+	}
+
+	category, votes := poll.winner()
+	r.logger.Infow("poll closed", "category", category, "votes", votes)
+	if votes == 0 {
+		return r.send("No one voted, so no quiz is started")
+	}
+	if err = r.send(fmt.Sprintf("%s wins the poll with %d vote(s)!", category, votes)); err != nil {
+		return fmt.Errorf("failed to announce the poll's winner: %w", err)
+	}
+
+	opts.filter.Category = category
+	quiz, problem, err := t.newQuiz(r, opts, nil)
+	if err != nil {
+		return err
+	}
+	if problem != "" {
+		return r.send(problem)
+	}
+	r.setQuiz(quiz)
+
+	return t.runQuiz(ctx, r, user)
+}
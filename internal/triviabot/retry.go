@@ -0,0 +1,92 @@
+package triviabot
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jbpratt/bots/internal/bot"
+	"go.uber.org/zap"
+)
+
+// retryingChat retries failed sends with exponential backoff, so a transient
+// hiccup of the chat server doesn't end a quiz. When the server rate limits
+// the bot every send is held back for pause, queuing behind the pause, and
+// the rate limited message is sent again without counting as an attempt, up
+// to maxRateLimitPauses times.
+type retryingChat struct {
+	chat
+	logger   *zap.SugaredLogger
+	attempts int
+	backoff  time.Duration
+	pause    time.Duration
+
+	mu          sync.Mutex
+	pausedUntil time.Time
+}
+
+// maxRateLimitPauses is how many times one message waits out a rate limit
+// before giving up, so a server which keeps throttling the bot can't hold up
+// a quiz forever.
+const maxRateLimitPauses = 5
+
+func (c *retryingChat) SendChannel(msg, channel string) error {
+	return c.retry(func() error {
+		return c.chat.SendChannel(msg, channel)
+	})
+}
+
+func (c *retryingChat) SendPriv(msg, user string) error {
+	return c.retry(func() error {
+		return c.chat.SendPriv(msg, user)
+	})
+}
+
+func (c *retryingChat) retry(send func() error) error {
+	backoff, pauses := c.backoff, 0
+	for attempt := 1; ; attempt++ {
+		c.waitForPause()
+		err := send()
+		if err == nil {
+			return nil
+		}
+
+		if errors.Is(err, bot.ErrRateLimited) {
+			if pauses >= maxRateLimitPauses {
+				return fmt.Errorf("giving up after %d rate limit pause(s): %w", pauses, err)
+			}
+			pauses++
+			c.logger.Warnw("rate limited, pausing sends", "pause", c.pause)
+			c.pauseSends()
+			attempt--
+			continue
+		}
+
+		if attempt >= c.attempts {
+			return fmt.Errorf("giving up after %d attempt(s): %w", attempt, err)
+		}
+
+		c.logger.Warnw("failed to send, retrying", "attempt", attempt, "backoff", backoff, "err", err)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// pauseSends holds back every send for the pause from now.
+func (c *retryingChat) pauseSends() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pausedUntil = time.Now().Add(c.pause)
+}
+
+// waitForPause blocks until sends are no longer paused.
+func (c *retryingChat) waitForPause() {
+	c.mu.Lock()
+	wait := time.Until(c.pausedUntil)
+	c.mu.Unlock()
+
+	if wait > 0 {
+		time.Sleep(wait)
+	}
+}
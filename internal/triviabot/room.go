@@ -0,0 +1,243 @@
+package triviabot
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jbpratt/bots/internal/trivia"
+	"go.uber.org/zap"
+)
+
+// room is the state of a single chat channel. Every room runs its own quizzes
+// with its own cooldown and leaderboard, so one bot can serve several
+// channels. The server's default channel is the room named "".
+type room struct {
+	logger      *zap.SugaredLogger
+	bot         chat
+	channel     string
+	leaderboard *trivia.Leaderboard
+	// quizMu guards replacing quiz, which is read outside the room's own
+	// goroutines by the API.
+	quizMu          sync.RWMutex
+	quiz            *trivia.Quiz
+	cancel          context.CancelFunc
+	lastQuizEndedAt time.Time
+	// running is claimed by the start command so only one quiz runs at a
+	// time, including between its rounds. claimMu guards claiming it, and
+	// claim numbers each claim so only its holder can release it.
+	running  atomic.Bool
+	claimMu  sync.Mutex
+	claim    uint64
+	throttle answerThrottle
+	// quiet suppresses the messages which aren't essential to play, see
+	// sendNonEssential.
+	quiet atomic.Bool
+	// nextCategory overrides the default category of the next quiz started
+	// without one, once, if not nil. Empty asks any category.
+	nextCategory atomic.Pointer[string]
+	// poll is the vote on the category of the next quiz while it is open.
+	poll atomic.Pointer[categoryPoll]
+	// advance signals a quiz waiting for the host, see WithManualAdvance, to
+	// start its next round, which awaitingNext is set while it waits for.
+	advance      chan struct{}
+	awaitingNext atomic.Bool
+	// lastDaily is the date in UTC the daily challenge was last started, so
+	// it is only played once a day.
+	dailyMu   sync.Mutex
+	lastDaily string
+}
+
+// maxMessageLength is the longest message sent in one go, staying below the
+// chat's limit.
+const maxMessageLength = 500
+
+func (r *room) send(msg string) error {
+	return r.bot.SendChannel(msg, r.channel)
+}
+
+// sendNonEssential sends msg unless the room is quiet. Only the questions,
+// their answers, the results and replies to commands are essential.
+func (r *room) sendNonEssential(msg string) error {
+	if r.quiet.Load() {
+		r.logger.Debugw("suppressed message in quiet mode", "msg", msg)
+		return nil
+	}
+	return r.send(msg)
+}
+
+// sendAll sends entries joined by sep, split over as few messages as fit
+// within maxMessageLength.
+func (r *room) sendAll(entries []string, sep string) error {
+	for _, msg := range splitMessages(entries, sep, maxMessageLength) {
+		if err := r.send(msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitMessages joins entries with sep into messages of at most limit bytes,
+// never splitting an entry. An entry longer than limit is sent on its own.
+func splitMessages(entries []string, sep string, limit int) []string {
+	msgs := []string{}
+	msg := ""
+	for _, entry := range entries {
+		if msg != "" && len(msg)+len(sep)+len(entry) > limit {
+			msgs = append(msgs, msg)
+			msg = ""
+		}
+		if msg != "" {
+			msg += sep
+		}
+		msg += entry
+	}
+	if msg != "" {
+		msgs = append(msgs, msg)
+	}
+	return msgs
+}
+
+func (r *room) currentQuiz() *trivia.Quiz {
+	r.quizMu.RLock()
+	defer r.quizMu.RUnlock()
+	return r.quiz
+}
+
+func (r *room) setQuiz(quiz *trivia.Quiz) {
+	r.quizMu.Lock()
+	defer r.quizMu.Unlock()
+	r.quiz = quiz
+}
+
+// setCancel sets the function cancelling the room's running quiz.
+func (r *room) setCancel(cancel context.CancelFunc) {
+	r.quizMu.Lock()
+	defer r.quizMu.Unlock()
+	r.cancel = cancel
+}
+
+// cancelQuiz cancels the room's running quiz, if any.
+func (r *room) cancelQuiz() {
+	r.quizMu.RLock()
+	defer r.quizMu.RUnlock()
+	if r.cancel != nil {
+		r.cancel()
+	}
+}
+
+// waitForAdvance waits until the host starts the next round with `trivia
+// next`, or ctx is done.
+func (r *room) waitForAdvance(ctx context.Context) error {
+	// a signal left over from before the wait doesn't count
+	select {
+	case <-r.advance:
+	default:
+	}
+
+	r.awaitingNext.Store(true)
+	defer r.awaitingNext.Store(false)
+
+	select {
+	case <-r.advance:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// advanceRound starts the next round of a quiz waiting for the host,
+// reporting whether one was.
+func (r *room) advanceRound() bool {
+	if !r.awaitingNext.Load() {
+		return false
+	}
+	select {
+	case r.advance <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+func (r *room) roundInProgress() bool {
+	quiz := r.currentQuiz()
+	return quiz != nil && quiz.InProgress()
+}
+
+// activeRound returns the round in progress, or nil if there is none. `trivia
+// unstick` may reset the quiz at any time, so answers are given to the round
+// returned here rather than looking it up again.
+func (r *room) activeRound() *trivia.Round {
+	quiz := r.currentQuiz()
+	if quiz == nil || !quiz.InProgress() {
+		return nil
+	}
+	return quiz.CurrentRound()
+}
+
+// quizLogger returns the room's logger with the fields identifying its quiz
+// and, if not nil, round, so a quiz's lifecycle can be followed in the logs.
+func (r *room) quizLogger(round *trivia.Round) *zap.SugaredLogger {
+	fields := []any{"quiz_id", r.currentQuiz().ID}
+	if round != nil {
+		fields = append(fields, "round_num", round.Num)
+	}
+	return r.logger.With(fields...)
+}
+
+// room returns the room of channel, creating it on first use.
+func (t *TriviaBot) room(channel string) (*room, error) {
+	t.roomsMu.Lock()
+	defer t.roomsMu.Unlock()
+
+	if r, ok := t.rooms[channel]; ok {
+		return r, nil
+	}
+
+	lboard, err := trivia.NewChannelLeaderboard(t.logger, t.db, channel)
+	if err != nil {
+		return nil, fmt.Errorf("failed to init leaderboard of channel %q: %w", channel, err)
+	}
+	lboard.SetDecay(t.pointsDecay)
+
+	r := &room{
+		logger:      t.logger.With("channel", channel),
+		bot:         t.bot,
+		channel:     channel,
+		leaderboard: lboard,
+		throttle:    answerThrottle{interval: t.answerInterval},
+		advance:     make(chan struct{}, 1),
+	}
+	r.quiet.Store(t.quiet)
+	t.rooms[channel] = r
+
+	return r, nil
+}
+
+// existingRoom returns the room of channel if it has been used.
+func (t *TriviaBot) existingRoom(channel string) *room {
+	t.roomsMu.Lock()
+	defer t.roomsMu.Unlock()
+	return t.rooms[channel]
+}
+
+// playingRooms returns the rooms with a round in progress.
+func (t *TriviaBot) playingRooms() []*room {
+	t.roomsMu.Lock()
+	rooms := []*room{}
+	for _, r := range t.rooms {
+		rooms = append(rooms, r)
+	}
+	t.roomsMu.Unlock()
+
+	// checked without roomsMu, so a busy quiz can't hold up room lookups
+	playing := []*room{}
+	for _, r := range rooms {
+		if r.roundInProgress() {
+			playing = append(playing, r)
+		}
+	}
+	return playing
+}
@@ -0,0 +1,43 @@
+package triviabot
+
+import (
+	"sync"
+	"time"
+
+	"github.com/jbpratt/bots/internal/trivia"
+)
+
+// answerThrottle limits how often each user may attempt to answer a round,
+// so one user flooding the bot with whispers can't drown out everyone else.
+type answerThrottle struct {
+	mu       sync.Mutex
+	interval time.Duration
+	round    *trivia.Round
+	last     map[string]time.Time
+	warned   map[string]bool
+}
+
+// allow reports whether user may attempt to answer round at now, and if not,
+// whether they should be warned. Each user is warned once per run of
+// throttled attempts.
+func (a *answerThrottle) allow(round *trivia.Round, user string, now time.Time) (bool, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	// attempts are only tracked for the current round
+	if a.round != round {
+		a.round = round
+		a.last = map[string]time.Time{}
+		a.warned = map[string]bool{}
+	}
+
+	if last, ok := a.last[user]; ok && now.Sub(last) < a.interval {
+		warn := !a.warned[user]
+		a.warned[user] = true
+		return false, warn
+	}
+
+	a.last[user] = now
+	a.warned[user] = false
+	return true, false
+}
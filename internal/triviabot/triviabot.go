@@ -6,11 +6,17 @@ import (
 	"errors"
 	"fmt"
 	"html/template"
+	"math/rand"
+	"net"
+	"net/http"
 	"os"
 	"path/filepath"
-	"strconv"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
+	"unicode/utf8"
 
 	"github.com/dustin/go-humanize"
 	"github.com/dustin/go-humanize/english"
@@ -21,21 +27,555 @@ import (
 	"go.uber.org/zap"
 )
 
+// chat is the part of *bot.Bot the trivia bot talks through.
+type chat interface {
+	SendChannel(msg, channel string) error
+	SendPriv(msg, user string) error
+	Run() error
+	Destroy() error
+}
+
 type TriviaBot struct {
 	logger                *zap.SugaredLogger
-	bot                   *bot.Bot
+	bot                   chat
+	db                    *sql.DB
 	source                trivia.Source
-	quiz                  *trivia.Quiz
-	leaderboard           *trivia.Leaderboard
-	lastQuizEndedAt       time.Time
+	roomsMu               sync.Mutex
+	rooms                 map[string]*room
 	leaderboardOutputPath string
 	leaderboardIngress    string
+	commands              []*command
+	asked                 askedQuestions
+	admins                []string
+	judges                []string
+	announcements         Announcements
+	announce              *announcer
+	emotes                Emotes
+	publicAnswers         bool
+	answerInterval        time.Duration
+	sendAttempts          int
+	sendBackoff           time.Duration
+	rateLimitPause        time.Duration
+	pollDuration          time.Duration
+	speedDuration         time.Duration
+	startDelay            time.Duration
+	roundDelay            time.Duration
+	endDelay              time.Duration
+	maxQuizDuration       time.Duration
+	// roundDelayJitter varies roundDelay by up to this fraction either way,
+	// drawn from jitterRand.
+	roundDelayJitter float64
+	jitterMu         sync.Mutex
+	jitterRand       *rand.Rand
+	// manualAdvance waits for `trivia next` between rounds instead of
+	// roundDelay.
+	manualAdvance bool
+	// skipVotes is how many players must vote `trivia skip` to skip a round,
+	// and requeueSkipped asks the questions skipped again at the end.
+	skipVotes      int
+	requeueSkipped bool
+	// minRoundDuration and maxRoundDuration bound `trivia start -duration`,
+	// unless zero.
+	minRoundDuration time.Duration
+	maxRoundDuration time.Duration
+	allCorrect       trivia.AllCorrectScoring
+	ties             trivia.TieScoring
+	countdown        []time.Duration
+	doubleChance     float64
+	textAnswers      bool
+	answerCase       AnswerCase
+	finalRoundLabel  string
+	changeAnswers    bool
+	freshness        float64
+	cooldown         time.Duration
+	// questionCooldown keeps questions out of quizzes for this long after
+	// they were asked, unless zero.
+	questionCooldown time.Duration
+	pointsDecay      float64
+	quiet            bool
+	answerCounts     bool
+	answerFeedback   bool
+	scoringBreakdown bool
+	archiveQuizzes   bool
+	// answerLog records the answers to each round without names, if set.
+	answerLog  *answerLog
+	grace      time.Duration
+	latePoints int
+	// maxQuestionLength shortens longer questions when asked, unless zero.
+	maxQuestionLength int
+	// minOddsAnswers is the fewest answers a round needs for its odds to be
+	// revealed.
+	minOddsAnswers int
+	// attribution credits the source of each question when asking it.
+	attribution bool
+	// distinctQuestions never asks a question twice in one quiz.
+	distinctQuestions bool
+	burst             trivia.BurstDetection
+	// defaultFilter is asked in channels without defaults of their own.
+	defaultFilter trivia.Filter
+	// cooldownBypassMods and cooldownBypassUsers skip the cooldown between
+	// quizzes without -force.
+	cooldownBypassMods  bool
+	cooldownBypassUsers []string
+	createIndexes       bool
+	apiAddr             string
+	apiListener         net.Listener
+	answerSources       []AnswerSource
+	// whispers takes the answers whispered to the bot, see onPrivMsg.
+	whispers *whisperAnswers
+	// stopListening stops the answer sources, which listen from Run until
+	// Close.
+	listenCtx     context.Context
+	stopListening context.CancelFunc
+	// quizzes tracks the goroutines running quizzes, so Close can wait for
+	// them.
+	quizzes sync.WaitGroup
+	// sourceMu serializes drawing questions from source, which keeps its
+	// own unguarded state, as polls build their quizzes on their own
+	// goroutines.
+	sourceMu sync.Mutex
+	// runningQuizzes counts the rooms running a quiz, or a poll turning into
+	// one, which may be at most maxQuizzes unless it is zero.
+	runningQuizzes atomic.Int32
+	maxQuizzes     int
+	// startedAt is when the bot was created, and quizzesHosted counts the
+	// quizzes completed since.
+	startedAt     time.Time
+	quizzesHosted atomic.Int64
+}
+
+// Option configures optional TriviaBot behaviour.
+type Option func(*TriviaBot)
+
+// WithAdmins lets users run the commands reserved for mods. Only mods may by
+// default.
+func WithAdmins(users ...string) Option {
+	return func(t *TriviaBot) {
+		t.admins = users
+	}
+}
+
+// WithCooldown sets the time to wait after a quiz before another may be
+// started, 5m by default.
+func WithCooldown(d time.Duration) Option {
+	return func(t *TriviaBot) {
+		t.cooldown = d
+	}
+}
+
+// WithMaxQuizzes limits how many channels may run a quiz at once, rejecting
+// new quizzes while that many are running. Quizzes are unlimited by default.
+func WithMaxQuizzes(n int) Option {
+	return func(t *TriviaBot) {
+		t.maxQuizzes = n
+	}
+}
+
+// WithQuestionCooldown keeps questions out of new quizzes for d after they
+// were asked, so back to back quizzes don't repeat each other. Only the
+// questions asked since the bot started are known. Like -exclude-used, quizzes
+// fail to start if too few questions are left. There is no cooldown by
+// default.
+func WithQuestionCooldown(d time.Duration) Option {
+	return func(t *TriviaBot) {
+		t.questionCooldown = d
+	}
+}
+
+// WithManualAdvance has quizzes wait between rounds until an admin starts the
+// next one with `trivia next`, for hosted events, rather than starting it
+// after a fixed delay.
+func WithManualAdvance() Option {
+	return func(t *TriviaBot) {
+		t.manualAdvance = true
+	}
+}
+
+// WithRoundDelayJitter varies the delay between rounds by up to fraction of it
+// either way, like 0.2 for 20% shorter or longer, so the next round is harder
+// to time. fraction is capped at 0.5. The delay is fixed by default.
+func WithRoundDelayJitter(fraction float64) Option {
+	return func(t *TriviaBot) {
+		t.roundDelayJitter = max(0, min(fraction, maxRoundDelayJitter))
+	}
+}
+
+// WithSkipVotes sets how many players must vote with `trivia skip` to skip
+// the round in progress, 3 by default. Admins skip it outright.
+func WithSkipVotes(n int) Option {
+	return func(t *TriviaBot) {
+		t.skipVotes = n
+	}
+}
+
+// WithRequeueSkipped asks the question of a skipped round again as an extra
+// round at the end of the quiz, giving it a second chance, rather than
+// revealing its answer and moving on.
+func WithRequeueSkipped() Option {
+	return func(t *TriviaBot) {
+		t.requeueSkipped = true
+	}
+}
+
+// WithScoringBreakdown explains how points are scored when a quiz starts,
+// see trivia.Quiz.ScoringBreakdown, setting expectations for custom scoring.
+func WithScoringBreakdown() Option {
+	return func(t *TriviaBot) {
+		t.scoringBreakdown = true
+	}
+}
+
+// WithQuietMode starts every room quiet, only sending the messages essential
+// to play until `trivia quiet off`. Rooms aren't quiet by default.
+func WithQuietMode() Option {
+	return func(t *TriviaBot) {
+		t.quiet = true
+	}
+}
+
+// WithBurstDetection flags size or more answers to a round given within
+// window of each other as suspicious, logging them along with the round's
+// results. Only answers with identical timestamps are caught with a zero
+// window. Answers aren't checked by default.
+func WithBurstDetection(size int, window time.Duration) Option {
+	return func(t *TriviaBot) {
+		t.burst = trivia.BurstDetection{Size: size, Window: window}
+	}
+}
+
+// WithMaxQuestionLength cuts questions longer than max characters short when
+// asking them, pointing to `trivia repeat` for the full text. Questions are
+// asked whole by default.
+func WithMaxQuestionLength(max int) Option {
+	return func(t *TriviaBot) {
+		t.maxQuestionLength = max
+	}
+}
+
+// WithGracePeriod keeps accepting whispered answers for grace after each
+// round closes, as chat can be slow to deliver them. Late answers count
+// towards answer history and stats, but a correct one only scores
+// latePoints. Answers after the round closes are rejected by default.
+func WithGracePeriod(grace time.Duration, latePoints int) Option {
+	return func(t *TriviaBot) {
+		t.grace = grace
+		t.latePoints = latePoints
+	}
+}
+
+// WithMinOddsAnswers only reveals the odds of rounds at least n players
+// answered, so they don't give away what a few players picked. The odds are
+// always revealed by default.
+func WithMinOddsAnswers(n int) Option {
+	return func(t *TriviaBot) {
+		t.minOddsAnswers = n
+	}
+}
+
+// WithAttribution credits where each question was collected from when asking
+// it, like "(via opentdb)". Sources are left out by default to keep messages
+// short.
+func WithAttribution() Option {
+	return func(t *TriviaBot) {
+		t.attribution = true
+	}
+}
+
+// WithQuizArchive stores every finished quiz, with its answers in the order
+// they were shown, so `trivia recap` still works after a restart. Only the
+// quiz in memory is recapped by default.
+func WithQuizArchive() Option {
+	return func(t *TriviaBot) {
+		t.archiveQuizzes = true
+	}
+}
+
+// WithAnswerLog appends how many picked each choice of every completed round,
+// without naming anyone, to the file at path as JSON lines, for analysing the
+// questions offline.
+func WithAnswerLog(path string) Option {
+	return func(t *TriviaBot) {
+		t.answerLog = &answerLog{path: path}
+	}
+}
+
+// WithAnswerCounts adds how many players answered each round, and how many
+// of them correctly, to the end of the round's results. Only the fastest
+// correct answers are listed by default.
+func WithAnswerCounts() Option {
+	return func(t *TriviaBot) {
+		t.answerCounts = true
+	}
+}
+
+// WithAnswerFeedback whispers everyone who answered a round, once it closes,
+// whether they got it right and what the correct answer was.
+func WithAnswerFeedback() Option {
+	return func(t *TriviaBot) {
+		t.answerFeedback = true
+	}
+}
+
+// WithPointsDecay makes players lose rate, a fraction from 0 to 1, of their
+// leaderboard points for each day they don't play. Points don't decay by
+// default.
+func WithPointsDecay(rate float64) Option {
+	return func(t *TriviaBot) {
+		t.pointsDecay = rate
+	}
+}
+
+// WithDefaultFilter sets the category and difficulty asked in channels which
+// haven't set their own with `trivia config`. Any question is asked by
+// default.
+func WithDefaultFilter(filter trivia.Filter) Option {
+	return func(t *TriviaBot) {
+		t.defaultFilter = filter
+	}
+}
+
+// WithJudges whispers the correct answer to each of judges when a round
+// starts, so moderated events can be monitored. No one is told by default.
+func WithJudges(judges ...string) Option {
+	return func(t *TriviaBot) {
+		t.judges = judges
+	}
+}
+
+// WithAnnouncements replaces the wording of the announcements made in chat.
+func WithAnnouncements(announcements Announcements) Option {
+	return func(t *TriviaBot) {
+		t.announcements = announcements
+	}
+}
+
+// WithEmotes replaces the emotes reacting to outcomes in announcements.
+func WithEmotes(emotes Emotes) Option {
+	return func(t *TriviaBot) {
+		t.emotes = emotes
+	}
+}
+
+// WithPublicAnswers takes answers from numbers typed in chat rather than from
+// whispers, which are then refused. A player's first number counts.
+func WithPublicAnswers() Option {
+	return func(t *TriviaBot) {
+		t.publicAnswers = true
+	}
+}
+
+// WithAnswerInterval sets the minimum time between a user's attempts to
+// answer a round. Attempts made sooner are dropped, warning the user once. By
+// default a user may try once a second.
+func WithAnswerInterval(interval time.Duration) Option {
+	return func(t *TriviaBot) {
+		t.answerInterval = interval
+	}
+}
+
+// WithSendRetries makes up to attempts tries to deliver each message, waiting
+// backoff after the first failure and doubling the wait after each following
+// one. By default a message is tried 3 times starting with a 500ms backoff.
+func WithSendRetries(attempts int, backoff time.Duration) Option {
+	return func(t *TriviaBot) {
+		t.sendAttempts = attempts
+		t.sendBackoff = backoff
+	}
+}
+
+// WithRateLimitPause holds back every message for d once the chat server rate
+// limits the bot, 2s by default.
+func WithRateLimitPause(d time.Duration) Option {
+	return func(t *TriviaBot) {
+		t.rateLimitPause = d
+	}
+}
+
+// WithPollDuration sets how long `trivia poll` collects votes before starting
+// the quiz, 30s by default.
+func WithPollDuration(d time.Duration) Option {
+	return func(t *TriviaBot) {
+		t.pollDuration = d
+	}
+}
+
+// WithSpeedDuration sets the time to answer each round of `trivia speed`, 10s
+// by default. It must be within the limits of -duration.
+func WithSpeedDuration(d time.Duration) Option {
+	return func(t *TriviaBot) {
+		t.speedDuration = d
+	}
+}
+
+// WithMaxQuizDuration ends a quiz which is still running after d and
+// announces the results so far, guarding against stuck rounds or long
+// delays. Quizzes are not capped by default.
+func WithMaxQuizDuration(d time.Duration) Option {
+	return func(t *TriviaBot) {
+		t.maxQuizDuration = d
+	}
+}
+
+// WithAllCorrectScoring sets how rounds which every participant answered
+// correctly are scored. They are ranked by speed like any other by default.
+func WithAllCorrectScoring(scoring trivia.AllCorrectScoring) Option {
+	return func(t *TriviaBot) {
+		t.allCorrect = scoring
+	}
+}
+
+// WithTieScoring sets how correct answers sent at the same time are ranked.
+// They are ranked in the order they arrived by default.
+func WithTieScoring(scoring trivia.TieScoring) Option {
+	return func(t *TriviaBot) {
+		t.ties = scoring
+	}
+}
+
+// WithRoundDurationBounds limits the time to answer each round which may be
+// picked with `trivia start -duration`, 5s to 5m by default. A zero bound is
+// not enforced.
+func WithRoundDurationBounds(min, max time.Duration) Option {
+	return func(t *TriviaBot) {
+		t.minRoundDuration = min
+		t.maxRoundDuration = max
+	}
+}
+
+// WithCountdown announces how long is left to answer each round once each of
+// the given durations remain, like 20s, 10s and 5s. Rounds are not counted
+// down by default.
+func WithCountdown(remaining ...time.Duration) Option {
+	return func(t *TriviaBot) {
+		t.countdown = append([]time.Duration{}, remaining...)
+		sort.Slice(t.countdown, func(i, j int) bool { return t.countdown[i] > t.countdown[j] })
+	}
+}
+
+// WithDoublePoints makes each scored round worth double points with the given
+// chance, from 0 to 1. Rounds are only doubled with `trivia start -double` by
+// default.
+func WithDoublePoints(chance float64) Option {
+	return func(t *TriviaBot) {
+		t.doubleChance = chance
+	}
+}
+
+// WithFinalRoundLabel sets what the last round is called when asked, "Final
+// round" by default. An empty label numbers it like any other, as in
+// "Round 5/5".
+func WithFinalRoundLabel(label string) Option {
+	return func(t *TriviaBot) {
+		t.finalRoundLabel = label
+	}
+}
+
+// WithAnswerCase sets how the text of answers is cased when shown, as
+// imported by default. Quotes wrapping an answer are never shown.
+func WithAnswerCase(casing AnswerCase) Option {
+	return func(t *TriviaBot) {
+		t.answerCase = casing
+	}
+}
+
+// WithTextAnswers sets whether whispering the text of an answer, or the
+// start of it, is accepted in place of its number. It is by default.
+func WithTextAnswers(enabled bool) Option {
+	return func(t *TriviaBot) {
+		t.textAnswers = enabled
+	}
+}
+
+// WithAnswerChanges lets players change their answer until the round closes,
+// scoring their latest. Only the first answer counts by default.
+func WithAnswerChanges() Option {
+	return func(t *TriviaBot) {
+		t.changeAnswers = true
+	}
+}
+
+// WithDistinctQuestions draws again in place of a question already in the
+// quiz, cutting the quiz short if the questions run out. Small pools can
+// repeat questions by default.
+func WithDistinctQuestions() Option {
+	return func(t *TriviaBot) {
+		t.distinctQuestions = true
+	}
+}
+
+// WithSource asks the questions of source instead of those in the database.
+// Commands managing questions, like `trivia lint`, still act on the database.
+func WithSource(source trivia.Source) Option {
+	return func(t *TriviaBot) {
+		t.source = source
+	}
+}
+
+// WithFreshness draws questions at random favoring those asked less often
+// and less recently, more strongly the higher exponent is. Questions are asked
+// in a shuffled sequence by default.
+func WithFreshness(exponent float64) Option {
+	return func(t *TriviaBot) {
+		t.freshness = exponent
+	}
+}
+
+// WithCooldownBypass lets mods, if mods is set, and users start quizzes
+// during the cooldown without `trivia start -force`, so events can run
+// quizzes back to back. Everyone waits out the cooldown by default.
+func WithCooldownBypass(mods bool, users ...string) Option {
+	return func(t *TriviaBot) {
+		t.cooldownBypassMods = mods
+		t.cooldownBypassUsers = users
+	}
+}
+
+// WithIndexes creates the indexes recommended for large question banks on
+// startup, which are otherwise only reported when missing.
+func WithIndexes() Option {
+	return func(t *TriviaBot) {
+		t.createIndexes = true
+	}
+}
+
+// WithAnswerSources takes answers from sources along with whispers, or chat
+// with WithPublicAnswers.
+func WithAnswerSources(sources ...AnswerSource) Option {
+	return func(t *TriviaBot) {
+		t.answerSources = append(t.answerSources, sources...)
+	}
+}
+
+// WithAPI serves read only JSON endpoints for the leaderboard and quiz
+// status on addr, for use in web overlays. No server is started by default.
+func WithAPI(addr string) Option {
+	return func(t *TriviaBot) {
+		t.apiAddr = addr
+	}
 }
 
 func New(
 	logger *zap.SugaredLogger,
 	url, jwt, dbPath, lboardOutputPath, lboardIngress string,
+	opts ...Option,
 ) (*TriviaBot, error) {
+	return NewFromConfig(logger, Config{
+		URL:                url,
+		JWT:                jwt,
+		DBPath:             dbPath,
+		LeaderboardPage:    lboardOutputPath,
+		LeaderboardIngress: lboardIngress,
+		Options:            opts,
+	})
+}
+
+// NewFromConfig connects to chat and opens the database as set in cfg.
+func NewFromConfig(logger *zap.SugaredLogger, cfg Config) (*TriviaBot, error) {
+	opts, err := cfg.options()
+	if err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
 	filters := []bot.MsgTypeFilter{
 		bot.JoinFilter,
 		bot.QuitFilter,
@@ -44,15 +584,18 @@ func New(
 		bot.PrivMsgSentFilter,
 	}
 
-	bot, err := bot.New(logger, url, jwt, true, filters...)
+	bot, err := bot.New(logger, cfg.URL, cfg.JWT, true, filters...)
 	if err != nil {
 		return nil, fmt.Errorf("error creating bot: %w", err)
 	}
 
-	db, err := sql.Open("sqlite3", dbPath)
+	db, err := sql.Open("sqlite3", cfg.DBPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open DB(%s): %w", dbPath, err)
+		return nil, fmt.Errorf("failed to open DB(%s): %w", cfg.DBPath, err)
 	}
+	// sqlite allows a single writer, so rooms share one connection to queue
+	// their writes rather than fail them while another is in a transaction
+	db.SetMaxOpenConns(1)
 
 	boil.SetDB(db)
 
@@ -61,46 +604,160 @@ func New(
 		return nil, fmt.Errorf("failed to create DB source: %w", err)
 	}
 
-	var lboard *trivia.Leaderboard
-	lboard, err = trivia.NewLeaderboard(logger, db)
-	if err != nil {
-		return nil, fmt.Errorf("failed to init leaderboard: %w", err)
-	}
-
 	t := &TriviaBot{
 		logger:                logger,
 		bot:                   bot,
-		source:                source,
-		leaderboard:           lboard,
-		leaderboardOutputPath: lboardOutputPath,
-		leaderboardIngress:    lboardIngress,
+		db:                    db,
+		rooms:                 map[string]*room{},
+		leaderboardOutputPath: cfg.LeaderboardPage,
+		leaderboardIngress:    cfg.LeaderboardIngress,
+		cooldown:              5 * time.Minute,
+		startDelay:            10 * time.Second,
+		roundDelay:            25 * time.Second,
+		endDelay:              5 * time.Second,
+		sendAttempts:          3,
+		sendBackoff:           500 * time.Millisecond,
+		rateLimitPause:        2 * time.Second,
+		pollDuration:          30 * time.Second,
+		skipVotes:             3,
+		speedDuration:         10 * time.Second,
+		emotes:                DefaultEmotes,
+		answerInterval:        time.Second,
+		textAnswers:           true,
+		finalRoundLabel:       "Final round",
+		startedAt:             time.Now(),
+		minRoundDuration:      5 * time.Second,
+		maxRoundDuration:      5 * time.Minute,
+		jitterRand:            rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	source.SetFreshness(t.freshness)
+	if t.source == nil {
+		t.source = source
+	}
+
+	t.bot = &retryingChat{
+		chat:     bot,
+		logger:   logger,
+		attempts: t.sendAttempts,
+		backoff:  t.sendBackoff,
+		pause:    t.rateLimitPause,
+	}
+
+	if t.announce, err = t.announcements.compile(); err != nil {
+		return nil, fmt.Errorf("invalid announcements: %w", err)
 	}
+
+	if t.createIndexes {
+		if err = trivia.CreateIndexes(context.Background(), db); err != nil {
+			return nil, fmt.Errorf("failed to create indexes: %w", err)
+		}
+	}
+	if err = trivia.CheckIndexes(context.Background(), db, logger); err != nil {
+		return nil, fmt.Errorf("failed to check indexes: %w", err)
+	}
+
+	t.listenCtx, t.stopListening = context.WithCancel(context.Background())
+	t.whispers = newWhisperAnswers(t)
+	t.answerSources = append(t.answerSources, t.whispers)
+
+	t.registerCommands()
 	bot.OnMessage(t.onMsg)
 	bot.OnPrivMessage(t.onPrivMsg)
 
+	if _, err = t.room(""); err != nil {
+		return nil, fmt.Errorf("failed to init leaderboard: %w", err)
+	}
+
 	if err = t.generateLeaderboardPage(); err != nil {
 		return nil, fmt.Errorf("failed to generate leaderboard page on startup: %w", err)
 	}
 
+	if t.apiAddr != "" {
+		listener, err := net.Listen("tcp", t.apiAddr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to listen for the api on %s: %w", t.apiAddr, err)
+		}
+		t.apiListener = listener
+
+		go func() {
+			if err := http.Serve(listener, t.apiHandler()); err != nil && !errors.Is(err, net.ErrClosed) {
+				t.logger.Errorw("api server stopped", "error", err)
+			}
+		}()
+	}
+
 	return t, nil
 }
 
 func (t *TriviaBot) Run() error {
+	t.listenForAnswers(t.listenCtx)
 	return t.bot.Run()
 }
 
+// Close cancels any running quizzes, scoring the rounds played so far, and
+// waits for them to stop before writing out the leaderboard page and closing
+// the connection to chat. It is safe to call when no quiz is running. If ctx
+// is done before the quizzes stop, its error is returned and nothing is
+// closed.
+func (t *TriviaBot) Close(ctx context.Context) error {
+	t.roomsMu.Lock()
+	for _, r := range t.rooms {
+		r.cancelQuiz()
+	}
+	t.roomsMu.Unlock()
+
+	stopped := make(chan struct{})
+	go func() {
+		t.quizzes.Wait()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		return fmt.Errorf("failed waiting for quizzes to stop: %w", ctx.Err())
+	}
+
+	if t.stopListening != nil {
+		t.stopListening()
+	}
+
+	if err := t.generateLeaderboardPage(); err != nil {
+		return fmt.Errorf("failed to generate leaderboard page on close: %w", err)
+	}
+
+	if t.apiListener != nil {
+		if err := t.apiListener.Close(); err != nil && !errors.Is(err, net.ErrClosed) {
+			return fmt.Errorf("failed to close the api listener: %w", err)
+		}
+	}
+
+	if err := t.bot.Destroy(); err != nil {
+		return fmt.Errorf("failed to close the bot: %w", err)
+	}
+
+	return nil
+}
+
 func (t *TriviaBot) onMsg(ctx context.Context, msg *bot.Msg) error {
-	// TODO: implement a FlagSet that allows passing in of quiz properties
-	// start: -duration, -category, -difficulty, -force
+	if t.publicAnswers {
+		if handled := t.onPublicAnswer(msg); handled {
+			return nil
+		}
+	}
 
-	if !strings.HasPrefix(msg.Data, "trivia") && !strings.HasPrefix(msg.Data, "!trivia") {
-		return nil
+	if r := t.existingRoom(msg.Channel); r != nil {
+		if poll := r.poll.Load(); poll != nil && poll.vote(msg.User, msg.Data) {
+			return nil
+		}
 	}
 
-	if strings.Contains(msg.Data, "help") || strings.Contains(msg.Data, "info") {
-		return t.bot.Send(
-			"Start a new round with `trivia start`. Whisper me the number beside the answer `/w trivia 2`.",
-		)
+	name, args, ok := parseCommand(msg.Data)
+	if !ok {
+		return nil
 	}
 
 	// TODO: when someone answer in public chat, send PM instructing user how to
@@ -108,42 +765,28 @@ func (t *TriviaBot) onMsg(ctx context.Context, msg *bot.Msg) error {
 	// if t.quiz.InProgress {
 	// }
 
-	if strings.Contains(msg.Data, "leaderboard") || strings.Contains(msg.Data, "highscore") {
-		return t.bot.Send(t.leaderboardIngress)
+	cmd := t.lookupCommand(name)
+	if cmd == nil {
+		return nil
 	}
 
-	if strings.Contains(msg.Data, "start") || strings.Contains(msg.Data, "new") {
-		if t.quiz != nil && t.quiz.InProgress() {
-			return t.bot.Send("a quiz is already in progress")
-		}
-
-		fiveMinAgo := time.Now().Add(-5 * time.Minute)
-		if t.lastQuizEndedAt.After(fiveMinAgo) {
-			timeLeft := t.lastQuizEndedAt.Sub(fiveMinAgo).Round(time.Second)
-			return t.bot.Send(fmt.Sprintf("on cooldown for %s PepoSleep", timeLeft))
-		}
-
-		// TODO: allow for providing quiz size
-		quiz, err := trivia.NewDefaultQuiz(t.logger, t.source)
-		if err != nil {
-			return fmt.Errorf("failed to create a new quiz: %w", err)
-		}
-		t.quiz = quiz
+	if cmd.admin && !t.isAdmin(msg) {
+		t.logger.Debugw("ignoring admin command from non mod", "user", msg.User, "command", cmd.name)
+		return nil
+	}
 
-		go func() {
-			if err = t.runQuiz(ctx, msg.User); err != nil {
-				t.logger.Fatalf("failed while running the quiz: %v", err)
-			}
-		}()
+	r, err := t.room(msg.Channel)
+	if err != nil {
+		return err
 	}
 
-	return nil
+	return cmd.run(ctx, r, msg, args)
 }
 
 func (t *TriviaBot) onPrivMsg(ctx context.Context, msg *bot.Msg) error {
 	t.logger.Debugw("private message received", "user", msg.User, "msg", msg.Data)
 
-	if strings.HasPrefix(msg.Data, "remove") && msg.IsMod() {
+	if strings.HasPrefix(msg.Data, "remove") && t.isAdmin(msg) {
 		question := strings.TrimPrefix(msg.Data, "remove ")
 		if question == "" {
 			return t.bot.SendPriv("invalid question data", msg.User)
@@ -156,158 +799,599 @@ func (t *TriviaBot) onPrivMsg(ctx context.Context, msg *bot.Msg) error {
 		return t.bot.SendPriv("PepOk removed", msg.User)
 	}
 
-	if t.quiz != nil && t.quiz.InProgress() {
-		answer, err := strconv.Atoi(msg.Data)
-		if err != nil {
-			return t.bot.SendPriv(
-				"Invalid answer NOPERS whisper the number of the answer. `/w trivia 2`",
-				msg.User,
-			)
-		}
+	if t.publicAnswers {
+		return t.bot.SendPriv("Answers are not taken in whispers, type the number of your answer in chat", msg.User)
+	}
+
+	return t.whispers.receive(ctx, msg)
+}
 
-		if !t.quiz.CurrentRound().NewParticipant(msg.User, answer-1, msg.Time) {
-			return t.bot.SendPriv("Your answer is invalid or you have already submitted one!", msg.User)
+// onPublicAnswer records a message typed in chat as an answer to the round in
+// progress there, reporting whether it was one. Only the first answer of each
+// player counts, so later ones are ignored without a reply to keep chat quiet.
+func (t *TriviaBot) onPublicAnswer(msg *bot.Msg) bool {
+	r := t.existingRoom(msg.Channel)
+	if r == nil {
+		return false
+	}
+	round := r.activeRound()
+	if round == nil {
+		return false
+	}
+
+	answer, ok := round.Question.ParseAnswer(msg.Data)
+	if !ok {
+		return false
+	}
+
+	sub := Submission{User: msg.User, Channel: r.channel, Choice: answer, Time: msg.Time}
+	if err := submitAnswer(round, sub); err != nil {
+		t.logger.Debugw("ignoring public answer", "user", msg.User, "answer", answer, "error", err)
+	}
+	return true
+}
+
+// answerRoom finds the room a whispered answer is meant for along with the
+// answer itself. Whispers carry no channel on most servers, so they go to the
+// only room with a round in progress, or when several rooms are playing, to
+// the room named before the answer (`/w trivia <channel> 2`). When no room is
+// found, the returned string is a reply for the user, if any.
+func (t *TriviaBot) answerRoom(msg *bot.Msg) (*room, string) {
+	if msg.Channel != "" {
+		r := t.existingRoom(msg.Channel)
+		if r == nil || !r.roundInProgress() {
+			return nil, ""
 		}
+		return r, msg.Data
+	}
 
-		return t.bot.SendPriv("Your answer has been locked in", msg.User)
+	playing := t.playingRooms()
+	switch len(playing) {
+	case 0:
+		return nil, ""
+	case 1:
+		return playing[0], msg.Data
 	}
 
-	return nil
+	fields := strings.Fields(msg.Data)
+	if len(fields) == 2 {
+		for _, r := range playing {
+			if r.channel == fields[0] {
+				return r, fields[1]
+			}
+		}
+	}
+
+	return nil, "Several quizzes are running, whisper the channel before your answer. `/w trivia <channel> 2`"
 }
 
-func (t *TriviaBot) runQuiz(ctx context.Context, user string) error {
-	if t.quiz.InProgress() {
+func (t *TriviaBot) runQuiz(ctx context.Context, r *room, user string) error {
+	// `trivia unstick` may replace the room's quiz before this one returns,
+	// which must only ever end and score its own
+	quiz := r.currentQuiz()
+	if quiz.InProgress() {
 		return errors.New("quiz is already in progress")
 	}
 
+	if t.maxQuizDuration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, t.maxQuizDuration)
+		defer cancel()
+	}
+
 	// insert who started the quiz to deter starting and not participating
-	t.quiz.Scoreboard[user] = 0
-	round, err := t.quiz.StartRound(t.onRoundCompletion)
+	quiz.AddPlayer(user)
+	round, err := t.startRound(r, quiz)
 	if err != nil {
 		return fmt.Errorf("failed to start the round: %w", err)
 	}
 
-	t.logger.Infof("quiz started by %s", user)
-	output := "Quiz starting soon! `/w trivia <number>` to answer."
-	if err = t.bot.Send(output); err != nil {
+	logger := r.quizLogger(nil)
+	logger.Infow("quiz started", "starter", user, "rounds", quiz.Size())
+	double := []string{}
+	for _, round := range quiz.Rounds {
+		if round.Multiplier > 1 {
+			double = append(double, fmt.Sprint(round.Num))
+		}
+	}
+	start := startData{
+		Starter: user,
+		Public:  t.publicAnswers,
+		Double:  series(double, "and", ""),
+	}
+	if t.scoringBreakdown {
+		start.Scoring = quiz.ScoringBreakdown()
+	}
+	output, err := render(t.announce.start, start)
+	if err != nil {
+		return err
+	}
+	if err = r.sendNonEssential(output); err != nil {
 		return fmt.Errorf("failed to send starting message: %w", err)
 	}
 
-	time.Sleep(10 * time.Second)
+	err = t.playRounds(ctx, r, quiz, round)
+	switch {
+	case errors.Is(err, context.Canceled):
+		logger.Warn("quiz cancelled")
+		// score the answers given so far in the unfinished round, unless
+		// `trivia unstick` reset the quiz
+		if current := quiz.CurrentRound(); current != nil {
+			current.End()
+			<-current.Done()
+		}
+	case errors.Is(err, context.DeadlineExceeded):
+		logger.Warnw("quiz ran past its maximum duration", "max", t.maxQuizDuration)
+		// score the answers given so far in the unfinished round
+		if current := quiz.CurrentRound(); current != nil {
+			current.End()
+			<-current.Done()
+		}
 
-	if err = t.runRound(ctx, round); err != nil {
-		return fmt.Errorf("error running round: %w", err)
+		if output, err = render(t.announce.timeout, timeoutData{Limit: t.maxQuizDuration}); err != nil {
+			return err
+		}
+		if err = r.sendNonEssential(output); err != nil {
+			return fmt.Errorf("failed to send timeout message: %w", err)
+		}
+	case err != nil:
+		return err
+	default:
+		time.Sleep(t.endDelay)
 	}
 
-	// continue running rounds
-	for !round.Final {
-		t.logger.Infof("running next round %d", round.Num)
-		round, err = t.quiz.StartRound(t.onRoundCompletion)
-		if err != nil {
-			return fmt.Errorf("failed to start the round: %w", err)
+	// the announcement and the leaderboard both follow the one ranking, so
+	// they can't disagree about who played
+	data := quizCompleteData{}
+	ranking := quiz.SortedScore()
+	winners := []string{}
+	for _, score := range ranking {
+		if score.Points > 0 {
+			winners = append(winners, fmt.Sprintf("%s +%d point(s)", score.Name, score.Points))
 		}
-		if err = t.runRound(ctx, round); err != nil {
-			return fmt.Errorf("error running round: %w", err)
+	}
+	if len(winners) != 0 {
+		data.Winners = series(winners, "and", "")
+		data.Tiebreak = tiebreak(ranking)
+	}
+
+	if err = t.recordScores(r, quiz, ranking); err != nil {
+		return err
+	}
+	// the archive only serves recaps, not worth failing the quiz over
+	if t.archiveQuizzes {
+		if err = r.leaderboard.RecordQuiz(quiz.State()); err != nil {
+			logger.Errorw("failed to archive quiz", "error", err)
 		}
 	}
 
-	time.Sleep(5 * time.Second)
+	if players := quiz.Players(); players > 0 {
+		data.Players = english.Plural(players, "player", "")
+	}
+	data.Emote = t.emotes.outcome(data.Winners != "")
+	t.quizzesHosted.Add(1)
+	logger.Infow("quiz complete", "participants", len(ranking), "winners", series(winners, "and", "none"))
 
-	output = "Quiz complete! The following users are awarded points: "
-	if len(t.quiz.Scoreboard) == 0 {
-		output += "No one! DuckerZ"
-	} else {
-		ss := t.quiz.Score()
-		winners := []string{}
-		for name, points := range ss {
-			if points > 0 {
-				winners = append(winners, fmt.Sprintf("%s +%d point(s)", name, points))
-			}
+	if output, err = render(t.announce.quizComplete, data); err != nil {
+		return err
+	}
+	return r.send(output)
+}
+
+// recordScores adds ranking, the players of quiz, to the room's leaderboard.
+// Nothing is written if no one played.
+func (t *TriviaBot) recordScores(r *room, quiz *trivia.Quiz, ranking []*trivia.Score) error {
+	if len(ranking) == 0 {
+		return nil
+	}
+
+	entries := map[string]int{}
+	for _, score := range ranking {
+		entries[score.Name] = score.Points
+	}
+
+	if err := r.leaderboard.Update(entries); err != nil {
+		return fmt.Errorf("failed to update leaderboard: %w", err)
+	}
+	if err := r.leaderboard.UpdateStreaks(quiz.Streaks()); err != nil {
+		return fmt.Errorf("failed to update streaks: %w", err)
+	}
+	// the published page shows the default channel's leaderboard
+	if r.channel == "" {
+		if err := t.generateLeaderboardPage(); err != nil {
+			return fmt.Errorf("failed to generated leaderboard: %w", err)
 		}
+	}
 
-		if len(winners) == 0 {
-			output += "No one! DuckerZ"
-		} else {
-			output += english.OxfordWordSeries(winners, "and")
+	return nil
+}
+
+// playRounds plays round and the rest of quiz in the room, stopping early if
+// ctx is done.
+func (t *TriviaBot) playRounds(ctx context.Context, r *room, quiz *trivia.Quiz, round *trivia.Round) error {
+	if err := sleep(ctx, t.startDelay); err != nil {
+		return err
+	}
+
+	for {
+		if err := t.runRound(ctx, r, round); err != nil {
+			return fmt.Errorf("error running round: %w", err)
+		}
+		if round.Final || quiz.NextRound() == nil {
+			return nil
 		}
 
-		if err = t.leaderboard.Update(ss); err != nil {
-			return fmt.Errorf("failed to update leaderboard: %w", err)
+		if err := t.waitBetweenRounds(ctx, r, round); err != nil {
+			return err
 		}
-		if err = t.generateLeaderboardPage(); err != nil {
-			return fmt.Errorf("failed to generated leaderboard: %w", err)
+
+		var err error
+		if round, err = t.startRound(r, quiz); err != nil {
+			return fmt.Errorf("failed to start the round: %w", err)
 		}
 	}
+}
+
+// maxRoundDelayJitter bounds WithRoundDelayJitter, keeping the delay between
+// rounds at least half of roundDelay.
+const maxRoundDelayJitter = 0.5
+
+// nextRoundDelay returns roundDelay varied by roundDelayJitter.
+func (t *TriviaBot) nextRoundDelay() time.Duration {
+	if t.roundDelayJitter <= 0 {
+		return t.roundDelay
+	}
 
-	return t.bot.Send(output)
+	// rooms wait between rounds concurrently and rand.Rand isn't safe for it
+	t.jitterMu.Lock()
+	offset := t.jitterRand.Float64()*2 - 1
+	t.jitterMu.Unlock()
+	return t.roundDelay + time.Duration(offset*t.roundDelayJitter*float64(t.roundDelay))
 }
 
-func (t *TriviaBot) runRound(ctx context.Context, round *trivia.Round) error {
-	leading := fmt.Sprintf("Round %d", round.Num)
-	if round.Final {
-		leading = "Final round"
-	} else {
-		defer func() {
-			t.logger.Info("sleeping for 25 seconds until next round")
-			time.Sleep(25 * time.Second)
-		}()
+// waitBetweenRounds waits after round for the round delay or, advancing
+// manually, for the host.
+func (t *TriviaBot) waitBetweenRounds(ctx context.Context, r *room, round *trivia.Round) error {
+	logger := r.quizLogger(round)
+	if !t.manualAdvance {
+		delay := t.nextRoundDelay()
+		logger.Debugw("waiting for the next round", "delay", delay)
+		return sleep(ctx, delay)
+	}
+
+	logger.Debug("waiting for the host to start the next round")
+	if err := r.sendNonEssential("The next round starts once the host types `trivia next`"); err != nil {
+		return fmt.Errorf("failed to announce the wait: %w", err)
+	}
+	return r.waitForAdvance(ctx)
+}
+
+// sleep pauses for d, or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// startRound starts the next round of quiz, played in the room.
+func (t *TriviaBot) startRound(r *room, quiz *trivia.Quiz) (*trivia.Round, error) {
+	round, err := quiz.StartRound(func(correct string, score []*trivia.Participant) error {
+		return t.onRoundCompletion(r, quiz, correct, score)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	t.asked.add(round.Question.ID)
+	if err = trivia.MarkAsked(context.Background(), t.db, round.Question.ID, time.Now()); err != nil {
+		t.logger.Errorw("failed to mark question as asked", "error", err)
+	}
+	return round, nil
+}
+
+// tiebreak returns how the top spot was decided when the leaders finished on
+// equal points, or nil if they didn't.
+func tiebreak(ranking []*trivia.Score) *tiebreakData {
+	if len(ranking) < 2 {
+		return nil
 	}
 
-	output := leading + ": `" + strings.ReplaceAll(round.Question.Question, "`", "'") + "`"
+	first, second := ranking[0], ranking[1]
+	if first.Points != second.Points || first.Speed == second.Speed {
+		return nil
+	}
+
+	return &tiebreakData{Winner: first.Name, RunnerUp: second.Name}
+}
+
+// formatRound renders the announcement asking round, shortening its question
+// to at most maxLength characters unless maxLength is zero.
+func (t *TriviaBot) formatRound(r *room, round *trivia.Round, maxLength int) (string, error) {
+	data := roundData{
+		Num:        round.Num,
+		Total:      r.currentQuiz().Size(),
+		Final:      round.Final || r.currentQuiz().NextRound() == nil,
+		FinalLabel: t.finalRoundLabel,
+		WarmUp:     round.WarmUp,
+		Double:     round.Multiplier > 1,
+		Media:      round.Question.Media,
+		Category:   orUnknown(round.Question.Category),
+		Difficulty: orUnknown(round.Question.Difficulty),
+		Type:       orUnknown(round.Question.Type),
+	}
+	if t.attribution {
+		data.Source = round.Question.Source
+	}
+	question := strings.ReplaceAll(round.Question.Question, "`", "'")
+	data.Question, data.Truncated = truncate(question, maxLength)
+
+	// quizzes skip questions with oversized choices, but keep any which
+	// slipped through from flooding chat
+	choiceLength := 0
+	if length := round.Question.ChoicesLength(); length > trivia.MaxChoicesLength {
+		choiceLength = trivia.MaxChoicesLength / len(round.Question.Answers)
+		r.quizLogger(round).Warnw("shortening oversized choices", "length", length)
+	}
 	// answers have already been shuffled
 	for idx, ans := range round.Question.Answers {
-		output += fmt.Sprintf(" `%d) %s`", idx+1, ans.Value)
+		value, _ := truncate(t.answerCase.show(ans.Value), choiceLength)
+		data.Answers = append(data.Answers, answerData{Num: idx + 1, Value: value})
+	}
+
+	return render(t.announce.round, data)
+}
+
+// truncate shortens s to at most max characters, ending in an ellipsis,
+// cutting at the last space which fits if there is one. s is returned whole
+// if it fits or max is zero, reporting whether it was cut.
+func truncate(s string, max int) (string, bool) {
+	runes := []rune(s)
+	if max <= 0 || len(runes) <= max {
+		return s, false
+	}
+
+	// leave room for the ellipsis
+	cut := max - 1
+	if space := strings.LastIndex(string(runes[:cut+1]), " "); space > 0 {
+		cut = utf8.RuneCountInString(s[:space])
+	}
+	return strings.TrimRight(string(runes[:cut]), " ") + "…", true
+}
+
+func (t *TriviaBot) runRound(ctx context.Context, r *room, round *trivia.Round) error {
+	output, err := t.formatRound(r, round, t.maxQuestionLength)
+	if err != nil {
+		return err
 	}
 
-	t.logger.Infow("running round and waiting for completion", "output", output)
-	if err := t.bot.Send(output); err != nil {
+	logger := r.quizLogger(round)
+	logger.Infow("round started", "output", output, "warm_up", round.WarmUp)
+	if err = r.send(output); err != nil {
 		return fmt.Errorf("failed to send round start msgs: %w", err)
 	}
 
-	round.StartedAt = time.Now()
+	if err = t.notifyJudges(round); err != nil {
+		return err
+	}
+
+	round.Open(time.Now())
+
+	if err = t.countDown(ctx, r, round); err != nil {
+		return err
+	}
+
+	select {
+	case <-round.Done():
+		logger.Debug("round is no longer in progress")
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	return nil
+}
+
+// countDown announces the time left in round at each of the configured
+// countdown marks, returning early if the round ends first.
+func (t *TriviaBot) countDown(ctx context.Context, r *room, round *trivia.Round) error {
+	for _, left := range t.countdown {
+		if time.Until(round.ClosesAt().Add(-left)) <= 0 {
+			// the round is shorter than this mark
+			continue
+		}
+
+		if done, err := waitForMark(ctx, round, left); done || err != nil {
+			return err
+		}
+
+		output, err := render(t.announce.countdown, countdownData{Num: round.Num, Left: left})
+		if err != nil {
+			return err
+		}
+		if err = r.sendNonEssential(output); err != nil {
+			return fmt.Errorf("failed to send countdown: %w", err)
+		}
+	}
 
+	return nil
+}
+
+// waitForMark waits until left remains of round, reporting whether the round
+// ended first. The time is checked again once waited, in case the round was
+// paused in the meantime.
+func waitForMark(ctx context.Context, round *trivia.Round, left time.Duration) (bool, error) {
 	for {
-		if !t.quiz.InProgress() {
-			t.logger.Info("round is no longer in progress.. breaking")
-			break
+		wait := time.Until(round.ClosesAt().Add(-left))
+		if wait <= 0 {
+			return false, nil
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-round.Done():
+			timer.Stop()
+			return true, nil
+		case <-ctx.Done():
+			timer.Stop()
+			return false, ctx.Err()
+		}
+	}
+}
+
+func (t *TriviaBot) notifyJudges(round *trivia.Round) error {
+	if len(t.judges) == 0 {
+		return nil
+	}
+
+	idx, correct := round.Question.Correct()
+	if correct == nil {
+		return nil
+	}
+
+	output := fmt.Sprintf("Round %d answer: %s", round.Num, t.answerCase.choice(idx, correct.Value))
+	for _, judge := range t.judges {
+		if err := t.bot.SendPriv(output, judge); err != nil {
+			return fmt.Errorf("failed to send answer to judge %s: %w", judge, err)
 		}
-		time.Sleep(500 * time.Millisecond)
 	}
 
 	return nil
 }
 
-func (t *TriviaBot) onRoundCompletion(correct string, score []*trivia.Participant) error {
-	output := fmt.Sprintf("Round complete! The correct answer is %s.", correct)
-	defer func() {
-		t.lastQuizEndedAt = time.Now()
-		t.logger.Info(output)
-	}()
+func (t *TriviaBot) onRoundCompletion(r *room, quiz *trivia.Quiz, correct string, score []*trivia.Participant) error {
+	r.lastQuizEndedAt = time.Now()
+
+	// nothing is left to announce once `trivia unstick` reset the quiz
+	round := quiz.CurrentRound()
+	if round == nil {
+		return nil
+	}
+	if round.Skipped() {
+		return t.announceSkipped(r, round)
+	}
+
+	logger := r.quizLogger(round)
+	// a player's history is not worth failing the quiz over
+	if round.WarmUp {
+		logger.Debug("not recording answers of the warm-up round")
+	} else if err := r.leaderboard.RecordAnswers(round); err != nil {
+		logger.Errorw("failed to record answers", "error", err)
+	}
+	if t.answerLog != nil {
+		if err := t.answerLog.write(round); err != nil {
+			logger.Errorw("failed to log answers", "error", err)
+		}
+	}
 
-	if len(score) == 0 {
-		output += " No one answered correctly DuckerZ"
-		return t.bot.Send(output)
+	answers := round.Answers()
+	data := roundCompleteData{
+		Num:      round.Num,
+		Correct:  correct,
+		Answered: len(answers),
+	}
+	// answers keep the shuffled order they were asked in
+	if idx, ans := round.Question.Correct(); ans != nil {
+		data.Correct = t.answerCase.choice(idx, ans.Value)
+		data.CorrectNum = idx + 1
+		data.CorrectValue = t.answerCase.show(ans.Value)
+		for _, p := range answers {
+			if p.Choice == idx {
+				data.AnsweredCorrectly++
+			}
+		}
 	}
 
-	var line string
 	entries := []string{}
+	positions := trivia.Positions(score, t.ties)
 	for i := 0; i < len(score) && i <= 2; i++ {
 		s := score[i]
-		line = fmt.Sprintf("%s %s", humanize.Ordinal(i+1), s.Name)
+		line := fmt.Sprintf("%s %s", humanize.Ordinal(positions[i]+1), s.Name)
 
 		if i == 0 {
 			rounded := s.TimeToSubmission.Round(time.Millisecond)
-			line = fmt.Sprintf(" (%s to answer) %s", rounded, line)
-		} else if i > 0 && len(score) >= 2 {
+			line = fmt.Sprintf("(%s to answer) %s", rounded, line)
+		} else {
 			diff := s.TimeToSubmission - score[i-1].TimeToSubmission
-			line = fmt.Sprintf(" (+%s) %s", diff.Round(time.Millisecond), line)
+			line = fmt.Sprintf("(+%s) %s", diff.Round(time.Millisecond), line)
 		}
 
 		entries = append(entries, line)
 	}
 
-	output += english.OxfordWordSeries(entries, "and")
-	return t.bot.Send(output)
+	data.Winners = series(entries, "and", "")
+	data.Emote = t.emotes.outcome(len(entries) != 0)
+
+	output, err := render(t.announce.roundComplete, data)
+	if err != nil {
+		return err
+	}
+	if t.answerCounts {
+		output += fmt.Sprintf(" (%d answered, %d correct)", data.Answered, data.AnsweredCorrectly)
+	}
+
+	logger.Infow("round complete",
+		"participants", data.Answered,
+		"correct", data.CorrectValue,
+		"winners", len(score),
+		"suspicious", round.Suspicious(),
+		"output", output,
+	)
+	if err = r.send(output); err != nil {
+		return err
+	}
+
+	if t.answerFeedback {
+		t.sendFeedback(round, answers)
+	}
+	return nil
+}
+
+// announceSkipped announces that round was skipped, revealing its answer
+// unless it is asked again.
+func (t *TriviaBot) announceSkipped(r *room, round *trivia.Round) error {
+	output := fmt.Sprintf("Round %d was skipped", round.Num)
+	if round.WarmUp {
+		output = "The warm-up round was skipped"
+	}
+
+	if num := round.RequeuedAs(); num > 0 {
+		output += fmt.Sprintf(", it is asked again as round %d", num)
+	} else if idx, ans := round.Question.Correct(); ans != nil {
+		output += fmt.Sprintf(", the correct answer was %s", t.answerCase.choice(idx, ans.Value))
+	}
+
+	r.quizLogger(round).Infow("round skipped", "output", output)
+	return r.send(output)
+}
+
+// sendFeedback whispers each of answers, those to round, whether it was
+// correct. A whisper which fails is logged rather than holding up the rest.
+func (t *TriviaBot) sendFeedback(round *trivia.Round, answers []*trivia.Participant) {
+	idx, ans := round.Question.Correct()
+	if ans == nil {
+		return
+	}
+	correct := t.answerCase.choice(idx, ans.Value)
+
+	name := fmt.Sprintf("round %d", round.Num)
+	if round.WarmUp {
+		name = "the warm-up round"
+	}
+	for _, p := range answers {
+		output := fmt.Sprintf("You got %s wrong, the answer was %s", name, correct)
+		if p.Choice == idx {
+			output = fmt.Sprintf("You got %s right, the answer was %s", name, correct)
+		}
+		if err := t.bot.SendPriv(output, p.Name); err != nil {
+			t.logger.Errorw("failed to send answer feedback", "user", p.Name, "error", err)
+		}
+	}
 }
 
 const tpl = `
@@ -479,7 +1563,12 @@ const tpl = `
 </html>`
 
 func (t *TriviaBot) generateLeaderboardPage() error {
-	highscores, err := t.leaderboard.Highscores(0)
+	r, err := t.room("")
+	if err != nil {
+		return err
+	}
+
+	highscores, err := r.leaderboard.Highscores(0)
 	if err != nil {
 		return fmt.Errorf("failed to get highscores: %w", err)
 	}
@@ -0,0 +1,2938 @@
+package triviabot
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jbpratt/bots/internal/bot"
+	"github.com/jbpratt/bots/internal/trivia"
+	"github.com/jbpratt/bots/internal/trivia/models"
+	"github.com/volatiletech/null/v8"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type chatMsg struct {
+	channel string
+	msg     string
+	at      time.Time
+}
+
+type privMsg struct {
+	user string
+	msg  string
+}
+
+// fakeChat records everything the bot sends instead of talking to a server.
+type fakeChat struct {
+	mu        sync.Mutex
+	sent      []chatMsg
+	priv      []privMsg
+	destroyed bool
+}
+
+func (c *fakeChat) SendChannel(msg, channel string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sent = append(c.sent, chatMsg{channel, msg, time.Now()})
+	return nil
+}
+
+func (c *fakeChat) SendPriv(msg, user string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.priv = append(c.priv, privMsg{user, msg})
+	return nil
+}
+
+func (c *fakeChat) Run() error {
+	return nil
+}
+
+func (c *fakeChat) Destroy() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.destroyed = true
+	return nil
+}
+
+// messages returns the messages sent to channel.
+func (c *fakeChat) messages(channel string) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	msgs := []string{}
+	for _, m := range c.sent {
+		if m.channel == channel {
+			msgs = append(msgs, m.msg)
+		}
+	}
+	return msgs
+}
+
+func (c *fakeChat) privMessages() []privMsg {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]privMsg{}, c.priv...)
+}
+
+// staticSource hands out copies of the same question forever.
+type staticSource struct {
+	question trivia.Question
+}
+
+func (s *staticSource) Question() (*trivia.Question, error) {
+	q := s.question
+	q.Answers = []*trivia.Answer{}
+	for _, ans := range s.question.Answers {
+		a := *ans
+		q.Answers = append(q.Answers, &a)
+	}
+	return &q, nil
+}
+
+func newStaticSource() *staticSource {
+	return &staticSource{trivia.Question{
+		Question: "What is the capital of France?",
+		Answers: []*trivia.Answer{
+			{Value: "Paris", Correct: true},
+			{Value: "Lyon"},
+			{Value: "Nice"},
+		},
+	}}
+}
+
+// newTestBot returns a bot backed by a fresh database which plays without
+// any delays between rounds.
+func newTestBot(t *testing.T, opts ...Option) (*TriviaBot, *fakeChat) {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "trivia.db"))
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	db.SetMaxOpenConns(1)
+	boil.SetDB(db)
+
+	chat := &fakeChat{}
+	tb := &TriviaBot{
+		logger:                zap.NewNop().Sugar(),
+		bot:                   chat,
+		db:                    db,
+		source:                newStaticSource(),
+		rooms:                 map[string]*room{},
+		leaderboardOutputPath: filepath.Join(t.TempDir(), "index.html"),
+		emotes:                DefaultEmotes,
+		cooldown:              5 * time.Minute,
+		finalRoundLabel:       "Final round",
+		skipVotes:             3,
+		startedAt:             time.Now(),
+		jitterRand:            rand.New(rand.NewSource(1)),
+	}
+	for _, opt := range opts {
+		opt(tb)
+	}
+	if tb.announce, err = tb.announcements.compile(); err != nil {
+		t.Fatalf("invalid announcements: %v", err)
+	}
+	tb.registerCommands()
+
+	tb.listenCtx, tb.stopListening = context.WithCancel(context.Background())
+	t.Cleanup(tb.stopListening)
+	tb.whispers = newWhisperAnswers(tb)
+	tb.answerSources = append(tb.answerSources, tb.whispers)
+	tb.listenForAnswers(tb.listenCtx)
+	return tb, chat
+}
+
+func newTestRoom(t *testing.T, tb *TriviaBot, channel string) *room {
+	t.Helper()
+
+	r, err := tb.room(channel)
+	if err != nil {
+		t.Fatalf("failed to create room: %v", err)
+	}
+	return r
+}
+
+func newTestQuiz(t *testing.T, tb *TriviaBot, r *room, size int, duration time.Duration) {
+	t.Helper()
+
+	quiz, err := trivia.NewQuiz(tb.logger, size, duration, tb.source)
+	if err != nil {
+		t.Fatalf("failed to create quiz: %v", err)
+	}
+	r.setQuiz(quiz)
+}
+
+// playRound runs a single round of the room's quiz to completion.
+func playRound(t *testing.T, tb *TriviaBot, r *room) *trivia.Round {
+	t.Helper()
+
+	round, err := tb.startRound(r, r.currentQuiz())
+	if err != nil {
+		t.Fatalf("failed to start round: %v", err)
+	}
+	if err = tb.runRound(context.Background(), r, round); err != nil {
+		t.Fatalf("failed to run round: %v", err)
+	}
+	return round
+}
+
+// waitForRound blocks until a round is in progress in r.
+func waitForRound(t *testing.T, r *room) *trivia.Round {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if round := r.activeRound(); round != nil && round.IsOpen() {
+			return round
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for a round to start")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// answer whispers the correct answer of the current round in r as user.
+func answer(t *testing.T, tb *TriviaBot, r *room, user string) {
+	t.Helper()
+
+	round := waitForRound(t, r)
+	idx, _ := round.Question.Correct()
+	msg := &bot.Msg{
+		User:    user,
+		Channel: r.channel,
+		Data:    fmt.Sprint(idx + 1),
+		Time:    time.Now().UnixMilli(),
+	}
+	if err := tb.onPrivMsg(context.Background(), msg); err != nil {
+		t.Fatalf("failed to answer: %v", err)
+	}
+}
+
+func TestCommandsHaveHelp(t *testing.T) {
+	tb, _ := newTestBot(t)
+
+	for _, cmd := range tb.commands {
+		if cmd.description == "" {
+			t.Errorf("command %q has no description", cmd.name)
+		}
+		if !strings.HasPrefix(cmd.help(), "`trivia "+cmd.name) {
+			t.Errorf("command %q help does not start with its usage: %q", cmd.name, cmd.help())
+		}
+	}
+
+	start := tb.lookupCommand("start").help()
+	for _, flag := range []string{"-duration", "-category", "-difficulty", "-size", "-force"} {
+		if !strings.Contains(start, flag) {
+			t.Errorf("start help is missing %s: %q", flag, start)
+		}
+	}
+}
+
+func TestJudgesReceiveAnswer(t *testing.T) {
+	tb, chat := newTestBot(t, WithJudges("judy", "jules"))
+	r := newTestRoom(t, tb, "")
+	newTestQuiz(t, tb, r, 1, 10*time.Millisecond)
+
+	round := playRound(t, tb, r)
+	idx, _ := round.Question.Correct()
+	want := fmt.Sprintf("`%d) Paris`", idx+1)
+
+	judged := map[string]bool{}
+	for _, pm := range chat.privMessages() {
+		if pm.user != "judy" && pm.user != "jules" {
+			t.Errorf("non-judge %s was whispered %q", pm.user, pm.msg)
+		}
+		if !strings.Contains(pm.msg, want) {
+			t.Errorf("judge %s was whispered %q, want it to contain %q", pm.user, pm.msg, want)
+		}
+		judged[pm.user] = true
+	}
+	if len(judged) != 2 {
+		t.Errorf("expected both judges to be whispered, got %v", judged)
+	}
+
+	for _, msg := range chat.messages("") {
+		if strings.HasPrefix(msg, "Final round") && strings.Contains(msg, "answer") {
+			t.Errorf("round message leaks the answer: %q", msg)
+		}
+	}
+}
+
+func TestNoJudgesByDefault(t *testing.T) {
+	tb, chat := newTestBot(t)
+	r := newTestRoom(t, tb, "")
+	newTestQuiz(t, tb, r, 1, 10*time.Millisecond)
+
+	playRound(t, tb, r)
+
+	if pms := chat.privMessages(); len(pms) != 0 {
+		t.Errorf("expected no whispers without judges, got %v", pms)
+	}
+}
+
+func TestRoomsPlayIndependently(t *testing.T) {
+	tb, chat := newTestBot(t)
+	rooms := map[string]*room{
+		"a": newTestRoom(t, tb, "a"),
+		"b": newTestRoom(t, tb, "b"),
+	}
+	players := map[string]string{"a": "alice", "b": "bob"}
+
+	var wg sync.WaitGroup
+	for channel, r := range rooms {
+		newTestQuiz(t, tb, r, 1, 200*time.Millisecond)
+
+		wg.Add(1)
+		go func(r *room, starter string) {
+			defer wg.Done()
+			if err := tb.runQuiz(context.Background(), r, starter); err != nil {
+				t.Errorf("failed to run quiz: %v", err)
+			}
+		}(r, "starter-"+channel)
+	}
+
+	for channel, r := range rooms {
+		answer(t, tb, r, players[channel])
+	}
+	wg.Wait()
+
+	for channel, r := range rooms {
+		other := "a"
+		if channel == "a" {
+			other = "b"
+		}
+
+		score := r.quiz.Score()
+		if score[players[channel]] == 0 {
+			t.Errorf("room %s did not score its own player: %v", channel, score)
+		}
+		if _, ok := score[players[other]]; ok {
+			t.Errorf("room %s scored a player of room %s: %v", channel, other, score)
+		}
+
+		for _, msg := range chat.messages(channel) {
+			if strings.Contains(msg, players[other]) {
+				t.Errorf("room %s was sent a message about room %s: %q", channel, other, msg)
+			}
+		}
+
+		highscores, err := r.leaderboard.Highscores(0)
+		if err != nil {
+			t.Fatalf("failed to get highscores: %v", err)
+		}
+		for _, user := range highscores {
+			if user.Name == players[other] {
+				t.Errorf("leaderboard of room %s contains %s", channel, user.Name)
+			}
+		}
+	}
+}
+
+// startRound starts the next round of the room's quiz without waiting for it
+// to complete, as runRound would.
+func startRound(t *testing.T, tb *TriviaBot, r *room) *trivia.Round {
+	t.Helper()
+
+	round, err := tb.startRound(r, r.currentQuiz())
+	if err != nil {
+		t.Fatalf("failed to start round: %v", err)
+	}
+	round.Open(time.Now())
+	return round
+}
+
+// finishRound waits for the current round of r to complete.
+func finishRound(t *testing.T, r *room) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for r.quiz.InProgress() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the round to complete")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// whisper sends data to the bot as a private message from user.
+func whisper(t *testing.T, tb *TriviaBot, user, data string) {
+	t.Helper()
+
+	msg := &bot.Msg{User: user, Data: data, Time: time.Now().UnixMilli()}
+	if err := tb.onPrivMsg(context.Background(), msg); err != nil {
+		t.Fatalf("failed to whisper %q: %v", data, err)
+	}
+}
+
+// say sends data to the bot as a chat message from user in channel.
+func say(t *testing.T, tb *TriviaBot, channel, user, data string) {
+	t.Helper()
+
+	msg := &bot.Msg{User: user, Channel: channel, Data: data, Time: time.Now().UnixMilli()}
+	if err := tb.onMsg(context.Background(), msg); err != nil {
+		t.Fatalf("failed to say %q: %v", data, err)
+	}
+}
+
+func lastMessage(chat *fakeChat, channel string) string {
+	msgs := chat.messages(channel)
+	if len(msgs) == 0 {
+		return ""
+	}
+	return msgs[len(msgs)-1]
+}
+
+func TestOddsAfterRoundCloses(t *testing.T) {
+	tb, chat := newTestBot(t)
+	r := newTestRoom(t, tb, "")
+	newTestQuiz(t, tb, r, 1, 100*time.Millisecond)
+
+	round := startRound(t, tb, r)
+	correct, _ := round.Question.Correct()
+	wrong := (correct + 1) % len(round.Question.Answers)
+
+	picks := map[string]int{"a": correct, "b": correct, "c": correct, "d": wrong, "e": wrong}
+	for user, pick := range picks {
+		whisper(t, tb, user, fmt.Sprint(pick+1))
+	}
+
+	say(t, tb, "", "f", "trivia odds")
+	if got := lastMessage(chat, ""); !strings.Contains(got, "once the round closes") {
+		t.Errorf("odds were revealed before the round closed: %q", got)
+	}
+
+	finishRound(t, r)
+
+	if round.Votes[correct] != 3 || round.Votes[wrong] != 2 {
+		t.Errorf("unexpected votes %v", round.Votes)
+	}
+
+	say(t, tb, "", "f", "trivia odds")
+	got := lastMessage(chat, "")
+	for _, want := range []string{
+		fmt.Sprintf("Most picked: `%d) Paris` (60%%)", correct+1),
+		fmt.Sprintf("`%d) %s` 40%%", wrong+1, round.Question.Answers[wrong].Value),
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("odds %q do not contain %q", got, want)
+		}
+	}
+}
+
+func TestCustomAnnouncements(t *testing.T) {
+	tb, chat := newTestBot(t, WithAnnouncements(Announcements{
+		Start:         "{{ .Starter }} wants to play",
+		Round:         "Q{{ .Num }}: {{ .Question }}{{ range .Answers }} [{{ .Num }}] {{ .Value }}{{ end }}",
+		RoundComplete: "It was {{ .Correct }}",
+	}))
+	r := newTestRoom(t, tb, "")
+	newTestQuiz(t, tb, r, 1, 10*time.Millisecond)
+
+	if err := tb.runQuiz(context.Background(), r, "alice"); err != nil {
+		t.Fatalf("failed to run quiz: %v", err)
+	}
+
+	msgs := chat.messages("")
+	if len(msgs) != 4 {
+		t.Fatalf("expected 4 announcements, got %q", msgs)
+	}
+	if msgs[0] != "alice wants to play" {
+		t.Errorf("unexpected start announcement %q", msgs[0])
+	}
+	if !strings.HasPrefix(msgs[1], "Q1: What is the capital of France? [1] ") {
+		t.Errorf("unexpected round announcement %q", msgs[1])
+	}
+	if !strings.HasPrefix(msgs[2], "It was `") {
+		t.Errorf("unexpected round complete announcement %q", msgs[2])
+	}
+	// empty templates keep the default wording
+	if msgs[3] != "Quiz complete! The following users are awarded points: No one! DuckerZ" {
+		t.Errorf("unexpected quiz complete announcement %q", msgs[3])
+	}
+}
+
+func TestRoundMedia(t *testing.T) {
+	const media = "https://example.com/eiffel.jpg"
+
+	for _, tc := range []struct {
+		name  string
+		media string
+	}{
+		{"with media", media},
+		{"without media", ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tb, chat := newTestBot(t)
+			tb.source.(*staticSource).question.Media = tc.media
+			r := newTestRoom(t, tb, "")
+			newTestQuiz(t, tb, r, 1, 10*time.Millisecond)
+
+			playRound(t, tb, r)
+
+			msgs := chat.messages("")
+			if len(msgs) == 0 {
+				t.Fatal("no round announcement was sent")
+			}
+			got := msgs[0]
+			if tc.media != "" && !strings.HasSuffix(got, " "+tc.media) {
+				t.Errorf("round announcement %q does not link %q", got, tc.media)
+			}
+			if tc.media == "" && strings.Contains(got, "https://") {
+				t.Errorf("round announcement %q links media", got)
+			}
+		})
+	}
+}
+
+func TestRevealMatchesAskedNumber(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		roundComplete string
+		want          string
+	}{
+		{"default", "", "The correct answer is `%d) Paris`."},
+		{"custom", "{{ .CorrectNum }} - {{ .CorrectValue }}", "%d - Paris"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tb, chat := newTestBot(t, WithAnnouncements(Announcements{RoundComplete: tc.roundComplete}))
+			r := newTestRoom(t, tb, "")
+			newTestQuiz(t, tb, r, 1, 10*time.Millisecond)
+
+			round := playRound(t, tb, r)
+			idx, _ := round.Question.Correct()
+
+			msgs := chat.messages("")
+			if len(msgs) != 2 {
+				t.Fatalf("expected a round and a reveal announcement, got %q", msgs)
+			}
+			asked := fmt.Sprintf("`%d) Paris`", idx+1)
+			if !strings.Contains(msgs[0], asked) {
+				t.Fatalf("round announcement %q does not ask %s", msgs[0], asked)
+			}
+			if want := fmt.Sprintf(tc.want, idx+1); !strings.Contains(msgs[1], want) {
+				t.Errorf("reveal %q does not contain %q", msgs[1], want)
+			}
+		})
+	}
+}
+
+func TestMaxQuizDuration(t *testing.T) {
+	tb, chat := newTestBot(t, WithMaxQuizDuration(300*time.Millisecond))
+	r := newTestRoom(t, tb, "")
+	newTestQuiz(t, tb, r, 3, time.Minute)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- tb.runQuiz(context.Background(), r, "starter")
+	}()
+
+	answer(t, tb, r, "alice")
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("failed to run quiz: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("quiz was not cut short by its maximum duration")
+	}
+
+	msgs := chat.messages("")
+	if len(msgs) < 2 || !strings.HasPrefix(msgs[len(msgs)-2], "Out of time!") {
+		t.Errorf("expected a timeout before the results, got %q", msgs)
+	}
+	if got := lastMessage(chat, ""); !strings.Contains(got, "alice +6 point(s)") {
+		t.Errorf("results so far were not announced: %q", got)
+	}
+
+	highscores, err := r.leaderboard.Highscores(0)
+	if err != nil {
+		t.Fatalf("failed to get highscores: %v", err)
+	}
+	if len(highscores) == 0 || highscores[0].Name != "alice" || highscores[0].Points != 6 {
+		t.Errorf("partial scores were not saved to the leaderboard: %v", highscores)
+	}
+}
+
+func TestHistoryCommand(t *testing.T) {
+	tb, chat := newTestBot(t)
+	r := newTestRoom(t, tb, "")
+	newTestQuiz(t, tb, r, 2, 100*time.Millisecond)
+
+	for _, correct := range []bool{true, false} {
+		round := startRound(t, tb, r)
+		idx, _ := round.Question.Correct()
+		if !correct {
+			idx = (idx + 1) % len(round.Question.Answers)
+		}
+		whisper(t, tb, "alice", fmt.Sprint(idx+1))
+		finishRound(t, r)
+	}
+
+	say(t, tb, "", "alice", "trivia history")
+	if got, want := lastMessage(chat, ""), "alice: 1/2 correct (50%), last 2: ✗ ✓"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	say(t, tb, "", "alice", "trivia history bob")
+	if got := lastMessage(chat, ""); got != "bob has not answered any questions yet" {
+		t.Errorf("unexpected history of bob %q", got)
+	}
+}
+
+func TestCustomEmotes(t *testing.T) {
+	tb, chat := newTestBot(t, WithEmotes(Emotes{Correct: ":tada:", NoCorrect: ":duck:", Cooldown: ":zzz:"}))
+	r := newTestRoom(t, tb, "")
+	newTestQuiz(t, tb, r, 2, 100*time.Millisecond)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- tb.runQuiz(context.Background(), r, "starter")
+	}()
+	answer(t, tb, r, "alice")
+	if err := <-done; err != nil {
+		t.Fatalf("failed to run quiz: %v", err)
+	}
+
+	msgs := chat.messages("")
+	if len(msgs) != 6 {
+		t.Fatalf("expected 6 announcements, got %q", msgs)
+	}
+	if !strings.HasSuffix(msgs[2], " :tada:") {
+		t.Errorf("correct round does not react with the correct emote: %q", msgs[2])
+	}
+	if !strings.HasSuffix(msgs[4], "No one answered correctly :duck:") {
+		t.Errorf("round without correct answers does not react with its emote: %q", msgs[4])
+	}
+	if !strings.HasSuffix(msgs[5], " :tada:") {
+		t.Errorf("quiz with winners does not react with the correct emote: %q", msgs[5])
+	}
+
+	say(t, tb, "", "bob", "trivia start")
+	if got := lastMessage(chat, ""); !strings.HasSuffix(got, " :zzz:") {
+		t.Errorf("cooldown does not react with its emote: %q", got)
+	}
+	for _, msg := range chat.messages("") {
+		if strings.Contains(msg, "DuckerZ") || strings.Contains(msg, "PepoSleep") {
+			t.Errorf("announcement %q uses a default emote", msg)
+		}
+	}
+}
+
+func TestSimultaneousStartsLaunchOneQuiz(t *testing.T) {
+	tb, chat := newTestBot(t)
+	r := newTestRoom(t, tb, "")
+
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for _, user := range []string{"alice", "bob", "carol"} {
+		wg.Add(1)
+		go func(user string) {
+			defer wg.Done()
+			<-start
+			say(t, tb, "", user, "trivia start -size 1 -duration 50ms")
+		}(user)
+	}
+	close(start)
+	wg.Wait()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for r.running.Load() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the quiz to finish")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	started, refused := 0, 0
+	for _, msg := range chat.messages("") {
+		switch {
+		case strings.HasPrefix(msg, "Quiz starting soon!"):
+			started++
+		case msg == "a quiz is already in progress":
+			refused++
+		}
+	}
+	if started != 1 || refused != 2 {
+		t.Errorf("expected 1 quiz to start and 2 to be refused, got %d and %d", started, refused)
+	}
+}
+
+func TestPublicAnswers(t *testing.T) {
+	tb, chat := newTestBot(t, WithPublicAnswers())
+	r := newTestRoom(t, tb, "")
+	newTestQuiz(t, tb, r, 1, 100*time.Millisecond)
+
+	round := startRound(t, tb, r)
+	correct, _ := round.Question.Correct()
+	wrong := (correct + 1) % len(round.Question.Answers)
+
+	say(t, tb, "", "alice", fmt.Sprint(correct+1))
+	say(t, tb, "", "alice", fmt.Sprint(wrong+1))
+	say(t, tb, "", "bob", fmt.Sprintf(" %d ", wrong+1))
+	whisper(t, tb, "carol", fmt.Sprint(correct+1))
+	finishRound(t, r)
+
+	choices := map[string]int{}
+	for _, p := range round.Answers() {
+		choices[p.Name] = p.Choice
+	}
+	if len(choices) != 2 || choices["alice"] != correct || choices["bob"] != wrong {
+		t.Errorf("expected the first public answers of alice and bob, got %v", choices)
+	}
+
+	pms := chat.privMessages()
+	if len(pms) != 1 || pms[0].user != "carol" || !strings.Contains(pms[0].msg, "in chat") {
+		t.Errorf("expected carol's whisper to be refused, got %v", pms)
+	}
+}
+
+func TestWarmUpRoundLabels(t *testing.T) {
+	tb, chat := newTestBot(t)
+	r := newTestRoom(t, tb, "")
+
+	say(t, tb, "", "alice", "trivia start -size 2 -duration 10ms -warmup")
+	deadline := time.Now().Add(5 * time.Second)
+	for r.running.Load() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the quiz to finish")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	rounds := []string{}
+	for _, msg := range chat.messages("") {
+		if strings.Contains(msg, "What is the capital of France?") {
+			rounds = append(rounds, msg)
+		}
+	}
+	if len(rounds) != 3 {
+		t.Fatalf("expected 3 rounds, got %q", rounds)
+	}
+	for i, prefix := range []string{"Warm-up round, no points:", "Round 1/2:", "Final round:"} {
+		if !strings.HasPrefix(rounds[i], prefix) {
+			t.Errorf("round %d: expected %q to start with %q", i, rounds[i], prefix)
+		}
+	}
+}
+
+func TestQuizLifecycleLogFields(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	tb, _ := newTestBot(t)
+	tb.logger = zap.New(core).Sugar()
+	r := newTestRoom(t, tb, "a")
+	newTestQuiz(t, tb, r, 1, 100*time.Millisecond)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- tb.runQuiz(context.Background(), r, "starter")
+	}()
+	answer(t, tb, r, "alice")
+	if err := <-done; err != nil {
+		t.Fatalf("failed to run quiz: %v", err)
+	}
+
+	for event, want := range map[string]map[string]any{
+		"quiz started":   {"starter": "starter", "rounds": int64(1)},
+		"round started":  {"round_num": int64(1)},
+		"round complete": {"round_num": int64(1), "participants": int64(1), "correct": "Paris", "winners": int64(1)},
+		"quiz complete":  {"participants": int64(2)},
+	} {
+		entries := logs.FilterMessage(event).All()
+		if len(entries) != 1 {
+			t.Errorf("expected one %q event, got %d", event, len(entries))
+			continue
+		}
+
+		fields := entries[0].ContextMap()
+		want["quiz_id"] = r.quiz.ID
+		want["channel"] = "a"
+		for key, value := range want {
+			if fields[key] != value {
+				t.Errorf("%q event: expected %s=%v, got %v", event, key, value, fields[key])
+			}
+		}
+	}
+}
+
+func TestBooleanAnswerWords(t *testing.T) {
+	tb, chat := newTestBot(t)
+	tb.source = &staticSource{trivia.Question{
+		Question: "The Eiffel Tower is in Paris.",
+		Type:     "boolean",
+		Answers: []*trivia.Answer{
+			{Value: "False"},
+			{Value: "True", Correct: true},
+		},
+	}}
+	r := newTestRoom(t, tb, "")
+	newTestQuiz(t, tb, r, 1, 100*time.Millisecond)
+
+	round := startRound(t, tb, r)
+	whisper(t, tb, "alice", "1")
+	whisper(t, tb, "bob", " TRUE ")
+	whisper(t, tb, "carol", "false")
+	whisper(t, tb, "dave", "maybe")
+	finishRound(t, r)
+
+	choices := map[string]string{}
+	for _, p := range round.Answers() {
+		choices[p.Name] = round.Question.Answers[p.Choice].Value
+	}
+	want := map[string]string{"alice": "True", "bob": "True", "carol": "False"}
+	if fmt.Sprint(choices) != fmt.Sprint(want) {
+		t.Errorf("got answers %v, want %v", choices, want)
+	}
+
+	for _, pm := range chat.privMessages() {
+		if pm.user == "dave" && !strings.Contains(pm.msg, "true/false") {
+			t.Errorf("invalid answer of a boolean round does not hint true/false: %q", pm.msg)
+		}
+	}
+}
+
+func TestRapidAnswersAreThrottled(t *testing.T) {
+	tb, chat := newTestBot(t, WithAnswerInterval(time.Minute))
+	r := newTestRoom(t, tb, "")
+	newTestQuiz(t, tb, r, 2, 100*time.Millisecond)
+
+	startRound(t, tb, r)
+	whisper(t, tb, "alice", "nope")
+	for i := 0; i < 5; i++ {
+		whisper(t, tb, "alice", "1")
+	}
+	whisper(t, tb, "bob", "1")
+
+	replies := map[string][]string{}
+	for _, pm := range chat.privMessages() {
+		replies[pm.user] = append(replies[pm.user], pm.msg)
+	}
+	if len(replies["alice"]) != 2 || !strings.HasPrefix(replies["alice"][1], "Slow down!") {
+		t.Errorf("expected alice to be warned once after her first attempt, got %q", replies["alice"])
+	}
+	if len(replies["bob"]) != 1 || !strings.HasSuffix(replies["bob"][0], "has been locked in") {
+		t.Errorf("expected bob not to be throttled by alice, got %q", replies["bob"])
+	}
+
+	finishRound(t, r)
+	round := startRound(t, tb, r)
+	whisper(t, tb, "alice", "1")
+	if len(round.Answers()) != 1 {
+		t.Error("expected alice's first attempt of the next round to be accepted")
+	}
+	finishRound(t, r)
+}
+
+func TestInvalidAnnouncements(t *testing.T) {
+	for _, a := range []Announcements{
+		{Start: "{{ .Starter"},
+		{Cooldown: "{{ .Nope }}"},
+	} {
+		if _, err := a.compile(); err == nil {
+			t.Errorf("expected %+v to be rejected", a)
+		}
+	}
+}
+
+// flakyChat fails its first `failures` sends before delivering messages.
+type flakyChat struct {
+	fakeChat
+	failures int
+	calls    int
+}
+
+func (c *flakyChat) SendChannel(msg, channel string) error {
+	c.calls++
+	if c.calls <= c.failures {
+		return errors.New("connection reset by peer")
+	}
+	return c.fakeChat.SendChannel(msg, channel)
+}
+
+func TestSendRetriesTransientErrors(t *testing.T) {
+	flaky := &flakyChat{failures: 1}
+	c := &retryingChat{chat: flaky, logger: zap.NewNop().Sugar(), attempts: 3, backoff: time.Millisecond}
+
+	if err := c.SendChannel("hello", ""); err != nil {
+		t.Fatalf("expected the second attempt to succeed: %v", err)
+	}
+	if flaky.calls != 2 {
+		t.Errorf("expected 2 attempts, got %d", flaky.calls)
+	}
+	if msgs := flaky.messages(""); len(msgs) != 1 || msgs[0] != "hello" {
+		t.Errorf("expected the message to be delivered once, got %q", msgs)
+	}
+
+	flaky = &flakyChat{failures: 5}
+	c.chat = flaky
+	if err := c.SendChannel("hello", ""); err == nil {
+		t.Fatal("expected sending to fail once attempts are exhausted")
+	}
+	if flaky.calls != 3 {
+		t.Errorf("expected 3 attempts, got %d", flaky.calls)
+	}
+}
+
+// rateLimitedChat is rate limited on its first `limited` sends, recording
+// when each send was made.
+type rateLimitedChat struct {
+	fakeChat
+	limited int
+	calls   []time.Time
+}
+
+func (c *rateLimitedChat) SendChannel(msg, channel string) error {
+	c.calls = append(c.calls, time.Now())
+	if len(c.calls) <= c.limited {
+		return fmt.Errorf("failed to send message %q: %w", msg, bot.ErrRateLimited)
+	}
+	return c.fakeChat.SendChannel(msg, channel)
+}
+
+func TestSendBacksOffWhenRateLimited(t *testing.T) {
+	limited := &rateLimitedChat{limited: 2}
+	pause := 30 * time.Millisecond
+	c := &retryingChat{chat: limited, logger: zap.NewNop().Sugar(), attempts: 1, pause: pause}
+
+	if err := c.SendChannel("hello", ""); err != nil {
+		t.Fatalf("expected the message to be sent once the rate limit passed: %v", err)
+	}
+	if len(limited.calls) != 3 {
+		t.Fatalf("expected 3 sends, got %d", len(limited.calls))
+	}
+	for i := 1; i < len(limited.calls); i++ {
+		if gap := limited.calls[i].Sub(limited.calls[i-1]); gap < pause {
+			t.Errorf("expected send %d to wait out the %s pause, waited %s", i+1, pause, gap)
+		}
+	}
+	if msgs := limited.messages(""); len(msgs) != 1 || msgs[0] != "hello" {
+		t.Errorf("expected the message to be delivered once, got %q", msgs)
+	}
+
+	// messages sent during a pause queue behind it
+	c.pauseSends()
+	start := time.Now()
+	if err := c.SendPriv("psst", "alice"); err != nil {
+		t.Fatalf("failed to whisper: %v", err)
+	}
+	if waited := time.Since(start); waited < pause {
+		t.Errorf("expected the whisper to wait out the pause, waited %s", waited)
+	}
+
+	// a server which never stops rate limiting is given up on
+	limited = &rateLimitedChat{limited: 100}
+	c = &retryingChat{chat: limited, logger: zap.NewNop().Sugar(), attempts: 1, pause: time.Millisecond}
+	if err := c.SendChannel("hello", ""); !errors.Is(err, bot.ErrRateLimited) {
+		t.Fatalf("expected sending to give up on the rate limit, got %v", err)
+	}
+	if len(limited.calls) != maxRateLimitPauses+1 {
+		t.Errorf("expected %d sends, got %d", maxRateLimitPauses+1, len(limited.calls))
+	}
+}
+
+func getJSON(t *testing.T, handler http.Handler, target string, v any) {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET %s returned %d: %s", target, rec.Code, rec.Body)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("GET %s returned content type %q", target, ct)
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+		t.Fatalf("GET %s returned invalid JSON %q: %v", target, rec.Body, err)
+	}
+}
+
+func TestAPILeaderboard(t *testing.T) {
+	tb, _ := newTestBot(t)
+	r := newTestRoom(t, tb, "")
+	if err := r.leaderboard.Update(map[string]int{"alice": 10, "bob": 5, "carol": 7}); err != nil {
+		t.Fatalf("failed to update leaderboard: %v", err)
+	}
+
+	var entries []map[string]any
+	getJSON(t, tb.apiHandler(), "/leaderboard?n=2", &entries)
+
+	want := []map[string]any{
+		{"name": "alice", "points": float64(10), "gamesPlayed": float64(1)},
+		{"name": "carol", "points": float64(7), "gamesPlayed": float64(1)},
+	}
+	if fmt.Sprint(entries) != fmt.Sprint(want) {
+		t.Errorf("got leaderboard %v, want %v", entries, want)
+	}
+
+	getJSON(t, tb.apiHandler(), "/leaderboard?channel=unknown", &entries)
+	if len(entries) != 0 {
+		t.Errorf("expected no leaderboard for an unknown channel, got %v", entries)
+	}
+	if tb.existingRoom("unknown") != nil {
+		t.Error("expected no room to be made for the request")
+	}
+}
+
+func TestAPIStatus(t *testing.T) {
+	tb, _ := newTestBot(t)
+	handler := tb.apiHandler()
+
+	var idle map[string]any
+	getJSON(t, handler, "/status", &idle)
+	if fmt.Sprint(idle) != fmt.Sprint(map[string]any{"inProgress": false}) {
+		t.Errorf("unexpected idle status %v", idle)
+	}
+
+	r := newTestRoom(t, tb, "")
+	claim, problem := tb.claimRoom(r)
+	if problem != "" {
+		t.Fatalf("failed to claim the room: %s", problem)
+	}
+	defer tb.releaseRoom(r, claim)
+	newTestQuiz(t, tb, r, 2, time.Minute)
+	round := startRound(t, tb, r)
+
+	type status struct {
+		InProgress bool           `json:"inProgress"`
+		Round      map[string]any `json:"round"`
+	}
+	var playing status
+	getJSON(t, handler, "/status", &playing)
+
+	if !playing.InProgress {
+		t.Error("status does not report the quiz in progress")
+	}
+	answers := []any{}
+	for _, ans := range round.Question.Answers {
+		answers = append(answers, ans.Value)
+	}
+	want := map[string]any{
+		"num":        float64(1),
+		"total":      float64(2),
+		"final":      false,
+		"inProgress": true,
+		"question":   "What is the capital of France?",
+		"answers":    answers,
+	}
+	if fmt.Sprint(playing.Round) != fmt.Sprint(want) {
+		t.Errorf("got round %v, want %v", playing.Round, want)
+	}
+
+	// between rounds the quiz is still running
+	round.End()
+	<-round.Done()
+	var between status
+	getJSON(t, handler, "/status", &between)
+	if !between.InProgress || between.Round["inProgress"] != false {
+		t.Errorf("expected the quiz to run between rounds, got %v", between)
+	}
+}
+
+func TestRecap(t *testing.T) {
+	tb, chat := newTestBot(t)
+	r := newTestRoom(t, tb, "")
+
+	say(t, tb, "", "alice", "trivia recap")
+	if got := lastMessage(chat, ""); got != "no quiz has been played yet" {
+		t.Errorf("unexpected recap before any quiz %q", got)
+	}
+
+	newTestQuiz(t, tb, r, 3, 10*time.Millisecond)
+	for i := 0; i < 3; i++ {
+		round := startRound(t, tb, r)
+		if i == 0 {
+			correct, _ := round.Question.Correct()
+			if err := round.NewParticipant("alice", correct, round.StartedAt.UnixMilli()); err != nil {
+				t.Fatalf("failed to answer: %v", err)
+			}
+
+			say(t, tb, "", "alice", "trivia recap")
+			if got := lastMessage(chat, ""); !strings.Contains(got, "once the quiz ends") {
+				t.Errorf("the recap was given during play: %q", got)
+			}
+		}
+		finishRound(t, r)
+	}
+
+	say(t, tb, "", "alice", "trivia recap")
+	got := lastMessage(chat, "")
+	for _, round := range r.quiz.Rounds {
+		correct, _ := round.Question.Correct()
+		want := fmt.Sprintf("Round %d: What is the capital of France? `%d) Paris`", round.Num, correct+1)
+		if !strings.Contains(got, want) {
+			t.Errorf("recap %q does not contain %q", got, want)
+		}
+	}
+	if !strings.Contains(got, "Paris` (answered in 0s on average) |") || strings.Count(got, "on average") != 1 {
+		t.Errorf("expected the average time of the only answered round in %q", got)
+	}
+}
+
+func TestRecapFromArchive(t *testing.T) {
+	tb, chat := newTestBot(t, WithQuizArchive())
+	r := newTestRoom(t, tb, "")
+
+	say(t, tb, "", "alice", "trivia start -size 3 -duration 10ms")
+	waitForQuiz(t, r)
+
+	// the numbers the correct answers were shown with, as announced
+	shown := []string{}
+	for _, msg := range chat.messages("") {
+		if strings.HasPrefix(msg, "Round complete!") {
+			shown = append(shown, strings.Split(msg, "`")[1])
+		}
+	}
+	if len(shown) != 3 {
+		t.Fatalf("expected 3 rounds played, got %q", chat.messages(""))
+	}
+
+	say(t, tb, "", "alice", "trivia recap")
+	played := lastMessage(chat, "")
+
+	// as after a restart, with no quiz in memory
+	r.setQuiz(nil)
+	say(t, tb, "", "alice", "trivia recap")
+	if got := lastMessage(chat, ""); got != played {
+		t.Errorf("expected the archived recap %q to match the one played %q", got, played)
+	}
+	for i, answer := range shown {
+		want := fmt.Sprintf("Round %d: What is the capital of France? `%s`", i+1, answer)
+		if !strings.Contains(played, want) {
+			t.Errorf("recap %q does not contain the answer as shown %q", played, want)
+		}
+	}
+}
+
+func TestSplitMessages(t *testing.T) {
+	entries := []string{"aaaa", "bbbb", "cccc", "dddddddddddd", "ee"}
+	got := splitMessages(entries, " | ", 11)
+	want := []string{"aaaa | bbbb", "cccc", "dddddddddddd", "ee"}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestCloseCancelsQuizAndFlushesLeaderboard(t *testing.T) {
+	tb, chat := newTestBot(t)
+	r := newTestRoom(t, tb, "")
+
+	say(t, tb, "", "alice", "trivia start -duration 1m")
+	answer(t, tb, r, "bob")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := tb.Close(ctx); err != nil {
+		t.Fatalf("failed to close: %v", err)
+	}
+
+	if r.running.Load() {
+		t.Error("expected the quiz to have stopped")
+	}
+	if !chat.destroyed {
+		t.Error("expected the chat connection to be closed")
+	}
+
+	users, err := r.leaderboard.Highscores(10)
+	if err != nil {
+		t.Fatalf("failed to get highscores: %v", err)
+	}
+	if len(users) == 0 || users[0].Name != "bob" || users[0].Points != 6 {
+		t.Errorf("expected bob's answer to be scored, got %v", users)
+	}
+
+	page, err := os.ReadFile(tb.leaderboardOutputPath)
+	if err != nil {
+		t.Fatalf("failed to read leaderboard page: %v", err)
+	}
+	if !strings.Contains(string(page), "bob") {
+		t.Error("expected the leaderboard page to list bob")
+	}
+}
+
+func TestCloseWithoutQuiz(t *testing.T) {
+	tb, chat := newTestBot(t)
+	newTestRoom(t, tb, "")
+
+	if err := tb.Close(context.Background()); err != nil {
+		t.Fatalf("failed to close: %v", err)
+	}
+	if !chat.destroyed {
+		t.Error("expected the chat connection to be closed")
+	}
+}
+
+func TestCountdown(t *testing.T) {
+	tb, chat := newTestBot(t, WithCountdown(100*time.Millisecond, 300*time.Millisecond, time.Second))
+	r := newTestRoom(t, tb, "")
+	newTestQuiz(t, tb, r, 1, 400*time.Millisecond)
+
+	round := playRound(t, tb, r)
+	closesAt := round.ClosesAt()
+
+	chat.mu.Lock()
+	defer chat.mu.Unlock()
+
+	countdown := []chatMsg{}
+	for _, m := range chat.sent {
+		if strings.HasSuffix(m.msg, " left") {
+			countdown = append(countdown, m)
+		}
+	}
+
+	// the 1s mark is longer than the round and is skipped
+	if len(countdown) != 2 || countdown[0].msg != "300ms left" || countdown[1].msg != "100ms left" {
+		t.Fatalf("unexpected countdown %v", countdown)
+	}
+	for i, left := range []time.Duration{300 * time.Millisecond, 100 * time.Millisecond} {
+		if early := closesAt.Add(-left).Sub(countdown[i].at); early > 0 {
+			t.Errorf("%q was sent %s early", countdown[i].msg, early)
+		}
+		if late := countdown[i].at.Sub(closesAt.Add(-left)); late > 100*time.Millisecond {
+			t.Errorf("%q was sent %s late", countdown[i].msg, late)
+		}
+	}
+}
+
+func TestNoCountdownByDefault(t *testing.T) {
+	tb, chat := newTestBot(t)
+	r := newTestRoom(t, tb, "")
+	newTestQuiz(t, tb, r, 1, 50*time.Millisecond)
+
+	playRound(t, tb, r)
+	for _, msg := range chat.messages("") {
+		if strings.HasSuffix(msg, " left") {
+			t.Errorf("unexpected countdown %q", msg)
+		}
+	}
+}
+
+func TestStartDurationBounds(t *testing.T) {
+	tb, chat := newTestBot(t, WithRoundDurationBounds(5*time.Second, 5*time.Minute))
+	r := newTestRoom(t, tb, "")
+
+	for _, tc := range []struct {
+		duration string
+		want     string
+	}{
+		{"1s", "-duration must be between 5s and 5m0s"},
+		{"10m", "-duration must be between 5s and 5m0s"},
+		{"soon", `invalid value "soon" for flag -duration`},
+	} {
+		say(t, tb, "", "alice", "trivia start -duration "+tc.duration)
+		if got := lastMessage(chat, ""); !strings.Contains(got, tc.want) {
+			t.Errorf("-duration %s: got %q, want it to contain %q", tc.duration, got, tc.want)
+		}
+		if r.running.Load() {
+			t.Fatalf("-duration %s started a quiz", tc.duration)
+		}
+	}
+
+	say(t, tb, "", "alice", "trivia start -duration 5s")
+	waitForRound(t, r)
+
+	if err := tb.Close(context.Background()); err != nil {
+		t.Fatalf("failed to close: %v", err)
+	}
+}
+
+func TestUptime(t *testing.T) {
+	tb, chat := newTestBot(t)
+	r := newTestRoom(t, tb, "")
+
+	say(t, tb, "", "alice", "trivia uptime")
+	if got, want := lastMessage(chat, ""), "Started now, hosted 0 quizzes this session"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	for i := 1; i <= 2; i++ {
+		newTestQuiz(t, tb, r, 1, 10*time.Millisecond)
+		if err := tb.runQuiz(context.Background(), r, "alice"); err != nil {
+			t.Fatalf("failed to run quiz: %v", err)
+		}
+		if got := tb.quizzesHosted.Load(); got != int64(i) {
+			t.Errorf("expected %d quizzes hosted, got %d", i, got)
+		}
+	}
+
+	if uptime := time.Since(tb.startedAt); uptime < 0 {
+		t.Errorf("expected a non-negative uptime, got %s", uptime)
+	}
+	say(t, tb, "", "alice", "trivia uptime")
+	if got := lastMessage(chat, ""); !strings.HasSuffix(got, "hosted 2 quizzes this session") {
+		t.Errorf("unexpected uptime %q", got)
+	}
+}
+
+func TestDoublePointsAnnounced(t *testing.T) {
+	tb, chat := newTestBot(t)
+	r := newTestRoom(t, tb, "")
+
+	say(t, tb, "", "alice", "trivia start -size 2 -duration 10ms -double 4")
+	if got := lastMessage(chat, ""); got != "-double must be a round from 1 to 2" {
+		t.Errorf("expected an out of range -double to be refused, got %q", got)
+	}
+
+	say(t, tb, "", "alice", "trivia start -size 2 -duration 10ms -double 2")
+	deadline := time.Now().Add(5 * time.Second)
+	for r.running.Load() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the quiz to finish")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	msgs := chat.messages("")
+	if len(msgs) < 4 {
+		t.Fatalf("expected the quiz to be announced, got %q", msgs)
+	}
+	if !strings.HasSuffix(msgs[1], "Double points in round 2!") {
+		t.Errorf("start does not announce the double points round: %q", msgs[1])
+	}
+	if strings.Contains(msgs[2], "double points") {
+		t.Errorf("round 1 is labelled double points: %q", msgs[2])
+	}
+	if !strings.HasPrefix(msgs[4], "Final round (double points):") {
+		t.Errorf("round 2 is not labelled double points: %q", msgs[4])
+	}
+}
+
+func TestTextAnswers(t *testing.T) {
+	tb, chat := newTestBot(t, WithTextAnswers(true))
+	tb.source = &staticSource{trivia.Question{
+		Question: "Which city is the capital of India?",
+		Answers: []*trivia.Answer{
+			{Value: "New York"},
+			{Value: "New Delhi", Correct: true},
+			{Value: "Paris"},
+		},
+	}}
+	r := newTestRoom(t, tb, "")
+	newTestQuiz(t, tb, r, 1, 100*time.Millisecond)
+
+	round := startRound(t, tb, r)
+	whisper(t, tb, "alice", "new delhi")
+	whisper(t, tb, "bob", "New")
+	whisper(t, tb, "carol", "London")
+	finishRound(t, r)
+
+	choices := map[string]string{}
+	for _, p := range round.Answers() {
+		choices[p.Name] = round.Question.Answers[p.Choice].Value
+	}
+	if len(choices) != 1 || choices["alice"] != "New Delhi" {
+		t.Errorf("expected only alice's text answer to be taken, got %v", choices)
+	}
+
+	replies := map[string]string{}
+	for _, pm := range chat.privMessages() {
+		replies[pm.user] = pm.msg
+	}
+	if !strings.Contains(replies["bob"], "matches more than one answer") {
+		t.Errorf("unexpected reply to an ambiguous answer %q", replies["bob"])
+	}
+	if !strings.HasPrefix(replies["carol"], "Invalid answer") {
+		t.Errorf("unexpected reply to an unmatched answer %q", replies["carol"])
+	}
+}
+
+// filterableSource is a staticSource which records the filters it is asked
+// for.
+type filterableSource struct {
+	*staticSource
+	mu      sync.Mutex
+	filters []trivia.Filter
+}
+
+func (s *filterableSource) Filtered(filter trivia.Filter) trivia.Source {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.filters = append(s.filters, filter)
+	return s.staticSource
+}
+
+func (s *filterableSource) Categories() ([]string, error) {
+	return []string{"Geography", "History"}, nil
+}
+
+func (s *filterableSource) Difficulties() ([]string, error) {
+	return []string{"easy", "hard"}, nil
+}
+
+func TestConfigDefaultsUsedByStart(t *testing.T) {
+	tb, chat := newTestBot(t)
+	source := &filterableSource{staticSource: newStaticSource()}
+	tb.source = source
+	r := newTestRoom(t, tb, "")
+
+	mod := func(data string) {
+		t.Helper()
+		msg := &bot.Msg{User: "mod", Data: data, Features: []string{"moderator"}, Time: time.Now().UnixMilli()}
+		if err := tb.onMsg(context.Background(), msg); err != nil {
+			t.Fatalf("failed to say %q: %v", data, err)
+		}
+	}
+
+	say(t, tb, "", "alice", "trivia config category geography")
+	if got := lastMessage(chat, ""); got != "only mods can change the config" {
+		t.Errorf("expected a non-mod to be refused, got %q", got)
+	}
+	mod("trivia config category sports")
+	if got := lastMessage(chat, ""); !strings.HasPrefix(got, `unknown category "sports"`) {
+		t.Errorf("expected an unknown category to be refused, got %q", got)
+	}
+
+	mod("trivia config category geography")
+	mod("trivia config difficulty HARD")
+	say(t, tb, "", "alice", "trivia config")
+	if got, want := lastMessage(chat, ""), `Quizzes ask category "Geography" and difficulty "hard" by default`; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	say(t, tb, "", "alice", "trivia start -size 1 -duration 10ms")
+	deadline := time.Now().Add(5 * time.Second)
+	for r.running.Load() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the quiz to finish")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	source.mu.Lock()
+	defer source.mu.Unlock()
+	want := trivia.Filter{Category: "Geography", Difficulty: "hard"}
+	if len(source.filters) != 1 || source.filters[0].String() != want.String() {
+		t.Errorf("expected the quiz to ask %s, got %v", want, source.filters)
+	}
+}
+
+func TestQuizCompleteOrderIsStable(t *testing.T) {
+	tb, chat := newTestBot(t)
+	r := newTestRoom(t, tb, "")
+
+	for i := 0; i < 10; i++ {
+		newTestQuiz(t, tb, r, 1, 10*time.Millisecond)
+		// equal points and speed leave only the names to order by
+		for _, name := range []string{"carol", "alice", "bob"} {
+			r.quiz.Scoreboard[name] = 6
+		}
+		if err := tb.runQuiz(context.Background(), r, "dave"); err != nil {
+			t.Fatalf("failed to run quiz: %v", err)
+		}
+
+		want := "Quiz complete! The following users are awarded points: alice +6 point(s), bob +6 point(s), and carol +6 point(s)"
+		if got := lastMessage(chat, ""); !strings.HasPrefix(got, want) {
+			t.Fatalf("run %d: got %q, want it to start with %q", i, got, want)
+		}
+	}
+}
+
+func TestCooldownBypass(t *testing.T) {
+	tb, chat := newTestBot(t, WithCooldownBypass(true, "host"))
+	r := newTestRoom(t, tb, "")
+	r.lastQuizEndedAt = time.Now()
+
+	start := func(msg *bot.Msg) bool {
+		t.Helper()
+		msg.Data, msg.Time = "trivia start -size 1 -duration 10ms", time.Now().UnixMilli()
+		if err := tb.onMsg(context.Background(), msg); err != nil {
+			t.Fatalf("failed to start as %s: %v", msg.User, err)
+		}
+		started := r.running.Load()
+		deadline := time.Now().Add(5 * time.Second)
+		for r.running.Load() {
+			if time.Now().After(deadline) {
+				t.Fatal("timed out waiting for the quiz to finish")
+			}
+			time.Sleep(time.Millisecond)
+		}
+		return started
+	}
+
+	if start(&bot.Msg{User: "alice"}) {
+		t.Error("expected a regular user to hit the cooldown")
+	}
+	if got := lastMessage(chat, ""); !strings.HasPrefix(got, "on cooldown") {
+		t.Errorf("expected the cooldown message, got %q", got)
+	}
+
+	for _, msg := range []*bot.Msg{{User: "mod", Features: []string{"moderator"}}, {User: "Host"}} {
+		r.lastQuizEndedAt = time.Now()
+		if !start(msg) {
+			t.Errorf("expected %s to skip the cooldown", msg.User)
+		}
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	err := os.WriteFile(path, []byte(`{
+		"url": "wss://example.com/ws",
+		"db_path": "/var/lib/trivia.db",
+		"cooldown": "2m",
+		"max_round_duration": "1m",
+		"category": "Science",
+		"admins": ["host"],
+		"all_correct": "flat",
+		"text_answers": false,
+		"emotes": {"Correct": ":tada:"}
+	}`), 0o600)
+	if err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	if cfg.URL != "wss://example.com/ws" || cfg.DBPath != "/var/lib/trivia.db" {
+		t.Errorf("unexpected connection settings: %+v", cfg)
+	}
+
+	opts, err := cfg.options()
+	if err != nil {
+		t.Fatalf("invalid config: %v", err)
+	}
+	tb, _ := newTestBot(t, append([]Option{WithRoundDurationBounds(5*time.Second, 5*time.Minute)}, opts...)...)
+
+	if tb.cooldown != 2*time.Minute {
+		t.Errorf("expected a 2m cooldown, got %s", tb.cooldown)
+	}
+	if tb.minRoundDuration != 5*time.Second || tb.maxRoundDuration != time.Minute {
+		t.Errorf("expected rounds of 5s to 1m, got %s to %s", tb.minRoundDuration, tb.maxRoundDuration)
+	}
+	if tb.defaultFilter.Category != "Science" || tb.defaultFilter.Difficulty != "" {
+		t.Errorf("expected Science questions by default, got %s", tb.defaultFilter)
+	}
+	if !tb.isAdmin(&bot.Msg{User: "Host"}) || tb.isAdmin(&bot.Msg{User: "guest"}) {
+		t.Error("expected only host to be an admin")
+	}
+	if tb.allCorrect != trivia.AllCorrectFlat || tb.textAnswers {
+		t.Errorf("unexpected scoring settings: %v, text answers %v", tb.allCorrect, tb.textAnswers)
+	}
+	if tb.emotes.Correct != ":tada:" {
+		t.Errorf("expected the configured emotes, got %+v", tb.emotes)
+	}
+
+	if err = os.WriteFile(path, []byte(`{"cooldown": 300}`), 0o600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	if _, err = LoadConfig(path); err == nil {
+		t.Error("expected a duration without a unit to be rejected")
+	}
+}
+
+func TestWhoami(t *testing.T) {
+	tb, chat := newTestBot(t, WithAdmins("host"))
+
+	say(t, tb, "", "alice", "trivia whoami")
+	if err := tb.onMsg(context.Background(), &bot.Msg{User: "mod", Data: "trivia whoami", Features: []string{"moderator"}}); err != nil {
+		t.Fatalf("failed to ask as a mod: %v", err)
+	}
+	say(t, tb, "", "host", "trivia whoami")
+
+	pms := chat.privMessages()
+	if len(pms) != 3 {
+		t.Fatalf("expected 3 whispers, got %v", pms)
+	}
+	for i, want := range []struct{ user, role string }{
+		{"alice", "player"},
+		{"mod", "mod"},
+		{"host", "admin"},
+	} {
+		if pms[i].user != want.user || !strings.HasPrefix(pms[i].msg, "You are "+want.user+", seen as a "+want.role) {
+			t.Errorf("expected %s to be told they are a %s, got %v", want.user, want.role, pms[i])
+		}
+	}
+	if msgs := chat.messages(""); len(msgs) != 0 {
+		t.Errorf("expected nothing said in chat, got %v", msgs)
+	}
+}
+
+func TestPreview(t *testing.T) {
+	tb, chat := newTestBot(t)
+	r := newTestRoom(t, tb, "")
+
+	say(t, tb, "", "alice", "trivia preview 2")
+	if pms := chat.privMessages(); len(pms) != 0 {
+		t.Fatalf("expected a regular user to be ignored, got %v", pms)
+	}
+
+	msg := &bot.Msg{User: "mod", Data: "trivia preview 2 -warmup", Features: []string{"moderator"}, Time: time.Now().UnixMilli()}
+	if err := tb.onMsg(context.Background(), msg); err != nil {
+		t.Fatalf("failed to preview: %v", err)
+	}
+
+	pms := chat.privMessages()
+	if len(pms) != 1 || pms[0].user != "mod" {
+		t.Fatalf("expected the preview whispered to mod, got %v", pms)
+	}
+	want := "Warm-up: What is the capital of France? `Paris` | Round 1: What is the capital of France? `Paris` | Round 2: What is the capital of France? `Paris`"
+	if pms[0].msg != want {
+		t.Errorf("expected preview %q, got %q", want, pms[0].msg)
+	}
+
+	if msgs := chat.messages(""); len(msgs) != 0 {
+		t.Errorf("expected nothing said in chat, got %v", msgs)
+	}
+	if r.running.Load() || r.currentQuiz() != nil || !r.lastQuizEndedAt.IsZero() {
+		t.Error("expected the preview not to start a quiz or the cooldown")
+	}
+
+	// the questions of the shared sequence are left to the quizzes played
+	source := &filterableSource{staticSource: newStaticSource()}
+	tb.source = source
+	if err := tb.onMsg(context.Background(), msg); err != nil {
+		t.Fatalf("failed to preview: %v", err)
+	}
+	if len(source.filters) != 1 || !source.filters[0].IsZero() {
+		t.Errorf("expected the preview to draw from a source of its own, got %v", source.filters)
+	}
+}
+
+func TestQuietMode(t *testing.T) {
+	tb, chat := newTestBot(t, WithQuietMode(), WithCountdown(50*time.Millisecond))
+	r := newTestRoom(t, tb, "")
+
+	say(t, tb, "", "alice", "trivia start -size 1 -duration 100ms")
+	waitForQuiz(t, r)
+
+	msgs := chat.messages("")
+	if len(msgs) != 3 {
+		t.Fatalf("expected only the round, its answer and the results, got %q", msgs)
+	}
+	for i, prefix := range []string{"Final round:", "Round complete!", "Quiz complete!"} {
+		if !strings.HasPrefix(msgs[i], prefix) {
+			t.Errorf("message %d: expected %q to start with %q", i, msgs[i], prefix)
+		}
+	}
+
+	mod := &bot.Msg{User: "mod", Data: "trivia quiet off", Features: []string{"moderator"}}
+	if err := tb.onMsg(context.Background(), mod); err != nil {
+		t.Fatalf("failed to turn quiet mode off: %v", err)
+	}
+	if r.quiet.Load() {
+		t.Fatal("expected quiet mode to be off")
+	}
+
+	r.lastQuizEndedAt = time.Time{}
+	say(t, tb, "", "alice", "trivia start -size 1 -duration 100ms")
+	waitForQuiz(t, r)
+
+	msgs = chat.messages("")[4:]
+	if len(msgs) != 5 || !strings.HasPrefix(msgs[0], "Quiz starting soon!") || msgs[2] != "50ms left" {
+		t.Errorf("expected the start and countdown to be sent once quiet mode is off, got %q", msgs)
+	}
+}
+
+// waitForQuiz waits for the room's quiz to finish.
+func waitForQuiz(t *testing.T, r *room) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for r.running.Load() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the quiz to finish")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// chanAnswerSource delivers the submissions sent on subs, replying on results.
+type chanAnswerSource struct {
+	subs    chan Submission
+	results chan error
+}
+
+func (s *chanAnswerSource) Listen(ctx context.Context, deliver func(Submission) error) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case sub := <-s.subs:
+			s.results <- deliver(sub)
+		}
+	}
+}
+
+func (s *chanAnswerSource) submit(sub Submission) error {
+	s.subs <- sub
+	return <-s.results
+}
+
+func TestAnswerSourceDrivesRound(t *testing.T) {
+	source := &chanAnswerSource{subs: make(chan Submission), results: make(chan error)}
+	tb, _ := newTestBot(t, WithAnswerSources(source))
+
+	if err := source.submit(Submission{User: "alice", Time: time.Now().UnixMilli()}); !errors.Is(err, ErrNoRound) {
+		t.Fatalf("expected %v before a round, got %v", ErrNoRound, err)
+	}
+
+	r := newTestRoom(t, tb, "")
+	newTestQuiz(t, tb, r, 1, 100*time.Millisecond)
+	round := startRound(t, tb, r)
+	correct, _ := round.Question.Correct()
+	wrong := (correct + 1) % len(round.Question.Answers)
+
+	if err := source.submit(Submission{User: "alice", Choice: correct, Time: time.Now().UnixMilli()}); err != nil {
+		t.Fatalf("failed to submit an answer: %v", err)
+	}
+	if err := source.submit(Submission{User: "bob", Channel: r.channel, Choice: wrong, Time: time.Now().UnixMilli()}); err != nil {
+		t.Fatalf("failed to submit an answer to the channel: %v", err)
+	}
+	err := source.submit(Submission{User: "alice", Choice: wrong, Time: time.Now().UnixMilli()})
+	if !errors.Is(err, trivia.ErrAlreadyAnswered) {
+		t.Errorf("expected a second answer to fail with %v, got %v", trivia.ErrAlreadyAnswered, err)
+	}
+	finishRound(t, r)
+
+	choices := map[string]int{}
+	for _, p := range round.Answers() {
+		choices[p.Name] = p.Choice
+	}
+	if len(choices) != 2 || choices["alice"] != correct || choices["bob"] != wrong {
+		t.Errorf("expected the answers of alice and bob to count, got %v", choices)
+	}
+}
+
+func TestAnswerSourcesShareThrottle(t *testing.T) {
+	source := &chanAnswerSource{subs: make(chan Submission), results: make(chan error)}
+	tb, chat := newTestBot(t, WithAnswerSources(source), WithAnswerInterval(time.Minute))
+	r := newTestRoom(t, tb, "")
+	newTestQuiz(t, tb, r, 1, 100*time.Millisecond)
+	startRound(t, tb, r)
+
+	whisper(t, tb, "alice", "1")
+	if err := source.submit(Submission{User: "alice", Time: time.Now().UnixMilli()}); !errors.Is(err, ErrThrottled) || errors.Is(err, ErrStillThrottled) {
+		t.Errorf("expected an answer right after a whisper to be throttled, got %v", err)
+	}
+	whisper(t, tb, "alice", "2")
+	if pms := chat.privMessages(); len(pms) != 1 {
+		t.Errorf("expected alice to be told of her throttled answers once, got %v", pms)
+	}
+	finishRound(t, r)
+}
+
+func TestAnswerCounts(t *testing.T) {
+	for _, enabled := range []bool{false, true} {
+		opts := []Option{}
+		if enabled {
+			opts = append(opts, WithAnswerCounts())
+		}
+		tb, chat := newTestBot(t, opts...)
+		r := newTestRoom(t, tb, "")
+		newTestQuiz(t, tb, r, 1, 100*time.Millisecond)
+
+		round := startRound(t, tb, r)
+		correct, _ := round.Question.Correct()
+		wrong := (correct + 1) % len(round.Question.Answers)
+		for user, choice := range map[string]int{"alice": correct, "bob": wrong, "carol": correct} {
+			if err := round.NewParticipant(user, choice, time.Now().UnixMilli()); err != nil {
+				t.Fatalf("failed to answer as %s: %v", user, err)
+			}
+		}
+		finishRound(t, r)
+
+		var results string
+		for _, msg := range chat.messages("") {
+			if strings.HasPrefix(msg, "Round complete!") {
+				results = msg
+			}
+		}
+		if got := strings.HasSuffix(results, " (3 answered, 2 correct)"); got != enabled {
+			t.Errorf("with answer counts %t, got round results %q", enabled, results)
+		}
+	}
+}
+
+func TestRecordScores(t *testing.T) {
+	tb, _ := newTestBot(t)
+	r := newTestRoom(t, tb, "")
+	newTestQuiz(t, tb, r, 1, 10*time.Millisecond)
+
+	if err := tb.recordScores(r, r.quiz, r.quiz.SortedScore()); err != nil {
+		t.Fatalf("failed to record an empty quiz: %v", err)
+	}
+	highscores, err := r.leaderboard.Highscores(0)
+	if err != nil {
+		t.Fatalf("failed to get highscores: %v", err)
+	}
+	if len(highscores) != 0 {
+		t.Errorf("expected nothing written for a quiz no one played, got %d users", len(highscores))
+	}
+
+	r.quiz.Scoreboard["starter"] = 0
+	round := startRound(t, tb, r)
+	correct, _ := round.Question.Correct()
+	if err = round.NewParticipant("alice", correct, time.Now().UnixMilli()); err != nil {
+		t.Fatalf("failed to answer: %v", err)
+	}
+	finishRound(t, r)
+
+	if err = tb.recordScores(r, r.quiz, r.quiz.SortedScore()); err != nil {
+		t.Fatalf("failed to record scores: %v", err)
+	}
+	if highscores, err = r.leaderboard.Highscores(0); err != nil {
+		t.Fatalf("failed to get highscores: %v", err)
+	}
+	got := map[string]int64{}
+	for _, user := range highscores {
+		got[user.Name] = user.Points
+	}
+	if len(got) != 2 || got["alice"] != 6 || got["starter"] != 0 {
+		t.Errorf("expected every ranked player recorded with their points, got %v", got)
+	}
+}
+
+func TestCategoryPoll(t *testing.T) {
+	tb, chat := newTestBot(t, WithPollDuration(100*time.Millisecond))
+	source := &filterableSource{staticSource: newStaticSource()}
+	tb.source = source
+	r := newTestRoom(t, tb, "")
+
+	say(t, tb, "", "alice", "trivia poll")
+	if r.poll.Load() != nil {
+		t.Fatal("expected a non-mod not to start a poll")
+	}
+
+	mod := &bot.Msg{User: "mod", Data: "trivia poll -size 1 -duration 10ms", Features: []string{"moderator"}}
+	if err := tb.onMsg(context.Background(), mod); err != nil {
+		t.Fatalf("failed to start the poll: %v", err)
+	}
+	poll := r.poll.Load()
+	if poll == nil {
+		t.Fatalf("expected a poll to be open, got %q", chat.messages(""))
+	}
+	if got := lastMessage(chat, ""); !strings.HasSuffix(got, fmt.Sprintf("1) %s or 2) %s", poll.categories[0], poll.categories[1])) {
+		t.Errorf("expected the poll to list the categories, got %q", got)
+	}
+
+	say(t, tb, "", "alice", "2")
+	say(t, tb, "", "bob", "1")
+	say(t, tb, "", "bob", "2")
+	say(t, tb, "", "carol", "2")
+	say(t, tb, "", "dave", "1")
+	say(t, tb, "", "erin", "3")
+	say(t, tb, "", "alice", "trivia start")
+	if got := lastMessage(chat, ""); got != "a quiz is already in progress" {
+		t.Errorf("expected the poll to hold the room, got %q", got)
+	}
+	waitForQuiz(t, r)
+
+	want := fmt.Sprintf("%s wins the poll with 3 vote(s)!", poll.categories[1])
+	found := false
+	for _, msg := range chat.messages("") {
+		found = found || msg == want
+	}
+	if !found {
+		t.Errorf("expected %q to be announced, got %q", want, chat.messages(""))
+	}
+
+	source.mu.Lock()
+	defer source.mu.Unlock()
+	if len(source.filters) != 1 || source.filters[0].Category != poll.categories[1] {
+		t.Errorf("expected the quiz to ask the winning category, got %v", source.filters)
+	}
+	if r.poll.Load() != nil {
+		t.Error("expected the poll to be closed")
+	}
+}
+
+func TestCategoryPollWithoutVotes(t *testing.T) {
+	tb, chat := newTestBot(t, WithPollDuration(50*time.Millisecond))
+	source := &filterableSource{staticSource: newStaticSource()}
+	tb.source = source
+	r := newTestRoom(t, tb, "")
+
+	mod := &bot.Msg{User: "mod", Data: "trivia poll -size 1 -duration 10ms", Features: []string{"moderator"}}
+	if err := tb.onMsg(context.Background(), mod); err != nil {
+		t.Fatalf("failed to start the poll: %v", err)
+	}
+	waitForQuiz(t, r)
+
+	if got, want := lastMessage(chat, ""), "No one voted, so no quiz is started"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	source.mu.Lock()
+	defer source.mu.Unlock()
+	if len(source.filters) != 0 || r.currentQuiz() != nil {
+		t.Errorf("expected no quiz to be started, got %v", source.filters)
+	}
+}
+
+func TestQuestionTruncation(t *testing.T) {
+	tests := []struct {
+		question string
+		max      int
+		want     string
+	}{
+		{"Quelle île française s’appelle l’Île de Beauté ?", 0, "Quelle île française s’appelle l’Île de Beauté ?"},
+		{"Quelle île française s’appelle l’Île de Beauté ?", 100, "Quelle île française s’appelle l’Île de Beauté ?"},
+		{"Quelle île française s’appelle l’Île de Beauté ?", 24, "Quelle île française…"},
+		{"Quelle île française s’appelle l’Île de Beauté ?", 21, "Quelle île française…"},
+		{"日本で一番高い山はどこですか", 6, "日本で一番…"},
+	}
+	for _, tt := range tests {
+		got, cut := truncate(tt.question, tt.max)
+		if got != tt.want || cut != (got != tt.question) {
+			t.Errorf("truncate(%q, %d) = %q, %t, want %q", tt.question, tt.max, got, cut, tt.want)
+		}
+		if !utf8.ValidString(got) || (tt.max > 0 && utf8.RuneCountInString(got) > tt.max) {
+			t.Errorf("truncate(%q, %d) = %q is invalid or too long", tt.question, tt.max, got)
+		}
+	}
+
+	tb, chat := newTestBot(t, WithMaxQuestionLength(20))
+	r := newTestRoom(t, tb, "")
+	newTestQuiz(t, tb, r, 1, 100*time.Millisecond)
+	round, err := tb.startRound(r, r.currentQuiz())
+	if err != nil {
+		t.Fatalf("failed to start round: %v", err)
+	}
+	go tb.runRound(context.Background(), r, round)
+	waitForRound(t, r)
+
+	msgs := chat.messages("")
+	if !strings.Contains(msgs[0], "`What is the capital…` (see `trivia repeat` for the rest)") {
+		t.Errorf("expected the question cut short, got %q", msgs[0])
+	}
+	say(t, tb, "", "alice", "trivia repeat")
+	if got := lastMessage(chat, ""); !strings.Contains(got, "`What is the capital of France?` `1)") {
+		t.Errorf("expected the full question repeated, got %q", got)
+	}
+	finishRound(t, r)
+}
+
+// listSource asks its questions in order, over again once all are asked.
+type listSource struct {
+	mu        sync.Mutex
+	questions []trivia.Question
+	next      int
+}
+
+func (s *listSource) Question() (*trivia.Question, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	q := s.questions[s.next%len(s.questions)]
+	s.next++
+	answers := q.Answers
+	q.Answers = []*trivia.Answer{}
+	for _, ans := range answers {
+		a := *ans
+		q.Answers = append(q.Answers, &a)
+	}
+	return &q, nil
+}
+
+func TestWithSource(t *testing.T) {
+	source := &listSource{questions: []trivia.Question{
+		{Question: "What is 1 + 1?", Answers: []*trivia.Answer{{Value: "2", Correct: true}, {Value: "3"}}},
+		{Question: "What is 2 + 2?", Answers: []*trivia.Answer{{Value: "4", Correct: true}, {Value: "5"}}},
+	}}
+	tb, chat := newTestBot(t, WithSource(source))
+	r := newTestRoom(t, tb, "")
+
+	say(t, tb, "", "alice", "trivia start -size 2 -duration 10ms")
+	waitForQuiz(t, r)
+
+	asked := []string{}
+	for _, msg := range chat.messages("") {
+		if strings.Contains(msg, "What is") {
+			asked = append(asked, msg)
+		}
+	}
+	if len(asked) != 2 || !strings.Contains(asked[0], "1 + 1") || !strings.Contains(asked[1], "2 + 2") {
+		t.Errorf("expected both questions of the source to be asked in order, got %q", asked)
+	}
+}
+
+func TestAnswerConfirmationEchoesChoice(t *testing.T) {
+	tb, chat := newTestBot(t, WithTextAnswers(true))
+	r := newTestRoom(t, tb, "")
+	quiz, err := trivia.NewQuizWithOptions(tb.logger, tb.source, trivia.QuizOptions{
+		Size:          1,
+		Duration:      50 * time.Millisecond,
+		ChangeAnswers: true,
+	})
+	if err != nil {
+		t.Fatalf("failed to create quiz: %v", err)
+	}
+	r.setQuiz(quiz)
+	round := startRound(t, tb, r)
+
+	whisper(t, tb, "alice", "2")
+	whisper(t, tb, "alice", "paris")
+	correct, _ := round.Question.Correct()
+
+	replies := []string{}
+	for _, pm := range chat.privMessages() {
+		replies = append(replies, pm.msg)
+	}
+	want := []string{
+		fmt.Sprintf("Your answer `2) %s` has been locked in", round.Question.Answers[1].Value),
+		fmt.Sprintf("Your answer has been changed to `%d) Paris`", correct+1),
+	}
+	if strings.Join(replies, "|") != strings.Join(want, "|") {
+		t.Errorf("expected replies %q, got %q", want, replies)
+	}
+
+	if _, ok := round.Question.Choice(len(round.Question.Answers)); ok {
+		t.Error("expected no choice past the last answer")
+	}
+	finishRound(t, r)
+}
+
+func TestOddsChart(t *testing.T) {
+	tb, chat := newTestBot(t)
+	r := newTestRoom(t, tb, "")
+	newTestQuiz(t, tb, r, 1, 100*time.Millisecond)
+
+	round := startRound(t, tb, r)
+	correct, _ := round.Question.Correct()
+	wrong := (correct + 1) % len(round.Question.Answers)
+	unpicked := 3 - correct - wrong
+
+	picks := map[string]int{"a": correct, "b": correct, "c": correct, "d": wrong}
+	for user, pick := range picks {
+		whisper(t, tb, user, fmt.Sprint(pick+1))
+	}
+	finishRound(t, r)
+
+	say(t, tb, "", "e", "trivia odds chart")
+	got := lastMessage(chat, "")
+	for idx, want := range map[int]string{
+		correct:  "████████░░ 75%",
+		wrong:    "███░░░░░░░ 25%",
+		unpicked: "░░░░░░░░░░ 0%",
+	} {
+		choice, _ := round.Question.Choice(idx)
+		if !strings.Contains(got, choice+" "+want) {
+			t.Errorf("chart %q does not contain %q", got, choice+" "+want)
+		}
+	}
+
+	say(t, tb, "", "e", "trivia odds pie")
+	if got := lastMessage(chat, ""); !strings.HasPrefix(got, "usage:") {
+		t.Errorf("expected usage for an unknown chart, got %q", got)
+	}
+}
+
+func TestSpeedQuiz(t *testing.T) {
+	tb, chat := newTestBot(t, WithSpeedDuration(time.Second))
+	r := newTestRoom(t, tb, "")
+
+	say(t, tb, "", "alice", "trivia speed -duration 1m")
+	if got := lastMessage(chat, ""); !strings.Contains(got, "set their own -duration") {
+		t.Errorf("expected -duration to be refused, got %q", got)
+	}
+
+	say(t, tb, "", "alice", "trivia speed -size 1")
+	round := waitForRound(t, r)
+	if window := round.ClosesAt().Sub(round.StartedAt); window > time.Second {
+		t.Errorf("expected a window of at most 1s, got %s", window)
+	}
+
+	answer(t, tb, r, "bob")
+	correct, _ := round.Question.Correct()
+	whisper(t, tb, "carol", fmt.Sprint(correct+1))
+	waitForQuiz(t, r)
+
+	winners, _ := round.DetermineOutcome()
+	if len(winners) != 1 || winners[0].Name != "bob" {
+		t.Errorf("expected only the first correct answer to win, got %v", winners)
+	}
+	if _, ok := r.quiz.Scoreboard["carol"]; ok {
+		t.Error("expected carol's answer after the round ended not to score")
+	}
+
+	say(t, tb, "", "alice", "trivia speed")
+	if got := lastMessage(chat, ""); !strings.HasPrefix(got, "on cooldown for") {
+		t.Errorf("expected the cooldown to apply to speed quizzes, got %q", got)
+	}
+}
+
+func TestUnstick(t *testing.T) {
+	tb, chat := newTestBot(t, WithAdmins("host"))
+	r := newTestRoom(t, tb, "")
+	newTestQuiz(t, tb, r, 1, time.Minute)
+
+	// a round left in progress with the room claimed, as after a crash
+	startRound(t, tb, r)
+	r.running.Store(true)
+
+	say(t, tb, "", "alice", "trivia start")
+	if got := lastMessage(chat, ""); got != "a quiz is already in progress" {
+		t.Fatalf("expected the stuck quiz to block starting another, got %q", got)
+	}
+
+	say(t, tb, "", "alice", "trivia unstick")
+	if !r.running.Load() {
+		t.Fatal("expected non-admins to be ignored")
+	}
+
+	say(t, tb, "", "host", "trivia unstick")
+	if r.running.Load() || r.roundInProgress() || r.quiz.CurrentRound() != nil {
+		t.Errorf("expected the room to be reset, got running %t, in progress %t", r.running.Load(), r.roundInProgress())
+	}
+
+	say(t, tb, "", "host", "trivia unstick")
+	if got := lastMessage(chat, ""); got != "no quiz is in progress" {
+		t.Errorf("expected nothing left to unstick, got %q", got)
+	}
+}
+
+func TestUnstickRunningQuiz(t *testing.T) {
+	tb, chat := newTestBot(t, WithAdmins("host"), WithCooldownBypass(false, "host"))
+	r := newTestRoom(t, tb, "")
+
+	say(t, tb, "", "host", "trivia start -size 2 -duration 5s")
+	stuck := waitForRound(t, r)
+	stuckQuiz := r.currentQuiz()
+	say(t, tb, "", "host", "trivia unstick")
+
+	// started before the stuck quiz's goroutine may have returned
+	say(t, tb, "", "host", "trivia start -size 1 -duration 300ms")
+	next := waitForRound(t, r)
+	if r.currentQuiz() == stuckQuiz || next == stuck {
+		t.Fatalf("expected a new quiz to start, got %q", chat.messages(""))
+	}
+
+	done := make(chan struct{})
+	go func() {
+		tb.quizzes.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the quizzes to return")
+	}
+
+	played := r.currentQuiz().State().Rounds[0]
+	if !played.Complete || played.EndedAt.Sub(played.StartedAt) < 250*time.Millisecond {
+		t.Errorf("expected the new quiz's round to run its full duration, ended after %s", played.EndedAt.Sub(played.StartedAt))
+	}
+	if r.running.Load() || tb.runningQuizzes.Load() != 0 {
+		t.Errorf("expected the room to be released once, got running %t with %d quizzes", r.running.Load(), tb.runningQuizzes.Load())
+	}
+
+	// the stopped quiz's late release leaves the claim of the next alone
+	stale := r.currentClaim()
+	claim, problem := tb.claimRoom(r)
+	if problem != "" {
+		t.Fatalf("failed to claim the room: %s", problem)
+	}
+	tb.releaseRoom(r, stale)
+	if !r.running.Load() {
+		t.Error("expected a stale claim not to release the room")
+	}
+	tb.releaseRoom(r, claim)
+	if r.running.Load() || tb.runningQuizzes.Load() != 0 {
+		t.Error("expected the room to be released by its own claim")
+	}
+}
+
+func TestUnstickDuringGrace(t *testing.T) {
+	tb, chat := newTestBot(t, WithAdmins("host"), WithGracePeriod(300*time.Millisecond, 1))
+	r := newTestRoom(t, tb, "")
+
+	say(t, tb, "", "host", "trivia start -size 2 -duration 50ms")
+	round := waitForRound(t, r)
+	for round.IsOpen() {
+		time.Sleep(time.Millisecond)
+	}
+	say(t, tb, "", "host", "trivia unstick")
+
+	select {
+	case <-round.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the round to finish")
+	}
+	done := make(chan struct{})
+	go func() {
+		tb.quizzes.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the quiz to return")
+	}
+	for _, msg := range chat.messages("") {
+		if strings.HasPrefix(msg, "Round complete!") {
+			t.Errorf("expected the reset round not to be announced, got %q", msg)
+		}
+	}
+}
+
+func TestPauseAndResume(t *testing.T) {
+	tb, chat := newTestBot(t, WithAdmins("host"))
+	r := newTestRoom(t, tb, "")
+	newTestQuiz(t, tb, r, 1, 300*time.Millisecond)
+
+	round := startRound(t, tb, r)
+	time.Sleep(100 * time.Millisecond)
+
+	say(t, tb, "", "host", "trivia pause")
+	if !round.Paused() {
+		t.Fatalf("expected the round to be paused, got %q", lastMessage(chat, ""))
+	}
+
+	whisper(t, tb, "alice", "1")
+	priv := chat.privMessages()
+	if got := priv[len(priv)-1].msg; got != "The round is paused, answer once it resumes" {
+		t.Errorf("expected answers to be rejected while paused, got %q", got)
+	}
+	if round.HasAnswered("alice") {
+		t.Error("expected the answer sent while paused not to count")
+	}
+
+	// outlast the round's duration, which is frozen
+	time.Sleep(400 * time.Millisecond)
+	if !r.roundInProgress() {
+		t.Fatal("expected the round to stay open while paused")
+	}
+
+	say(t, tb, "", "host", "trivia resume")
+	if got := lastMessage(chat, ""); !strings.HasPrefix(got, "The quiz resumes") {
+		t.Errorf("expected the quiz to resume, got %q", got)
+	}
+	if left := time.Until(round.ClosesAt()); left < 100*time.Millisecond || left > 250*time.Millisecond {
+		t.Errorf("expected the round to close in about 200ms, closes in %s", left)
+	}
+
+	answer(t, tb, r, "bob")
+	finishRound(t, r)
+	answers := round.Answers()
+	if len(answers) != 1 || answers[0].TimeToSubmission >= 300*time.Millisecond {
+		t.Errorf("expected bob's time to exclude the pause, got %+v", answers)
+	}
+}
+
+func TestAttribution(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		opts []Option
+		want bool
+	}{
+		{"default", nil, false},
+		{"enabled", []Option{WithAttribution()}, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tb, _ := newTestBot(t, tc.opts...)
+			tb.source.(*staticSource).question.Source = "opentdb"
+			r := newTestRoom(t, tb, "")
+			newTestQuiz(t, tb, r, 1, time.Millisecond)
+
+			output, err := tb.formatRound(r, startRound(t, tb, r), 0)
+			if err != nil {
+				t.Fatalf("failed to format round: %v", err)
+			}
+			if got := strings.HasSuffix(output, " (via opentdb)"); got != tc.want {
+				t.Errorf("expected attribution %t in %q", tc.want, output)
+			}
+			finishRound(t, r)
+		})
+	}
+}
+
+func TestFinalRoundLabel(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{"default", nil, "Final round"},
+		{"custom", []Option{WithFinalRoundLabel("Last chance")}, "Last chance"},
+		{"numbered", []Option{WithFinalRoundLabel("")}, "Round 2/2"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tb, _ := newTestBot(t, tc.opts...)
+			r := newTestRoom(t, tb, "")
+			newTestQuiz(t, tb, r, 2, time.Millisecond)
+			// the last round is told apart even without its flag
+			r.quiz.Rounds[len(r.quiz.Rounds)-1].Final = false
+
+			var outputs []string
+			for i := 0; i < 2; i++ {
+				output, err := tb.formatRound(r, startRound(t, tb, r), 0)
+				if err != nil {
+					t.Fatalf("failed to format round: %v", err)
+				}
+				outputs = append(outputs, output)
+				finishRound(t, r)
+			}
+
+			if !strings.HasPrefix(outputs[0], "Round 1/2: ") {
+				t.Errorf("expected the first round to be numbered, got %q", outputs[0])
+			}
+			if !strings.HasPrefix(outputs[1], tc.want+": ") {
+				t.Errorf("expected the last round to be announced as %q, got %q", tc.want, outputs[1])
+			}
+		})
+	}
+}
+
+func TestMinOddsAnswers(t *testing.T) {
+	tb, chat := newTestBot(t, WithMinOddsAnswers(2))
+	r := newTestRoom(t, tb, "")
+	newTestQuiz(t, tb, r, 2, 20*time.Millisecond)
+
+	startRound(t, tb, r)
+	answer(t, tb, r, "alice")
+	finishRound(t, r)
+
+	for _, cmd := range []string{"trivia odds", "trivia odds chart"} {
+		say(t, tb, "", "bob", cmd)
+		if got := lastMessage(chat, ""); got != "the odds are only revealed once at least 2 players answer, 1 did" {
+			t.Errorf("expected %q to be suppressed for a single answer, got %q", cmd, got)
+		}
+	}
+
+	startRound(t, tb, r)
+	answer(t, tb, r, "alice")
+	answer(t, tb, r, "bob")
+	finishRound(t, r)
+
+	say(t, tb, "", "carol", "trivia odds")
+	if got := lastMessage(chat, ""); !strings.HasPrefix(got, "Most picked:") {
+		t.Errorf("expected the odds to be revealed for two answers, got %q", got)
+	}
+}
+
+func TestNextCategory(t *testing.T) {
+	tb, chat := newTestBot(t, WithAdmins("host"), WithCooldownBypass(false, "host"))
+	source := &filterableSource{staticSource: newStaticSource()}
+	tb.source = source
+	r := newTestRoom(t, tb, "")
+
+	say(t, tb, "", "host", "trivia next category sports")
+	if got := lastMessage(chat, ""); !strings.HasPrefix(got, `unknown category "sports"`) {
+		t.Errorf("expected an unknown category to be refused, got %q", got)
+	}
+	say(t, tb, "", "host", "trivia next category history")
+	say(t, tb, "", "host", "trivia next")
+	if got, want := lastMessage(chat, ""), `The next quiz asks category "History"`; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// previewing the next quiz leaves the category to it
+	msg := &bot.Msg{User: "mod", Data: "trivia preview 1", Features: []string{"moderator"}, Time: time.Now().UnixMilli()}
+	if err := tb.onMsg(context.Background(), msg); err != nil {
+		t.Fatalf("failed to preview: %v", err)
+	}
+	source.mu.Lock()
+	if len(source.filters) != 1 || source.filters[0].Category != "History" {
+		t.Errorf("expected the preview to ask History, got %v", source.filters)
+	}
+	source.filters = nil
+	source.mu.Unlock()
+
+	for i := 0; i < 2; i++ {
+		say(t, tb, "", "host", "trivia start -size 1 -duration 10ms")
+		waitForQuiz(t, r)
+	}
+	played := 0
+	for _, msg := range chat.messages("") {
+		if strings.HasPrefix(msg, "Quiz complete!") {
+			played++
+		}
+	}
+	if played != 2 {
+		t.Fatalf("expected 2 quizzes to be played, got %d", played)
+	}
+
+	source.mu.Lock()
+	defer source.mu.Unlock()
+	if len(source.filters) != 1 || source.filters[0].Category != "History" {
+		t.Errorf("expected only the first quiz to ask History, got %v", source.filters)
+	}
+}
+
+func TestRoundWithoutDifficulty(t *testing.T) {
+	tb, _ := newTestBot(t, WithAnnouncements(Announcements{
+		Round: "{{ .Category }}, {{ .Difficulty }}, {{ .Type }}: {{ .Question }}",
+	}))
+	// a NULL difficulty and type are read from the database as empty
+	tb.source.(*staticSource).question.Category = "Geography"
+	r := newTestRoom(t, tb, "")
+	newTestQuiz(t, tb, r, 1, time.Millisecond)
+
+	output, err := tb.formatRound(r, startRound(t, tb, r), 0)
+	if err != nil {
+		t.Fatalf("failed to format round: %v", err)
+	}
+	if want := "Geography, unknown, unknown: What is the capital of France?"; output != want {
+		t.Errorf("got %q, want %q", output, want)
+	}
+	finishRound(t, r)
+}
+
+func TestCommandsListing(t *testing.T) {
+	tb, chat := newTestBot(t)
+	tb.commands = append(tb.commands, &command{
+		name:        "custom",
+		description: "A command registered later.",
+		admin:       true,
+		run: func(ctx context.Context, r *room, msg *bot.Msg, args []string) error {
+			return nil
+		},
+	})
+
+	say(t, tb, "", "alice", "trivia commands")
+	listing := strings.Join(chat.messages(""), ", ")
+	if !strings.HasPrefix(listing, "Commands: commands, config, ") {
+		t.Errorf("expected the commands in order, got %q", listing)
+	}
+	for _, cmd := range tb.commands {
+		if !strings.Contains(listing, cmd.name) {
+			t.Errorf("expected %s to be listed in %q", cmd.name, listing)
+		}
+	}
+	for _, want := range []string{
+		", help, ",
+		", lint (mods only), ",
+		", poll (mods only, takes flags, 5m0s between quizzes), ",
+		", start (takes flags, 5m0s between quizzes), ",
+		", daily (once a day), ",
+		", custom (mods only)",
+	} {
+		if !strings.Contains(listing, want) {
+			t.Errorf("expected %q in %q", want, listing)
+		}
+	}
+}
+
+func TestAnswerLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "answers.jsonl")
+	tb, _ := newTestBot(t, WithAnswerLog(path))
+	r := newTestRoom(t, tb, "")
+	newTestQuiz(t, tb, r, 1, 100*time.Millisecond)
+
+	round := startRound(t, tb, r)
+	correct, _ := round.Question.Correct()
+	wrong := (correct + 1) % len(round.Question.Answers)
+	whisper(t, tb, "alice", fmt.Sprint(correct+1))
+	whisper(t, tb, "bob", fmt.Sprint(correct+1))
+	whisper(t, tb, "carol", fmt.Sprint(wrong+1))
+	finishRound(t, r)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read answer log: %v", err)
+	}
+	for _, name := range []string{"alice", "bob", "carol"} {
+		if strings.Contains(string(data), name) {
+			t.Errorf("expected the log not to name %s, got %s", name, data)
+		}
+	}
+
+	var record answerRecord
+	if err = json.Unmarshal(data, &record); err != nil {
+		t.Fatalf("failed to parse answer log %q: %v", data, err)
+	}
+	votes := make([]int, len(round.Question.Answers))
+	votes[correct], votes[wrong] = 2, 1
+	if record.Question != round.Question.Question || record.Correct != correct || !reflect.DeepEqual(record.Votes, votes) {
+		t.Errorf("expected %q answered %v with %d correct, got %+v", round.Question.Question, votes, correct, record)
+	}
+	if len(record.Choices) != len(round.Question.Answers) || record.Choices[correct] != round.Question.Answers[correct].Value {
+		t.Errorf("expected the choices in the order asked, got %v", record.Choices)
+	}
+}
+
+func TestAnswerFeedback(t *testing.T) {
+	tb, chat := newTestBot(t, WithAnswerFeedback())
+	r := newTestRoom(t, tb, "")
+	newTestQuiz(t, tb, r, 1, 100*time.Millisecond)
+
+	round := startRound(t, tb, r)
+	correct, _ := round.Question.Correct()
+	wrong := (correct + 1) % len(round.Question.Answers)
+	whisper(t, tb, "alice", fmt.Sprint(correct+1))
+	whisper(t, tb, "bob", fmt.Sprint(wrong+1))
+	finishRound(t, r)
+
+	answer, _ := round.Question.Choice(correct)
+	want := map[string]string{
+		"alice": "You got round 1 right, the answer was " + answer,
+		"bob":   "You got round 1 wrong, the answer was " + answer,
+	}
+	got := map[string]string{}
+	for _, msg := range chat.privMessages() {
+		if strings.HasPrefix(msg.msg, "You got") {
+			got[msg.user] = msg.msg
+		}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected feedback %v, got %v", want, got)
+	}
+}
+
+func TestFormatStrengths(t *testing.T) {
+	record := func(category string, correct int64) *trivia.CategoryRecord {
+		return &trivia.CategoryRecord{Category: category, Answered: 4, Correct: correct}
+	}
+
+	for _, tc := range []struct {
+		name    string
+		records []*trivia.CategoryRecord
+		want    string
+	}{
+		{"none", nil, "alice has not answered enough questions of any category yet"},
+		{"one", []*trivia.CategoryRecord{record("Art", 4)}, "alice is best at Art (100% of 4)"},
+		{
+			"few",
+			[]*trivia.CategoryRecord{record("Art", 4), record("Film", 3), record("Math", 1)},
+			"alice is best at Art (100% of 4), Film (75% of 4); worst at Math (25% of 4)",
+		},
+		{
+			"many",
+			[]*trivia.CategoryRecord{
+				record("Art", 4), record("Film", 4), record("Math", 3), record("Music", 2),
+				record("Pets", 2), record("Sports", 1), record("TV", 0),
+			},
+			"alice is best at Art (100% of 4), Film (100% of 4), Math (75% of 4); worst at TV (0% of 4), Sports (25% of 4), Pets (50% of 4)",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := formatStrengths("alice", tc.records); got != tc.want {
+				t.Errorf("expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestManualAdvance(t *testing.T) {
+	tb, chat := newTestBot(t, WithManualAdvance(), WithAdmins("host"), WithCooldownBypass(false, "host"))
+	r := newTestRoom(t, tb, "")
+
+	awaitNext := func() {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for !r.awaitingNext.Load() {
+			if time.Now().After(deadline) {
+				t.Fatal("timed out waiting for the quiz to wait for the host")
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	say(t, tb, "", "host", "trivia start -size 2 -duration 10ms")
+	awaitNext()
+	time.Sleep(50 * time.Millisecond)
+	say(t, tb, "", "alice", "trivia next")
+	time.Sleep(50 * time.Millisecond)
+	if round := r.quiz.CurrentRound(); round.Num != 1 || !r.awaitingNext.Load() {
+		t.Fatalf("expected the quiz to stay after round 1 until the host moves on, got round %d", round.Num)
+	}
+
+	say(t, tb, "", "host", "trivia next")
+	waitForQuiz(t, r)
+	if got := r.quiz.CurrentRound(); got.Num != 2 || !got.Complete {
+		t.Errorf("expected round 2 to be played once the host moved on, got round %d", got.Num)
+	}
+	if got := lastMessage(chat, ""); !strings.HasPrefix(got, "Quiz complete!") {
+		t.Errorf("expected the quiz to complete, got %q", got)
+	}
+
+	// cancelling a quiz waiting for the host stops it
+	say(t, tb, "", "host", "trivia start -size 2 -duration 10ms")
+	awaitNext()
+	r.cancelQuiz()
+	waitForQuiz(t, r)
+	if round := r.quiz.CurrentRound(); round.Num != 1 {
+		t.Errorf("expected the cancelled quiz to stop after round 1, got round %d", round.Num)
+	}
+}
+
+func TestRoundDelayJitter(t *testing.T) {
+	tb, _ := newTestBot(t, WithRoundDelayJitter(0.2))
+	tb.roundDelay = 10 * time.Second
+
+	delays := map[time.Duration]bool{}
+	for i := 0; i < 1000; i++ {
+		delay := tb.nextRoundDelay()
+		if delay < 8*time.Second || delay > 12*time.Second {
+			t.Fatalf("delay %s is outside 20%% of %s", delay, tb.roundDelay)
+		}
+		delays[delay] = true
+	}
+	if len(delays) < 2 {
+		t.Errorf("delay never varied from %v", delays)
+	}
+
+	// the same seed gives the same delays
+	again, _ := newTestBot(t, WithRoundDelayJitter(0.2))
+	again.roundDelay = tb.roundDelay
+	tb.jitterRand = rand.New(rand.NewSource(1))
+	for i := 0; i < 10; i++ {
+		if got, want := again.nextRoundDelay(), tb.nextRoundDelay(); got != want {
+			t.Fatalf("delay %d is %s, want %s", i, got, want)
+		}
+	}
+
+	capped, _ := newTestBot(t, WithRoundDelayJitter(3))
+	capped.roundDelay = tb.roundDelay
+	for i := 0; i < 1000; i++ {
+		if delay := capped.nextRoundDelay(); delay < 5*time.Second || delay > 15*time.Second {
+			t.Fatalf("delay %s is outside the capped jitter", delay)
+		}
+	}
+
+	fixed, _ := newTestBot(t)
+	fixed.roundDelay = tb.roundDelay
+	if delay := fixed.nextRoundDelay(); delay != tb.roundDelay {
+		t.Errorf("delay without jitter is %s, want %s", delay, tb.roundDelay)
+	}
+}
+
+func TestAnswerReveal(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		casing AnswerCase
+		want   string
+	}{
+		{"original", AnswerCaseOriginal, "pARIS de france"},
+		{"lower", AnswerCaseLower, "paris de france"},
+		{"title", AnswerCaseTitle, "Paris De France"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tb, chat := newTestBot(t, WithAnswerCase(tc.casing))
+			tb.source.(*staticSource).question = trivia.Question{
+				Question: "What is the capital of France?",
+				Answers: []*trivia.Answer{
+					{Value: `"pARIS de france"`, Correct: true},
+					{Value: "Lyon"},
+					{Value: "Nice"},
+				},
+			}
+			r := newTestRoom(t, tb, "")
+			newTestQuiz(t, tb, r, 1, 10*time.Millisecond)
+
+			round := startRound(t, tb, r)
+			idx, _ := round.Question.Correct()
+			want := fmt.Sprintf("`%d) %s`", idx+1, tc.want)
+
+			output, err := tb.formatRound(r, round, 0)
+			if err != nil {
+				t.Fatalf("failed to format round: %v", err)
+			}
+			if !strings.Contains(output, want) {
+				t.Errorf("expected the choice shown as %s in %q", want, output)
+			}
+
+			finishRound(t, r)
+			if got := lastMessage(chat, ""); !strings.HasPrefix(got, "Round complete! The correct answer is "+want+".") {
+				t.Errorf("expected the answer revealed as %s, got %q", want, got)
+			}
+		})
+	}
+}
+
+func TestSkipRequeuesQuestion(t *testing.T) {
+	tb, chat := newTestBot(t, WithSkipVotes(2), WithRequeueSkipped(), WithAdmins("host"), WithCooldownBypass(false, "host"))
+	r := newTestRoom(t, tb, "")
+
+	say(t, tb, "", "host", "trivia start -size 2 -duration 500ms")
+	round := waitForRound(t, r)
+	answer(t, tb, r, "alice")
+
+	say(t, tb, "", "alice", "trivia skip")
+	if got := lastMessage(chat, ""); got != "1 of 2 votes to skip the round" {
+		t.Errorf("expected the vote to be counted, got %q", got)
+	}
+	say(t, tb, "", "bob", "trivia skip")
+	<-round.Done()
+	if !round.Skipped() {
+		t.Fatal("expected the round to be skipped once enough players voted")
+	}
+
+	waitForQuiz(t, r)
+	announced := false
+	for _, msg := range chat.messages("") {
+		announced = announced || msg == "Round 1 was skipped, it is asked again as round 3"
+	}
+	if !announced {
+		t.Errorf("expected the skip to be announced without the answer, got %q", chat.messages(""))
+	}
+	if len(r.quiz.Rounds) != 3 {
+		t.Fatalf("expected the skipped question to be asked as an extra round, got %d rounds", len(r.quiz.Rounds))
+	}
+	last := r.quiz.Rounds[2]
+	if last.Num != 3 || !last.Final || !last.Complete || last.Question.Question != round.Question.Question {
+		t.Errorf("expected the skipped question to be asked last, got round %d asking %q", last.Num, last.Question.Question)
+	}
+	if points := r.quiz.Score()["alice"]; points != 0 {
+		t.Errorf("expected the skipped round to score nothing, alice has %d points", points)
+	}
+}
+
+func TestMaxQuizzes(t *testing.T) {
+	tb, chat := newTestBot(t, WithMaxQuizzes(2))
+	rooms := []*room{}
+	for _, channel := range []string{"a", "b", "c"} {
+		rooms = append(rooms, newTestRoom(t, tb, channel))
+	}
+
+	say(t, tb, "a", "alice", "trivia start -size 1 -duration 200ms")
+	say(t, tb, "b", "bob", "trivia start -size 1 -duration 200ms")
+	say(t, tb, "c", "carol", "trivia start -size 1 -duration 200ms")
+	if got := lastMessage(chat, "c"); got != "2 quizzes are already running, try again once one ends" {
+		t.Errorf("expected the third quiz to be rejected, got %q", got)
+	}
+	if rooms[2].running.Load() {
+		t.Error("expected the rejected quiz not to claim its room")
+	}
+
+	waitForQuiz(t, rooms[0])
+	// the room is released just before the count
+	for tb.runningQuizzes.Load() > 1 {
+		time.Sleep(time.Millisecond)
+	}
+	say(t, tb, "c", "carol", "trivia start -size 1 -duration 200ms")
+	if !rooms[2].running.Load() {
+		t.Errorf("expected a quiz to start once another ended, got %q", lastMessage(chat, "c"))
+	}
+	for _, r := range rooms {
+		waitForQuiz(t, r)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if running := tb.runningQuizzes.Load(); running != 0 {
+		t.Errorf("expected no quizzes to be counted as running, got %d", running)
+	}
+}
+
+func TestScoringBreakdown(t *testing.T) {
+	tb, chat := newTestBot(t,
+		WithScoringBreakdown(),
+		WithAllCorrectScoring(trivia.AllCorrectFlat),
+		WithTieScoring(trivia.TiesShared),
+		WithGracePeriod(10*time.Millisecond, 1),
+		WithDoublePoints(1),
+	)
+	r := newTestRoom(t, tb, "")
+
+	say(t, tb, "", "alice", "trivia start -size 1 -duration 10ms")
+	waitForQuiz(t, r)
+
+	want := "Scoring: 1st +6, 2nd +4, 3rd +2, other correct answers +1, +1 each if everyone is correct, ties share their place, late answers +1, double rounds ×2."
+	for _, msg := range chat.messages("") {
+		if strings.HasPrefix(msg, "Quiz starting soon!") {
+			if !strings.HasSuffix(msg, want) {
+				t.Errorf("expected the start message to end in %q, got %q", want, msg)
+			}
+			return
+		}
+	}
+	t.Errorf("expected the quiz to be announced, got %q", chat.messages(""))
+}
+
+func TestSeries(t *testing.T) {
+	for _, tc := range []struct {
+		items []string
+		want  string
+	}{
+		{nil, "none"},
+		{[]string{}, "none"},
+		{[]string{"alice"}, "alice"},
+		{[]string{"alice", "bob"}, "alice and bob"},
+		{[]string{"alice", "bob", "carol"}, "alice, bob, and carol"},
+	} {
+		if got := series(tc.items, "and", "none"); got != tc.want {
+			t.Errorf("series(%q) = %q, want %q", tc.items, got, tc.want)
+		}
+	}
+}
+
+func TestRoundCompleteWinners(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		players []string
+		want    *regexp.Regexp
+	}{
+		{"none", nil, regexp.MustCompile("^Round complete! The correct answer is `\\d\\) Paris`\\. No one answered correctly$")},
+		{"one", []string{"alice"}, regexp.MustCompile("^Round complete! The correct answer is `\\d\\) Paris`\\. \\([\\d.]+m?s to answer\\) 1st alice$")},
+		{"two", []string{"alice", "bob"}, regexp.MustCompile("^Round complete! The correct answer is `\\d\\) Paris`\\. \\([\\d.]+m?s to answer\\) 1st alice and \\(\\+[\\d.]+m?s\\) 2nd bob$")},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tb, chat := newTestBot(t, WithEmotes(Emotes{}))
+			r := newTestRoom(t, tb, "")
+			newTestQuiz(t, tb, r, 1, 50*time.Millisecond)
+
+			startRound(t, tb, r)
+			for _, player := range tc.players {
+				answer(t, tb, r, player)
+				time.Sleep(2 * time.Millisecond)
+			}
+			finishRound(t, r)
+
+			if got := lastMessage(chat, ""); !tc.want.MatchString(got) {
+				t.Errorf("expected the round to complete like %s, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestQuizCompleteTiebreak(t *testing.T) {
+	tb, _ := newTestBot(t)
+	ranking := []*trivia.Score{
+		{Name: "alice", Points: 6, Speed: time.Second},
+		{Name: "bob", Points: 6, Speed: 2 * time.Second},
+	}
+	data := quizCompleteData{Winners: "alice +6 point(s) and bob +6 point(s)", Tiebreak: tiebreak(ranking)}
+
+	output, err := render(tb.announce.quizComplete, data)
+	if err != nil {
+		t.Fatalf("failed to render: %v", err)
+	}
+	want := "Quiz complete! The following users are awarded points: alice +6 point(s) and bob +6 point(s). alice and bob tied on points, alice wins on speed"
+	if output != want {
+		t.Errorf("got %q, want %q", output, want)
+	}
+
+	ranking[1].Points = 4
+	if got := tiebreak(ranking); got != nil {
+		t.Errorf("expected no tiebreak between different points, got %v", got)
+	}
+}
+
+func TestFormatSubmissions(t *testing.T) {
+	questions := models.QuestionSlice{
+		{ID: null.Int64From(3), Removed: "0"},
+		{ID: null.Int64From(2), Removed: "0", Used: 4},
+		{ID: null.Int64From(1), Removed: "1", Used: 1},
+	}
+	want := "alice's questions: #3 not asked yet, #2 asked, #1 removed (latest 3 of 5)"
+	if got := formatSubmissions("alice", 5, questions); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestQuestionCooldown(t *testing.T) {
+	tb, _ := newTestBot(t, WithQuestionCooldown(time.Hour), WithCooldown(0))
+	source := &filterableSource{staticSource: newStaticSource()}
+	source.question.ID = 7
+	tb.source = source
+	r := newTestRoom(t, tb, "")
+
+	play := func() trivia.Filter {
+		t.Helper()
+		say(t, tb, "", "alice", "trivia start -size 1 -duration 10ms")
+		waitForQuiz(t, r)
+
+		source.mu.Lock()
+		defer source.mu.Unlock()
+		if len(source.filters) == 0 {
+			return trivia.Filter{}
+		}
+		return source.filters[len(source.filters)-1]
+	}
+
+	if filter := play(); len(filter.Exclude) != 0 {
+		t.Errorf("expected the first quiz to exclude nothing, got %v", filter.Exclude)
+	}
+	if filter := play(); !reflect.DeepEqual(filter.Exclude, []int64{7}) {
+		t.Errorf("expected the next quiz to exclude the question just asked, got %v", filter.Exclude)
+	}
+
+	// once the window has passed the question may be asked again
+	tb.asked.mu.Lock()
+	tb.asked.ids[7] = time.Now().Add(-2 * time.Hour)
+	tb.asked.mu.Unlock()
+	source.mu.Lock()
+	source.filters = nil
+	source.mu.Unlock()
+	if filter := play(); len(filter.Exclude) != 0 {
+		t.Errorf("expected a question asked before the window to be allowed, got %v", filter.Exclude)
+	}
+}
+
+func TestConfigShow(t *testing.T) {
+	cfg := Config{
+		Cooldown:         Duration(2 * time.Minute),
+		QuestionCooldown: Duration(time.Hour),
+		MaxRoundDuration: Duration(time.Minute),
+		Category:         "Geography",
+		Admins:           []string{"host", "cohost"},
+		Ties:             "shared",
+		DoubleChance:     0.5,
+		Quiet:            true,
+		ManualAdvance:    true,
+	}
+	opts, err := cfg.options()
+	if err != nil {
+		t.Fatalf("failed to build options: %v", err)
+	}
+	tb, chat := newTestBot(t, opts...)
+	newTestRoom(t, tb, "")
+
+	say(t, tb, "", "alice", "trivia config show")
+	if got := lastMessage(chat, ""); got != "only mods can see the settings" {
+		t.Errorf("expected a non-admin to be refused, got %q", got)
+	}
+
+	say(t, tb, "", "host", "trivia config show")
+	want := `Cooldown 2m0s, question cooldown 1h0m0s, rounds 0s to 1m0s, max quiz length unlimited, asking category "Geography" by default. ` +
+		"Scoring: 1st +6, 2nd +4, 3rd +2, other correct answers +1, ties share their place, double rounds ×2. " +
+		"2 admins, 0 judges. Quiet mode on, manual advance on, public answers off"
+	if got := lastMessage(chat, ""); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestPlayers(t *testing.T) {
+	tb, chat := newTestBot(t)
+	r := newTestRoom(t, tb, "")
+
+	say(t, tb, "", "alice", "trivia players")
+	if got := lastMessage(chat, ""); got != "no quiz has been played yet" {
+		t.Errorf("expected no players before a quiz, got %q", got)
+	}
+
+	say(t, tb, "", "alice", "trivia start -size 3 -duration 300ms")
+	for num, users := range [][]string{{"alice", "bob"}, {"bob", "carol"}, {"alice", "carol"}} {
+		for round := waitForRound(t, r); round.Num != num+1; round = waitForRound(t, r) {
+			time.Sleep(time.Millisecond)
+		}
+		for _, user := range users {
+			answer(t, tb, r, user)
+		}
+		if num == 1 {
+			say(t, tb, "", "dave", "trivia players")
+			if got, want := lastMessage(chat, ""), "2 players answered so far"; got != want {
+				t.Errorf("got %q, want %q", got, want)
+			}
+		}
+	}
+	waitForQuiz(t, r)
+
+	if got := lastMessage(chat, ""); !strings.HasPrefix(got, "Quiz complete!") || !strings.Contains(got, " (3 players took part)") {
+		t.Errorf("expected the quiz results to count 3 players, got %q", got)
+	}
+	say(t, tb, "", "dave", "trivia players")
+	if got, want := lastMessage(chat, ""), "3 players answered the last quiz"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
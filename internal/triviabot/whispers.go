@@ -0,0 +1,123 @@
+package triviabot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jbpratt/bots/internal/bot"
+	"github.com/jbpratt/bots/internal/trivia"
+)
+
+// whisperAnswers is the AnswerSource of answers whispered to the bot, handed
+// over by onPrivMsg once it has ruled out commands. Each whisper is read as an
+// answer to the round it is meant for, and its sender is told whether it
+// counted.
+type whisperAnswers struct {
+	t        *TriviaBot
+	whispers chan pendingWhisper
+}
+
+// pendingWhisper is a private message to be read as an answer. The error of
+// replying to it is sent on done.
+type pendingWhisper struct {
+	msg  *bot.Msg
+	done chan error
+}
+
+func newWhisperAnswers(t *TriviaBot) *whisperAnswers {
+	return &whisperAnswers{t: t, whispers: make(chan pendingWhisper)}
+}
+
+func (w *whisperAnswers) Listen(ctx context.Context, deliver func(Submission) error) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case wh := <-w.whispers:
+			wh.done <- w.answer(wh.msg, deliver)
+		}
+	}
+}
+
+// receive hands msg to Listen, returning the error of replying to it. Once the
+// bot stops listening, whispers are ignored.
+func (w *whisperAnswers) receive(ctx context.Context, msg *bot.Msg) error {
+	wh := pendingWhisper{msg: msg, done: make(chan error, 1)}
+	select {
+	case w.whispers <- wh:
+	case <-w.t.listenCtx.Done():
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return <-wh.done
+}
+
+// answer delivers the answer whispered in msg and tells its sender whether it
+// was counted. Answers which can't be read are delivered all the same, so they
+// count towards the answer interval.
+func (w *whisperAnswers) answer(msg *bot.Msg, deliver func(Submission) error) error {
+	t := w.t
+	r, data := t.answerRoom(msg)
+	if r == nil {
+		if data != "" {
+			return t.bot.SendPriv(data, msg.User)
+		}
+		return nil
+	}
+	round := r.activeRound()
+	if round == nil {
+		return nil
+	}
+
+	answer, ok := round.Question.ParseAnswer(data)
+	ambiguous := false
+	if !ok && t.textAnswers {
+		var err error
+		answer, err = round.Question.MatchChoice(data)
+		ambiguous = errors.Is(err, trivia.ErrAmbiguousAnswer)
+		ok = err == nil
+	}
+	if !ok {
+		answer = -1
+	}
+
+	changed := round.HasAnswered(msg.User)
+	err := deliver(Submission{User: msg.User, Channel: r.channel, Choice: answer, Time: msg.Time, room: r})
+	var windowErr *trivia.OutsideWindowError
+	switch {
+	case errors.Is(err, ErrNoRound):
+		return nil
+	case errors.Is(err, ErrStillThrottled):
+		t.logger.Debugw("dropping throttled answer", "user", msg.User)
+		return nil
+	case errors.Is(err, ErrThrottled):
+		return t.bot.SendPriv("Slow down! Answers sent this quickly are ignored", msg.User)
+	case ambiguous:
+		return t.bot.SendPriv(fmt.Sprintf("Invalid answer NOPERS %q matches more than one answer, whisper its number. `/w trivia 2`", data), msg.User)
+	case !ok:
+		hint := "whisper the number of the answer. `/w trivia 2`"
+		if round.Question.Type == "boolean" {
+			hint = "whisper the number of the answer or true/false. `/w trivia true`"
+		}
+		return t.bot.SendPriv("Invalid answer NOPERS "+hint, msg.User)
+	case errors.Is(err, trivia.ErrPaused):
+		return t.bot.SendPriv("The round is paused, answer once it resumes", msg.User)
+	case errors.As(err, &windowErr):
+		t.logger.Infow("rejected answer outside the round", "user", msg.User, "error", err)
+		return t.bot.SendPriv("Your answer arrived outside of the round and was not counted", msg.User)
+	case err != nil:
+		return t.bot.SendPriv("Your answer is invalid or you have already submitted one!", msg.User)
+	}
+
+	// echo the answer as shown, so a mistyped number can be noticed
+	choice := t.answerCase.choice(answer, round.Question.Answers[answer].Value)
+	if changed {
+		return t.bot.SendPriv(fmt.Sprintf("Your answer has been changed to %s", choice), msg.User)
+	}
+	if !round.IsOpen() {
+		return t.bot.SendPriv(fmt.Sprintf("Your answer %s arrived late, a correct one is only worth %d point(s)", choice, t.latePoints), msg.User)
+	}
+	return t.bot.SendPriv(fmt.Sprintf("Your answer %s has been locked in", choice), msg.User)
+}
@@ -5,9 +5,19 @@ import json
 from typing import Any
 from typing import Dict
 from typing import List
+from typing import Optional
 from typing import TextIO
 
-INSERT_LINE = "INSERT OR IGNORE INTO\n\tquestions(question,answer,choices,source,type)\nVALUES\n"
+INSERT_LINE = "INSERT INTO\n\tquestions(question,answer,choices,source,type,category,difficulty,media)\nVALUES\n"
+# refresh metadata on questions which were imported before it was tracked
+UPSERT_LINE = "\nON CONFLICT(question) DO UPDATE SET\n\tcategory = excluded.category,\n\tdifficulty = excluded.difficulty,\n\tmedia = excluded.media"
+
+
+def nullable(value: Optional[str]) -> str:
+    """Quote value as a SQL string, or NULL when it is missing."""
+    if not value:
+        return "NULL"
+    return '"' + value.replace("\"", "\'") + '"'
 
 
 def jackbox_3_murder_gen(output: TextIO) -> None:
@@ -21,7 +31,7 @@ def jackbox_3_murder_gen(output: TextIO) -> None:
         question = row["question"].replace("\"", "\'")
         answer = row["answer"].replace("\"", "\'")
         choices = ",".join(row["options"]).replace("\"", "\'")
-        values.append(f'\t("{question}","{answer}","{choices}","jackbox_3_murder","")')
+        values.append(f'\t("{question}","{answer}","{choices}","jackbox_3_murder","",NULL,NULL,{nullable(row.get("media"))})')
 
     output.write(",\n".join(values))
 
@@ -39,7 +49,7 @@ def millionairedb_gen(output: TextIO) -> None:
         question = row["question"]
         answer = row["answer"]
         choices = ",".join(row["choices"])
-        values.append(f'\t("{question}","{answer}","{choices}","millionairedb","")')
+        values.append(f'\t("{question}","{answer}","{choices}","millionairedb","",NULL,NULL,{nullable(row.get("media"))})')
 
     output.write(",\n".join(values))
 
@@ -54,15 +64,18 @@ def opentdb_gen(output: TextIO) -> None:
     values: List[str] = []
     for row in data.values():
         question_type = row["type"]
+        category = row["category"]
+        difficulty = row["difficulty"]
         question = row["question"]
         correct_answer = row["correct_answer"]
         answers = row["incorrect_answers"]
+        media = nullable(row.get("media"))
 
         answers.append(correct_answer)
         choices = ",".join(answers)
 
         values.append(
-            f'\t("{question}","{correct_answer}","{choices}","opentdb","{question_type}")'
+            f'\t("{question}","{correct_answer}","{choices}","opentdb","{question_type}","{category}","{difficulty}",{media})'
         )
 
     output.write(",\n".join(values))
@@ -77,6 +90,7 @@ def main() -> int:
         opentdb_gen(file)
         file.write(",\n")
         jackbox_3_murder_gen(file)
+        file.write(UPSERT_LINE)
         file.write(";")
 
     return 0